@@ -0,0 +1,69 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+// events - streams mempool events of the network as Server-Sent Events. Supports `kind`, `account`, `entrypoint` and `status` filters.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	network, _, ok := s.network(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	filter := stream.NewFilter(r.URL.Query())
+	filter.Networks = map[string]struct{}{network: {}}
+
+	subscription := s.hub.Subscribe(filter, 0)
+	defer s.hub.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Err(err).Msg("marshaling event")
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
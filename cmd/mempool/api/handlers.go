@@ -0,0 +1,218 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// pagination limits
+const (
+	defaultLimit = 10
+	maxLimit     = 100
+	maxOffset    = 10_000
+)
+
+// Operation - operation group with its status transitions
+type Operation struct {
+	Hash     string                 `json:"hash"`
+	Contents []any                  `json:"contents"`
+	History  []models.StatusHistory `json:"history"`
+}
+
+// Stats - counts of stored operations and fee estimates of the network
+type Stats struct {
+	Operations   []models.OperationsCount `json:"operations"`
+	FeeEstimates []models.FeeEstimate     `json:"fee_estimates"`
+}
+
+// Head - indexer state of the network
+type Head struct {
+	Level     uint64    `json:"level"`
+	Hash      string    `json:"hash"`
+	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+}
+
+// Error -
+type Error struct {
+	Message string `json:"message"`
+}
+
+func (s *Server) operation(w http.ResponseWriter, r *http.Request) {
+	network, kinds, ok := s.network(w, r)
+	if !ok {
+		return
+	}
+	hash := r.PathValue("hash")
+
+	contents, err := models.GetByHash(r.Context(), s.db.DB(), network, hash, kinds...)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	if len(contents) == 0 {
+		writeError(w, http.StatusNotFound, errors.Errorf("operation %s not found", hash))
+		return
+	}
+
+	history, err := models.GetStatusHistory(r.Context(), s.db.DB(), network, hash)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Operation{
+		Hash:     hash,
+		Contents: contents,
+		History:  history,
+	})
+}
+
+func (s *Server) pending(w http.ResponseWriter, r *http.Request) {
+	network, kinds, ok := s.network(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filters := models.PendingFilters{
+		Kinds:       kinds,
+		Source:      query.Get("source"),
+		Destination: query.Get("destination"),
+	}
+	if kind := query.Get("kind"); kind != "" {
+		if !slices.Contains(kinds, kind) {
+			writeError(w, http.StatusBadRequest, errors.Errorf("kind %s is not indexed", kind))
+			return
+		}
+		filters.Kinds = []string{kind}
+	}
+
+	limit, err := intParam(query.Get("limit"), defaultLimit, 1, maxLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "limit"))
+		return
+	}
+	offset, err := intParam(query.Get("offset"), 0, 0, maxOffset)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "offset"))
+		return
+	}
+	filters.Limit = limit
+	filters.Offset = offset
+
+	operations, err := models.GetPending(r.Context(), s.db.DB(), network, filters)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, operations)
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	network, kinds, ok := s.network(w, r)
+	if !ok {
+		return
+	}
+
+	counts, err := models.CountByStatus(r.Context(), s.db.DB(), network, kinds...)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	stats := Stats{
+		Operations: counts,
+	}
+	// fee estimates are calculated only if manager operations are indexed
+	if slices.ContainsFunc(kinds, node.IsManager) {
+		estimates, err := models.GetFeeEstimates(r.Context(), s.db.DB(), network)
+		if err != nil {
+			internalError(w, err)
+			return
+		}
+		stats.FeeEstimates = estimates
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (s *Server) head(w http.ResponseWriter, r *http.Request) {
+	network, _, ok := s.network(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := s.db.State(r.Context(), models.MempoolIndexName(network))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.Errorf("state of %s not found", network))
+			return
+		}
+		internalError(w, err)
+		return
+	}
+
+	head := Head{
+		Level:     state.Level,
+		Hash:      state.Hash,
+		Timestamp: state.Timestamp,
+		Status:    "OK",
+	}
+	if state.Timestamp.Before(time.Now().Add(-3 * time.Minute)) {
+		head.Status = "OUTDATED"
+	}
+	writeJSON(w, http.StatusOK, head)
+}
+
+// network - returns network from the path and its kinds. Writes 404 if the network is not indexed.
+func (s *Server) network(w http.ResponseWriter, r *http.Request) (string, []string, bool) {
+	network := r.PathValue("network")
+	kinds, ok := s.networks[network]
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.Errorf("unknown network: %s", network))
+	}
+	return network, kinds, ok
+}
+
+func intParam(value string, defaultValue, min, max int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if i < min || i > max {
+		return 0, errors.Errorf("should be between %d and %d", min, max)
+	}
+	return i, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Err(err).Msg("writing API response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Message: err.Error()})
+}
+
+func internalError(w http.ResponseWriter, err error) {
+	log.Err(err).Msg("API request")
+	writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+}
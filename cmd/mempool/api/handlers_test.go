@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+)
+
+func TestRequestValidation(t *testing.T) {
+	s := &Server{
+		networks: map[string][]string{
+			"mainnet": {"transaction", "delegation"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{network}/pending", s.pending)
+	mux.HandleFunc("GET /v1/{network}/head", s.head)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{
+			name: "unknown network",
+			url:  "/v1/ghostnet/head",
+			want: http.StatusNotFound,
+		}, {
+			name: "not indexed kind",
+			url:  "/v1/mainnet/pending?kind=reveal",
+			want: http.StatusBadRequest,
+		}, {
+			name: "invalid limit",
+			url:  "/v1/mainnet/pending?limit=abc",
+			want: http.StatusBadRequest,
+		}, {
+			name: "too big limit",
+			url:  "/v1/mainnet/pending?limit=1000",
+			want: http.StatusBadRequest,
+		}, {
+			name: "negative offset",
+			url:  "/v1/mainnet/pending?offset=-1",
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	hub := stream.NewHub()
+	s := &Server{
+		hub:      hub,
+		shutdown: make(chan struct{}),
+		networks: map[string][]string{
+			"mainnet": {"transaction"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{network}/events", s.events)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/v1/mainnet/events?status=applied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if ct := response.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+
+	hub.Publish(
+		stream.Event{Type: stream.EventTypeStatus, Network: "mainnet", Hash: "refused", Status: "refused"},
+		stream.Event{Type: stream.EventTypeOperation, Network: "ghostnet", Hash: "other", Status: "applied"},
+		stream.Event{Type: stream.EventTypeOperation, Network: "mainnet", Hash: "expected", Status: "applied"},
+	)
+
+	reader := bufio.NewReader(response.Body)
+	eventLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventLine != "event: operation\n" {
+		t.Errorf("event line = %q", eventLine)
+	}
+	dataLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dataLine, `"hash":"expected"`) {
+		t.Errorf("data line = %q", dataLine)
+	}
+}
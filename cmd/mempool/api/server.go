@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Config -
+type Config struct {
+	Bind string `validate:"required,hostname_port" yaml:"bind"`
+}
+
+// Server - HTTP API over the stored mempool operations
+type Server struct {
+	db       *database.Bun
+	hub      *stream.Hub
+	networks map[string][]string
+	server   *http.Server
+	shutdown chan struct{}
+	g        workerpool.Group
+}
+
+// New - creates server. `networks` maps indexed network to its operation kinds.
+func New(cfg *Config, db *database.Bun, hub *stream.Hub, networks map[string][]string) (*Server, error) {
+	if cfg == nil {
+		return nil, errors.New("nil api config")
+	}
+	if db == nil {
+		return nil, errors.New("nil database connection")
+	}
+
+	s := &Server{
+		db:       db,
+		hub:      hub,
+		networks: networks,
+		shutdown: make(chan struct{}),
+		g:        workerpool.NewGroup(),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/{network}/operations/{hash}", s.operation)
+	mux.HandleFunc("GET /v1/{network}/pending", s.pending)
+	mux.HandleFunc("GET /v1/{network}/stats", s.stats)
+	mux.HandleFunc("GET /v1/{network}/head", s.head)
+	if hub != nil {
+		mux.HandleFunc("GET /v1/{network}/events", s.events)
+	}
+
+	s.server = &http.Server{
+		Addr:              cfg.Bind,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// streams are endless so they have to be stopped explicitly on shutdown
+	s.server.RegisterOnShutdown(func() {
+		close(s.shutdown)
+	})
+	return s, nil
+}
+
+// Start -
+func (s *Server) Start(ctx context.Context) {
+	log.Info().Str("bind", s.server.Addr).Msg("starting API server...")
+	s.g.GoCtx(ctx, s.listen)
+}
+
+func (s *Server) listen(ctx context.Context) {
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Err(err).Msg("API server")
+	}
+}
+
+// Close -
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+	s.g.Wait()
+	return nil
+}
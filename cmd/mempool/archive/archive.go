@@ -0,0 +1,223 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/export"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultPremake      = 2
+	maintenanceInterval = time.Hour
+)
+
+// Archive - creates time partitions of operation tables in advance and detaches old ones.
+// Detached partitions are optionally exported to parquet files.
+type Archive struct {
+	db           *database.Bun
+	tables       []string
+	period       string
+	premake      int
+	detachAfter  int
+	exportDir    string
+	dropExported bool
+	g            workerpool.Group
+}
+
+// New -
+func New(db *database.Bun, cfg config.Archive, kinds ...string) (*Archive, error) {
+	a := &Archive{
+		db:           db,
+		period:       cfg.Period,
+		premake:      cfg.Premake,
+		detachAfter:  cfg.DetachAfter,
+		exportDir:    cfg.ExportDir,
+		dropExported: cfg.DropExported,
+		g:            workerpool.NewGroup(),
+	}
+	if a.period == "" {
+		a.period = config.PeriodMonth
+	}
+	if a.premake == 0 {
+		a.premake = defaultPremake
+	}
+
+	for _, kind := range kinds {
+		model, err := models.ModelByKind(kind)
+		if err != nil {
+			return nil, err
+		}
+		table := db.DB().Table(reflect.TypeOf(model)).Name
+		if !slices.Contains(a.tables, table) {
+			a.tables = append(a.tables, table)
+		}
+	}
+
+	if a.exportDir != "" {
+		if err := os.MkdirAll(a.exportDir, 0o755); err != nil {
+			return nil, errors.Wrap(err, "create export directory")
+		}
+	}
+	return a, nil
+}
+
+// Init - creates partitions for the current and the next periods. It has to be called before indexers start writing operations.
+func (a *Archive) Init(ctx context.Context) error {
+	return a.createPartitions(ctx, time.Now())
+}
+
+// Start -
+func (a *Archive) Start(ctx context.Context) {
+	a.g.GoCtx(ctx, a.run)
+}
+
+// Close -
+func (a *Archive) Close() error {
+	a.g.Wait()
+	return nil
+}
+
+func (a *Archive) run(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.maintain(ctx, time.Now()); err != nil {
+				log.Err(err).Msg("archive maintenance")
+			}
+		}
+	}
+}
+
+func (a *Archive) maintain(ctx context.Context, now time.Time) error {
+	if err := a.createPartitions(ctx, now); err != nil {
+		return err
+	}
+	if a.detachAfter > 0 {
+		if err := a.detachPartitions(ctx, now); err != nil {
+			return err
+		}
+	}
+	if a.exportDir != "" {
+		return a.exportDetached(ctx)
+	}
+	return nil
+}
+
+// createPartitions - creates partitions for the period containing `now` and `premake` next periods
+func (a *Archive) createPartitions(ctx context.Context, now time.Time) error {
+	current := periodStart(now, a.period)
+	for _, table := range a.tables {
+		for i := 0; i <= a.premake; i++ {
+			start := shift(current, a.period, i)
+			end := shift(current, a.period, i+1)
+			if _, err := a.db.DB().ExecContext(ctx,
+				"CREATE TABLE IF NOT EXISTS ? PARTITION OF ? FOR VALUES FROM (?) TO (?)",
+				bun.Ident(partitionName(table, start)), bun.Ident(table), start.Unix(), end.Unix(),
+			); err != nil {
+				return errors.Wrapf(err, "create partition of %s", table)
+			}
+		}
+	}
+	return nil
+}
+
+// detachPartitions - detaches partitions which started earlier than `detachAfter` periods before the current one
+func (a *Archive) detachPartitions(ctx context.Context, now time.Time) error {
+	threshold := shift(periodStart(now, a.period), a.period, -a.detachAfter)
+	for _, table := range a.tables {
+		var partitions []string
+		if err := a.db.DB().NewSelect().
+			TableExpr("pg_inherits AS i").
+			ColumnExpr("c.relname").
+			Join("JOIN pg_class AS c ON c.oid = i.inhrelid").
+			Join("JOIN pg_class AS p ON p.oid = i.inhparent").
+			Where("p.relname = ?", table).
+			Where("p.relnamespace = current_schema()::regnamespace").
+			Scan(ctx, &partitions); err != nil {
+			return errors.Wrapf(err, "partitions of %s", table)
+		}
+
+		for _, partition := range partitions {
+			start, ok := partitionStart(table, partition)
+			if !ok || !start.Before(threshold) {
+				continue
+			}
+			if _, err := a.db.DB().ExecContext(ctx, "ALTER TABLE ? DETACH PARTITION ?", bun.Ident(table), bun.Ident(partition)); err != nil {
+				return errors.Wrapf(err, "detach partition %s", partition)
+			}
+			log.Info().Str("table", table).Str("partition", partition).Msg("partition was detached")
+		}
+	}
+	return nil
+}
+
+// exportDetached - exports detached partitions which are not exported yet. The partition is dropped after export if `drop_exported` is set.
+func (a *Archive) exportDetached(ctx context.Context) error {
+	for _, table := range a.tables {
+		var detached []string
+		if err := a.db.DB().NewSelect().
+			TableExpr("pg_class AS c").
+			ColumnExpr("c.relname").
+			Where("c.relkind = 'r'").
+			Where("c.relnamespace = current_schema()::regnamespace").
+			Where("c.relname LIKE ?", table+"\\_p%").
+			Where("NOT EXISTS (SELECT 1 FROM pg_inherits AS i WHERE i.inhrelid = c.oid)").
+			Scan(ctx, &detached); err != nil {
+			return errors.Wrapf(err, "detached partitions of %s", table)
+		}
+
+		for _, partition := range detached {
+			if _, ok := partitionStart(table, partition); !ok {
+				continue
+			}
+
+			path := filepath.Join(a.exportDir, partition+".parquet")
+			if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+				if err := exportPartition(ctx, a.db.DB(), path, partition); err != nil {
+					return errors.Wrapf(err, "export %s", partition)
+				}
+				log.Info().Str("partition", partition).Str("file", path).Msg("partition was exported")
+			} else if err != nil {
+				return err
+			}
+
+			if a.dropExported {
+				if _, err := a.db.DB().ExecContext(ctx, "DROP TABLE ?", bun.Ident(partition)); err != nil {
+					return errors.Wrapf(err, "drop partition %s", partition)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// exportPartition - writes the partition to parquet file
+func exportPartition(ctx context.Context, db bun.IDB, path, partition string) error {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM ?", bun.Ident(partition))
+	if err != nil {
+		return errors.Wrapf(err, "select %s", partition)
+	}
+	defer rows.Close()
+
+	if _, err := export.ToFile(path, export.FormatParquet, partition, rows); err != nil {
+		return errors.Wrapf(err, "write %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+)
+
+const partitionDateLayout = "20060102"
+
+// periodStart - returns the beginning of the period which contains `t`. Weeks start on Monday.
+func periodStart(t time.Time, period string) time.Time {
+	t = t.UTC()
+	switch period {
+	case config.PeriodDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case config.PeriodWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// shift - returns the beginning of the period which is `n` periods after the period started at `start`
+func shift(start time.Time, period string, n int) time.Time {
+	switch period {
+	case config.PeriodDay:
+		return start.AddDate(0, 0, n)
+	case config.PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	default:
+		return start.AddDate(0, n, 0)
+	}
+}
+
+// partitionName - returns name of the table partition which starts at `start`
+func partitionName(table string, start time.Time) string {
+	return table + "_p" + start.Format(partitionDateLayout)
+}
+
+// partitionStart - parses the beginning of the partition from its name
+func partitionStart(table, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, table+"_p")
+	if !ok {
+		return time.Time{}, false
+	}
+	start, err := time.Parse(partitionDateLayout, suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return start, true
+}
@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+)
+
+func TestPeriodStart(t *testing.T) {
+	ts := time.Date(2024, time.March, 14, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		period string
+		want   time.Time
+		next   time.Time
+	}{
+		{
+			name:   "day",
+			period: config.PeriodDay,
+			want:   time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC),
+			next:   time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC),
+		}, {
+			name:   "week starts on monday",
+			period: config.PeriodWeek,
+			want:   time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+			next:   time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC),
+		}, {
+			name:   "month",
+			period: config.PeriodMonth,
+			want:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			next:   time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := periodStart(ts, tt.period)
+			if !got.Equal(tt.want) {
+				t.Errorf("periodStart() = %v, want %v", got, tt.want)
+			}
+			if next := shift(got, tt.period, 1); !next.Equal(tt.next) {
+				t.Errorf("shift() = %v, want %v", next, tt.next)
+			}
+		})
+	}
+}
+
+func TestPartitionStart(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	name := partitionName("transactions", start)
+	if name != "transactions_p20240301" {
+		t.Fatalf("partitionName() = %s", name)
+	}
+
+	tests := []struct {
+		name      string
+		table     string
+		partition string
+		ok        bool
+	}{
+		{name: "own partition", table: "transactions", partition: name, ok: true},
+		{name: "partition of another table", table: "reveals", partition: name, ok: false},
+		{name: "not a partition", table: "transactions", partition: "transactions_pending", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := partitionStart(tt.table, tt.partition)
+			if ok != tt.ok {
+				t.Fatalf("partitionStart() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !got.Equal(start) {
+				t.Errorf("partitionStart() = %v, want %v", got, start)
+			}
+		})
+	}
+}
@@ -33,11 +33,7 @@ func (indexer *Indexer) setEndorsementBakers(ctx context.Context) {
 			} else {
 				endorsement.Baker = unknownBaker
 			}
-			if _, err := indexer.db.DB().NewUpdate().
-				Model(endorsement).
-				WherePK().
-				Set("baker = ?", endorsement.Baker).
-				Exec(ctx); err != nil {
+			if err := indexer.db.SetEndorsementBaker(ctx, endorsement); err != nil {
 				log.Err(err).Msg("set baker to endorsement")
 			}
 		}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/pkg/errors"
+)
+
+// default batch settings
+const (
+	defaultBatchSize          = 1000
+	defaultBatchFlushInterval = time.Second
+	maxFlushAttempts          = 5
+	maxFlushBackoff           = time.Minute
+)
+
+// operationBatch - mempool operations which are received but not written yet. Operations of the same type are written by one statement.
+type operationBatch struct {
+	operations []any
+	events     []stream.Event
+	gasStats   []models.GasStats
+	history    []models.StatusHistory
+	suspects   []models.MevSuspect
+	statuses   []statusUpdate
+	// parameters - transactions which parameters are decoded after they're stored, by index of the operation
+	parameters map[int]parametersTask
+}
+
+// statusUpdate - new mempool status of the stored operation. Its events and history row are written only if the operation is found.
+type statusUpdate struct {
+	models.StatusUpdate
+
+	kinds   []string
+	events  []stream.Event
+	history models.StatusHistory
+}
+
+func newOperationBatch(size int) *operationBatch {
+	return &operationBatch{
+		operations: make([]any, 0, size),
+		events:     make([]stream.Event, 0, size),
+		gasStats:   make([]models.GasStats, 0, size),
+		history:    make([]models.StatusHistory, 0, size),
+		suspects:   make([]models.MevSuspect, 0),
+		statuses:   make([]statusUpdate, 0),
+		parameters: make(map[int]parametersTask),
+	}
+}
+
+// addOperation - adds the model and the event which is published if the model is stored
+func (b *operationBatch) addOperation(model any, event stream.Event) {
+	b.operations = append(b.operations, model)
+	b.events = append(b.events, event)
+}
+
+// addParameters - adds decoding task of the last added operation
+func (b *operationBatch) addParameters(task parametersTask) {
+	b.parameters[len(b.operations)-1] = task
+}
+
+func (b *operationBatch) addGasStats(stats models.GasStats) {
+	b.gasStats = append(b.gasStats, stats)
+}
+
+func (b *operationBatch) addHistory(history models.StatusHistory) {
+	b.history = append(b.history, history)
+}
+
+func (b *operationBatch) addStatus(update statusUpdate) {
+	b.statuses = append(b.statuses, update)
+}
+
+func (b *operationBatch) addSuspects(suspects ...models.MevSuspect) {
+	b.suspects = append(b.suspects, suspects...)
+}
+
+// Len - count of the operations and status updates in the batch
+func (b *operationBatch) Len() int {
+	return len(b.operations) + len(b.statuses)
+}
+
+func (b *operationBatch) empty() bool {
+	return b.Len() == 0 && len(b.gasStats) == 0 && len(b.history) == 0 && len(b.suspects) == 0
+}
+
+func (b *operationBatch) reset() {
+	clear(b.operations)
+	b.operations = b.operations[:0]
+	b.events = b.events[:0]
+	b.gasStats = b.gasStats[:0]
+	b.history = b.history[:0]
+	b.suspects = b.suspects[:0]
+	clear(b.statuses)
+	b.statuses = b.statuses[:0]
+	clear(b.parameters)
+}
+
+// writtenBatch - stored operations which are processed further after the transaction is committed
+type writtenBatch struct {
+	endorsements []*models.Endorsement
+	parameters   []parametersTask
+}
+
+// writeBatch - writes the batch in the transaction and enqueues events about stored operations
+func (w *worker) writeBatch(ctx context.Context, tx storage.Tx) (writtenBatch, error) {
+	var written writtenBatch
+	stored, err := tx.SaveOperations(ctx, w.batch.operations...)
+	if err != nil {
+		return written, errors.Wrap(err, "SaveOperations")
+	}
+
+	for i := range stored {
+		if !stored[i] {
+			continue
+		}
+		w.enqueue(w.batch.events[i])
+		if endorsement, ok := w.batch.operations[i].(*models.Endorsement); ok {
+			written.endorsements = append(written.endorsements, endorsement)
+		}
+		if task, ok := w.batch.parameters[i]; ok {
+			written.parameters = append(written.parameters, task)
+		}
+	}
+
+	history, err := w.writeStatuses(ctx, tx)
+	if err != nil {
+		return written, err
+	}
+
+	if err := tx.SaveMempoolGasStats(ctx, w.batch.gasStats...); err != nil {
+		return written, errors.Wrap(err, "SaveMempoolGasStats")
+	}
+	if err := tx.SaveStatusHistory(ctx, history...); err != nil {
+		return written, errors.Wrap(err, "SaveStatusHistory")
+	}
+	if err := tx.SaveMevSuspects(ctx, w.batch.suspects...); err != nil {
+		return written, errors.Wrap(err, "SaveMevSuspects")
+	}
+	return written, nil
+}
+
+// writeStatuses - applies status updates of the batch after its operations are written and enqueues events about found operations.
+// Returns history rows of the batch followed by rows of the found operations.
+func (w *worker) writeStatuses(ctx context.Context, tx storage.Tx) ([]models.StatusHistory, error) {
+	if len(w.batch.statuses) == 0 {
+		return w.batch.history, nil
+	}
+
+	updates := make([]models.StatusUpdate, len(w.batch.statuses))
+	kinds := make([]string, 0)
+	for i := range w.batch.statuses {
+		updates[i] = w.batch.statuses[i].StatusUpdate
+		for _, kind := range w.batch.statuses[i].kinds {
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	found, err := tx.SetStatuses(ctx, w.network, updates, kinds...)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetStatuses")
+	}
+
+	history := make([]models.StatusHistory, len(w.batch.history), len(w.batch.history)+len(updates))
+	copy(history, w.batch.history)
+	for i := range found {
+		if !found[i] {
+			continue
+		}
+		update := w.batch.statuses[i]
+		if w.prom != nil {
+			for _, kind := range update.kinds {
+				w.prom.IncrementCounter(operationCountMetricName, map[string]string{
+					"kind":    kind,
+					"status":  update.Status,
+					"network": w.network,
+				})
+			}
+		}
+		w.enqueue(update.events...)
+		history = append(history, update.history)
+	}
+	return history, nil
+}
+
+// flush - writes the batch by one transaction. If the write failed the batch is kept and retried with backoff.
+// After `maxFlushAttempts` failures the batch is dropped and its operations are forgotten, so they can be stored if they're received again.
+func (w *worker) flush(ctx context.Context) error {
+	if w.batch.empty() {
+		return nil
+	}
+
+	var written writtenBatch
+	if err := w.commit(ctx, func(ctx context.Context, tx storage.Tx) (err error) {
+		written, err = w.writeBatch(ctx, tx)
+		return
+	}); err != nil {
+		w.flushAttempts++
+		if w.flushAttempts < maxFlushAttempts {
+			w.retryAt = time.Now().Add(w.flushBackoff())
+			return errors.Wrapf(err, "flush batch of %d operations, attempt %d", w.batch.Len(), w.flushAttempts)
+		}
+
+		count := w.batch.Len()
+		w.dropBatch()
+		return errors.Wrapf(err, "batch of %d operations is dropped after %d attempts", count, maxFlushAttempts)
+	}
+	w.batch.reset()
+	w.flushAttempts = 0
+	w.retryAt = time.Time{}
+
+	for i := range written.endorsements {
+		w.endorsements <- written.endorsements[i]
+	}
+	w.enqueueParameters(written.parameters...)
+	return nil
+}
+
+// flushBackoff - delay before the next attempt to write the failed batch. It's doubled on every attempt.
+func (w *worker) flushBackoff() time.Duration {
+	backoff := w.flushInterval << w.flushAttempts
+	if backoff <= 0 || backoff > maxFlushBackoff {
+		return maxFlushBackoff
+	}
+	return backoff
+}
+
+// dropBatch - resets the batch and forgets statuses of its operations
+func (w *worker) dropBatch() {
+	for i := range w.batch.events {
+		w.forgetStatus(w.batch.events[i].Hash)
+	}
+	w.batch.reset()
+	w.flushAttempts = 0
+	w.retryAt = time.Time{}
+}
+
+// flushDue - flushes the batch unless the retry of the failed write is postponed
+func (w *worker) flushDue(ctx context.Context) error {
+	if time.Now().Before(w.retryAt) {
+		return nil
+	}
+	return w.flush(ctx)
+}
+
+// flushIfFull - flushes the batch if it reached the size limit
+func (w *worker) flushIfFull(ctx context.Context) error {
+	if w.batch.Len() < w.batchSize {
+		return nil
+	}
+	return w.flushDue(ctx)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/filter"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+)
+
+func newTestWorker(db storage.Storage, batchSize int) *worker {
+	indexer := &Indexer{
+		db:           db,
+		hub:          stream.NewHub(),
+		network:      "mainnet",
+		filters:      config.Filters{Kinds: []string{node.KindTransaction}},
+		accounts:     new(filter.Accounts),
+		transactions: new(filter.Transactions),
+		state:        &database.State{Level: 100},
+		branches:     newBlockQueue(60, nil, nil, nil, nil),
+		batchSize:    batchSize,
+		cache:        NewCache(time.Hour),
+		endorsements: make(chan *models.Endorsement, 16),
+	}
+	return newWorker(indexer, batchSize)
+}
+
+func newAppliedTransaction(hash string, counters ...string) node.Applied {
+	applied := node.Applied{
+		Hash:   hash,
+		Branch: "BLock",
+	}
+	for _, counter := range counters {
+		applied.Contents = append(applied.Contents, node.Content{
+			Kind: node.KindTransaction,
+			Body: []byte(`{"kind":"transaction","source":"tz1","destination":"KT1","counter":"` + counter + `"}`),
+		})
+	}
+	return applied
+}
+
+func TestIndexer_Batch(t *testing.T) {
+	ctx := context.Background()
+	db := storage.NewMemory()
+	indexer := newTestWorker(db, 3)
+	subscription := indexer.hub.Subscribe(stream.Filter{}, 16)
+
+	operations := []node.Applied{
+		newAppliedTransaction("oo1", "1", "2"),
+		newAppliedTransaction("oo2", "3"),
+		newAppliedTransaction("oo3", "4"),
+	}
+	tests := []struct {
+		name   string
+		stored int
+	}{
+		{name: "batch is not full", stored: 0},
+		{name: "batch is full", stored: 3},
+		{name: "new batch", stored: 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := indexer.handleAppliedOperation(ctx, operations[i], receiver.Message{Status: receiver.StatusApplied}); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := db.Operations("mainnet", node.KindTransaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != tt.stored {
+				t.Errorf("stored operations = %d, want %d", len(stored), tt.stored)
+			}
+		})
+	}
+
+	// status update is batched and applied after the pending operation is written
+	if err := indexer.handleStatusUpdate(ctx, "oo3", operations[2].Contents, nil, receiver.Message{Status: receiver.StatusRefused}); err != nil {
+		t.Fatal(err)
+	}
+	if indexer.batch.Len() != 2 {
+		t.Fatalf("batch length = %d, want 2: status update must be batched", indexer.batch.Len())
+	}
+	if err := indexer.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 4 {
+		t.Fatalf("stored operations = %d, want 4", len(stored))
+	}
+	if status := stored[3].(*models.Transaction).Status; status != models.StatusRefused {
+		t.Errorf("status of oo3 = %s, want %s", status, models.StatusRefused)
+	}
+
+	var events int
+	for len(subscription.Events()) > 0 {
+		<-subscription.Events()
+		events++
+	}
+	if events != 5 {
+		t.Errorf("published events = %d, want 5", events)
+	}
+}
+
+func TestIndexer_BatchFiltered(t *testing.T) {
+	ctx := context.Background()
+	db := storage.NewMemory()
+
+	accounts, err := filter.NewAccounts(config.Filters{
+		Kinds: []string{node.KindTransaction},
+		Rules: []config.AccountRule{
+			{Accounts: []string{"tz1watched"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWorker(db, 10)
+	w.accounts = accounts
+	w.hasManager = true
+
+	if err := w.handleAppliedOperation(ctx, newAppliedTransaction("oo1", "1"), receiver.Message{Status: receiver.StatusApplied}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.handleFailedOperation(ctx, node.FailedMonitor{
+		Hash:     "oo2",
+		Contents: newAppliedTransaction("oo2", "2").Contents,
+	}, receiver.Message{Status: receiver.StatusRefused}); err != nil {
+		t.Fatal(err)
+	}
+	if !w.batch.empty() {
+		t.Errorf("batch of filtered operations isn't empty: operations=%d gas_stats=%d history=%d", w.batch.Len(), len(w.batch.gasStats), len(w.batch.history))
+	}
+	for _, hash := range []string{"oo1", "oo2"} {
+		prev, processed := w.swapStatus(hash, string(receiver.StatusBranchDelayed))
+		if !isDuplicate(prev, string(receiver.StatusBranchDelayed), processed) {
+			t.Errorf("next status of filtered operation %s isn't skipped", hash)
+		}
+	}
+}
+
+// failingStorage - fails the first `failures` transactions
+type failingStorage struct {
+	*storage.Memory
+	failures int
+}
+
+func (s *failingStorage) RunInTx(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection refused")
+	}
+	return s.Memory.RunInTx(ctx, fn)
+}
+
+func TestIndexer_BatchRetry(t *testing.T) {
+	ctx := context.Background()
+	db := &failingStorage{Memory: storage.NewMemory(), failures: 1}
+	w := newTestWorker(db, 10)
+	w.flushInterval = time.Second
+
+	if err := w.handleAppliedOperation(ctx, newAppliedTransaction("oo1", "1"), receiver.Message{Status: receiver.StatusApplied}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.flush(ctx); err == nil {
+		t.Fatal("first flush must fail")
+	}
+	if w.batch.Len() != 1 {
+		t.Fatalf("batch length after failed flush = %d, want 1", w.batch.Len())
+	}
+	if err := w.flushDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if w.batch.Len() != 1 {
+		t.Fatalf("batch is flushed before backoff expired")
+	}
+
+	if err := w.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Errorf("stored operations = %d, want 1", len(stored))
+	}
+	if !w.batch.empty() || w.flushAttempts != 0 {
+		t.Errorf("batch isn't reset after successful flush: length=%d attempts=%d", w.batch.Len(), w.flushAttempts)
+	}
+}
+
+func TestIndexer_BatchStatuses(t *testing.T) {
+	ctx := context.Background()
+	db := storage.NewMemory()
+	w := newTestWorker(db, 10)
+	subscription := w.hub.Subscribe(stream.Filter{}, 16)
+
+	applied := newAppliedTransaction("oo1", "1")
+	if err := w.handleAppliedOperation(ctx, applied, receiver.Message{Status: receiver.StatusApplied}); err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []receiver.Status{receiver.StatusBranchDelayed, receiver.StatusRefused} {
+		if err := w.handleStatusUpdate(ctx, "oo1", applied.Contents, nil, receiver.Message{Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the operation isn't stored, so the update is skipped
+	if err := w.handleStatusUpdate(ctx, "oo2", applied.Contents, nil, receiver.Message{Status: receiver.StatusRefused}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := db.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].(*models.Transaction).Status != models.StatusRefused {
+		t.Fatalf("stored operations = %v, want refused oo1", stored)
+	}
+
+	history := db.StatusHistory("mainnet")
+	want := []string{models.StatusApplied, models.StatusBranchDelayed, models.StatusRefused}
+	if len(history) != len(want) {
+		t.Fatalf("history rows = %d, want %d", len(history), len(want))
+	}
+	for i := range want {
+		if history[i].Hash != "oo1" || history[i].Status != want[i] {
+			t.Errorf("history row %d = %s %s, want oo1 %s", i, history[i].Hash, history[i].Status, want[i])
+		}
+	}
+	if events := len(subscription.Events()); events != 3 {
+		t.Errorf("published events = %d, want 3", events)
+	}
+}
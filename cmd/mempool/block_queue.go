@@ -2,39 +2,63 @@ package main
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
 	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+	"github.com/rs/zerolog/log"
 )
 
 // Block -
 type Block struct {
-	Branch    string
-	Level     uint64
-	Type      events.MessageType
-	Timestamp time.Time
+	Branch      string
+	Predecessor string
+	Level       uint64
+	Type        events.MessageType
+	Timestamp   time.Time
 }
 
 func fromMessage(block tzkt.BlockMessage) Block {
 	return Block{
-		Branch:    block.Hash,
-		Level:     block.Level,
-		Type:      block.Type,
-		Timestamp: block.Timestamp.UTC(),
+		Branch:      block.Hash,
+		Predecessor: block.Predecessor,
+		Level:       block.Level,
+		Type:        block.Type,
+		Timestamp:   block.Timestamp.UTC(),
 	}
 }
 
-// BlockQueue -
+func (b Block) model(network string) models.Block {
+	return models.Block{
+		Network:     network,
+		Hash:        b.Branch,
+		Predecessor: b.Predecessor,
+		Level:       b.Level,
+		Timestamp:   b.Timestamp,
+	}
+}
+
+// BlockQueue - is written by the chain goroutine only and is read by mempool goroutines concurrently
 type BlockQueue struct {
 	queue      []Block
 	levels     map[string]uint64
 	onPop      func(ctx context.Context, block Block) error
 	onRollback func(ctx context.Context, block Block) error
+	onReorg    func(ctx context.Context, ancestor Block, orphaned []Block) error
+	getBlock   func(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error)
 	capacity   uint64
+	mx         sync.RWMutex
 }
 
-func newBlockQueue(capacity uint64, onPop func(ctx context.Context, block Block) error, onRollback func(ctx context.Context, block Block) error) *BlockQueue {
+func newBlockQueue(
+	capacity uint64,
+	onPop func(ctx context.Context, block Block) error,
+	onRollback func(ctx context.Context, block Block) error,
+	onReorg func(ctx context.Context, ancestor Block, orphaned []Block) error,
+	getBlock func(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error),
+) *BlockQueue {
 	if capacity == 0 {
 		capacity = 60
 	}
@@ -43,49 +67,208 @@ func newBlockQueue(capacity uint64, onPop func(ctx context.Context, block Block)
 		levels:     make(map[string]uint64),
 		onPop:      onPop,
 		onRollback: onRollback,
+		onReorg:    onReorg,
+		getBlock:   getBlock,
 		capacity:   capacity,
 	}
 }
 
-// Add -
-func (bq *BlockQueue) Add(ctx context.Context, block tzkt.BlockMessage) error {
+// Add - adds data block to the queue and returns added blocks. If the block isn't a child of the tail, blocks above the common ancestor
+// are rolled back first. The ancestor is found by requesting predecessors of the block which are unknown to the queue,
+// the requested blocks are added to the queue before the block. Reorg message rolls back blocks above its level.
+func (bq *BlockQueue) Add(ctx context.Context, block tzkt.BlockMessage) ([]Block, error) {
 	b := fromMessage(block)
 
 	switch block.Type {
 	case events.MessageTypeState:
 	case events.MessageTypeReorg:
-		for item := bq.queue[len(bq.queue)-1]; len(bq.queue) > 0 && bq.queue[len(bq.queue)-1].Level > block.Level; item = bq.queue[len(bq.queue)-1] {
-			if err := bq.onRollback(ctx, item); err != nil {
+		ancestor := Block{Branch: b.Branch, Level: b.Level}
+		return nil, bq.rollback(ctx, ancestor, func(tail Block) bool {
+			return tail.Level > b.Level
+		})
+	case events.MessageTypeData:
+		if bq.Contains(b.Branch) {
+			return nil, nil
+		}
+
+		ancestor, missing, found := bq.commonAncestor(ctx, b)
+		orphaned := func(tail Block) bool {
+			return tail.Branch != ancestor.Branch
+		}
+		if !found {
+			ancestor = Block{Branch: b.Predecessor, Level: b.Level - 1}
+			orphaned = func(tail Block) bool {
+				switch {
+				case tail.Level >= b.Level:
+					return true
+				case b.Predecessor == "" || tail.Branch == b.Predecessor:
+					return false
+				default:
+					// the ancestor is unknown: only the block of the previous level is surely orphaned
+					return tail.Level == b.Level-1
+				}
+			}
+		}
+		if err := bq.rollback(ctx, ancestor, orphaned); err != nil {
+			return nil, err
+		}
+
+		added := make([]Block, 0, len(missing)+1)
+		for i := len(missing) - 1; i >= 0; i-- {
+			added = append(added, missing[i])
+		}
+		added = append(added, b)
+		for i := range added {
+			if err := bq.push(ctx, added[i]); err != nil {
+				return nil, err
+			}
+		}
+		return added, nil
+	}
+
+	return nil, nil
+}
+
+// commonAncestor - walks back from the block by predecessor hashes until the block known to the queue or the level below the queue.
+// Returns the ancestor and blocks between it and the block in descending order. If the block has no predecessor,
+// the chain source can't return a block or the fork is deeper than the queue, `found` is false.
+func (bq *BlockQueue) commonAncestor(ctx context.Context, b Block) (ancestor Block, missing []Block, found bool) {
+	ancestor = Block{Branch: b.Predecessor, Level: b.Level - 1}
+
+	bq.mx.RLock()
+	if len(bq.queue) == 0 {
+		bq.mx.RUnlock()
+		return ancestor, nil, true
+	}
+	lowest := bq.queue[0].Level
+	bq.mx.RUnlock()
+
+	for {
+		switch {
+		case ancestor.Branch == "" || ancestor.Level == 0:
+			return ancestor, nil, false
+		case bq.Contains(ancestor.Branch), ancestor.Level < lowest:
+			return ancestor, missing, true
+		case bq.getBlock == nil || uint64(len(missing)) >= bq.capacity:
+			return ancestor, nil, false
+		}
+
+		parent, err := bq.getBlock(ctx, ancestor.Branch, ancestor.Level)
+		if err != nil {
+			log.Warn().Err(err).Str("hash", ancestor.Branch).Uint64("level", ancestor.Level).Msg("get predecessor of the forked block")
+			return ancestor, nil, false
+		}
+		missing = append(missing, fromMessage(parent))
+		ancestor = Block{Branch: parent.Predecessor, Level: parent.Level - 1}
+	}
+}
+
+// push - appends the block to the queue. The oldest block is popped if the queue is full.
+func (bq *BlockQueue) push(ctx context.Context, b Block) error {
+	if bq.Space() == 0 {
+		bq.mx.Lock()
+		item := bq.queue[0]
+		bq.queue = bq.queue[1:]
+		bq.mx.Unlock()
+		if bq.onPop != nil {
+			if err := bq.onPop(ctx, item); err != nil {
 				return err
 			}
-			bq.queue = bq.queue[:len(bq.queue)-1]
-			delete(bq.levels, item.Branch)
 		}
-	case events.MessageTypeData:
-		if bq.Space() == 0 {
-			item := bq.queue[0]
-			bq.queue = bq.queue[1:]
-			if bq.onPop != nil {
-				if err := bq.onPop(ctx, item); err != nil {
-					return err
-				}
+		bq.mx.Lock()
+		delete(bq.levels, item.Branch)
+		bq.mx.Unlock()
+	}
+	bq.mx.Lock()
+	bq.queue = append(bq.queue, b)
+	bq.levels[b.Branch] = b.Level + bq.capacity
+	bq.mx.Unlock()
+	return nil
+}
+
+// rollback - removes blocks from the tail while `orphaned` returns true for them. If any block is removed, `onReorg` is called
+// with the new tail or with `ancestor` if the queue is empty.
+func (bq *BlockQueue) rollback(ctx context.Context, ancestor Block, orphaned func(tail Block) bool) error {
+	removed := make([]Block, 0)
+	for {
+		tail, ok := bq.tail()
+		if !ok || !orphaned(tail) {
+			break
+		}
+		if bq.onRollback != nil {
+			if err := bq.onRollback(ctx, tail); err != nil {
+				return err
 			}
-			delete(bq.levels, item.Branch)
 		}
-		bq.queue = append(bq.queue, b)
-		bq.levels[b.Branch] = b.Level + bq.capacity
+		bq.mx.Lock()
+		bq.queue = bq.queue[:len(bq.queue)-1]
+		delete(bq.levels, tail.Branch)
+		bq.mx.Unlock()
+		removed = append(removed, tail)
+	}
+	if len(removed) == 0 {
+		return nil
 	}
 
+	if tail, ok := bq.tail(); ok {
+		ancestor = tail
+	}
+	if bq.onReorg != nil {
+		return bq.onReorg(ctx, ancestor, removed)
+	}
 	return nil
 }
 
+// Load - fills the empty queue by stored blocks sorted by level without calling handlers. Returns blocks which don't fit into the queue.
+func (bq *BlockQueue) Load(blocks []models.Block) []models.Block {
+	bq.mx.Lock()
+	defer bq.mx.Unlock()
+
+	var skipped []models.Block
+	if uint64(len(blocks)) > bq.capacity {
+		skipped = blocks[:uint64(len(blocks))-bq.capacity]
+		blocks = blocks[len(skipped):]
+	}
+	for i := range blocks {
+		bq.queue = append(bq.queue, Block{
+			Branch:      blocks[i].Hash,
+			Predecessor: blocks[i].Predecessor,
+			Level:       blocks[i].Level,
+			Type:        events.MessageTypeData,
+			Timestamp:   blocks[i].Timestamp.UTC(),
+		})
+		bq.levels[blocks[i].Hash] = blocks[i].Level + bq.capacity
+	}
+	return skipped
+}
+
+func (bq *BlockQueue) tail() (Block, bool) {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
+	if len(bq.queue) == 0 {
+		return Block{}, false
+	}
+	return bq.queue[len(bq.queue)-1], true
+}
+
+// Empty -
+func (bq *BlockQueue) Empty() bool {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
+	return len(bq.queue) == 0
+}
+
 // Space -
 func (bq *BlockQueue) Space() uint64 {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
 	return bq.capacity - uint64(len(bq.queue))
 }
 
 // ExpirationLevel -
 func (bq *BlockQueue) ExpirationLevel(hash string) uint64 {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
 	level, ok := bq.levels[hash]
 	if ok {
 		return level
@@ -95,6 +278,20 @@ func (bq *BlockQueue) ExpirationLevel(hash string) uint64 {
 
 // Contains -
 func (bq *BlockQueue) Contains(hash string) bool {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
 	_, ok := bq.levels[hash]
 	return ok
 }
+
+// Timestamp - returns timestamp of the block on the level if the block is in the queue
+func (bq *BlockQueue) Timestamp(level uint64) (time.Time, bool) {
+	bq.mx.RLock()
+	defer bq.mx.RUnlock()
+	for i := len(bq.queue) - 1; i >= 0; i-- {
+		if bq.queue[i].Level == level {
+			return bq.queue[i].Timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+)
+
+func TestBlockQueue_Add(t *testing.T) {
+	data := func(level uint64, hash, predecessor string) tzkt.BlockMessage {
+		return tzkt.BlockMessage{Type: events.MessageTypeData, Level: level, Hash: hash, Predecessor: predecessor}
+	}
+	chain := []tzkt.BlockMessage{
+		data(100, "BL100", "BL99"),
+		data(101, "BL101", "BL100"),
+		data(102, "BL102", "BL101"),
+	}
+	// blocks of the other branch which are returned by the chain source
+	fork := map[string]tzkt.BlockMessage{
+		"BL101b": data(101, "BL101b", "BL100"),
+		"BL102b": data(102, "BL102b", "BL101b"),
+		"BL100c": data(100, "BL100c", "BL99c"),
+		"BL101c": data(101, "BL101c", "BL100c"),
+	}
+
+	tests := []struct {
+		name       string
+		block      tzkt.BlockMessage
+		rolledBack []string
+		ancestor   uint64
+		queue      []string
+	}{
+		{
+			name:  "child of the tail",
+			block: data(103, "BL103", "BL102"),
+			queue: []string{"BL100", "BL101", "BL102", "BL103"},
+		}, {
+			name:  "block which is already in the queue",
+			block: data(101, "BL101", "BL100"),
+			queue: []string{"BL100", "BL101", "BL102"},
+		}, {
+			name:       "predecessor is unknown to the chain source",
+			block:      data(103, "BL103x", "BL102x"),
+			rolledBack: []string{"BL102"},
+			ancestor:   101,
+			queue:      []string{"BL100", "BL101", "BL103x"},
+		}, {
+			name:       "two blocks deep fork",
+			block:      data(103, "BL103b", "BL102b"),
+			rolledBack: []string{"BL102", "BL101"},
+			ancestor:   100,
+			queue:      []string{"BL100", "BL101b", "BL102b", "BL103b"},
+		}, {
+			name:       "fork below the queue",
+			block:      data(102, "BL102c", "BL101c"),
+			rolledBack: []string{"BL102", "BL101", "BL100"},
+			ancestor:   99,
+			queue:      []string{"BL100c", "BL101c", "BL102c"},
+		}, {
+			name:       "predecessor is in the queue",
+			block:      data(102, "BL102b", "BL100"),
+			rolledBack: []string{"BL102", "BL101"},
+			ancestor:   100,
+			queue:      []string{"BL100", "BL102b"},
+		}, {
+			name:       "same level without predecessor",
+			block:      data(102, "BL102b", ""),
+			rolledBack: []string{"BL102"},
+			ancestor:   101,
+			queue:      []string{"BL100", "BL101", "BL102b"},
+		}, {
+			name:       "reorg message",
+			block:      tzkt.BlockMessage{Type: events.MessageTypeReorg, Level: 100},
+			rolledBack: []string{"BL102", "BL101"},
+			ancestor:   100,
+			queue:      []string{"BL100"},
+		}, {
+			name:       "reorg below the queue",
+			block:      tzkt.BlockMessage{Type: events.MessageTypeReorg, Level: 90},
+			rolledBack: []string{"BL102", "BL101", "BL100"},
+			ancestor:   90,
+			queue:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			var (
+				rolledBack []string
+				reorgs     int
+				ancestor   Block
+			)
+			bq := newBlockQueue(10, nil, func(ctx context.Context, block Block) error {
+				rolledBack = append(rolledBack, block.Branch)
+				return nil
+			}, func(ctx context.Context, a Block, orphaned []Block) error {
+				reorgs++
+				ancestor = a
+				return nil
+			}, func(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error) {
+				block, ok := fork[hash]
+				if !ok {
+					return tzkt.BlockMessage{}, fmt.Errorf("unknown block %s", hash)
+				}
+				return block, nil
+			})
+			for i := range chain {
+				if _, err := bq.Add(ctx, chain[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if _, err := bq.Add(ctx, tt.block); err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(rolledBack, tt.rolledBack) {
+				t.Errorf("rolledBack = %v, want %v", rolledBack, tt.rolledBack)
+			}
+			switch {
+			case len(tt.rolledBack) == 0 && reorgs != 0:
+				t.Errorf("unexpected reorg to %d", ancestor.Level)
+			case len(tt.rolledBack) > 0 && (reorgs != 1 || ancestor.Level != tt.ancestor):
+				t.Errorf("reorgs = %d, ancestor = %d, want 1 reorg to %d", reorgs, ancestor.Level, tt.ancestor)
+			}
+
+			queue := make([]string, 0)
+			for i := range bq.queue {
+				queue = append(queue, bq.queue[i].Branch)
+			}
+			if !slices.Equal(queue, tt.queue) {
+				t.Errorf("queue = %v, want %v", queue, tt.queue)
+			}
+			for _, hash := range tt.rolledBack {
+				if bq.Contains(hash) {
+					t.Errorf("rolled back block %s is still known", hash)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockQueue_Load(t *testing.T) {
+	blocks := make([]models.Block, 0)
+	for level := uint64(100); level < 105; level++ {
+		blocks = append(blocks, models.Block{Network: "mainnet", Hash: fmt.Sprintf("BL%d", level), Level: level})
+	}
+
+	bq := newBlockQueue(3, nil, nil, nil, nil)
+	skipped := bq.Load(blocks)
+	if len(skipped) != 2 || skipped[0].Hash != "BL100" || skipped[1].Hash != "BL101" {
+		t.Errorf("skipped = %v, want the oldest blocks", skipped)
+	}
+	if bq.Contains("BL101") || !bq.Contains("BL102") || !bq.Contains("BL104") {
+		t.Errorf("unexpected queue: %v", bq.queue)
+	}
+	if level := bq.ExpirationLevel("BL104"); level != 107 {
+		t.Errorf("expiration level = %d, want 107", level)
+	}
+}
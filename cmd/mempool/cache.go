@@ -8,10 +8,15 @@ import (
 	"github.com/dipdup-io/workerpool"
 )
 
+type cacheItem struct {
+	value   string
+	expires int64
+}
+
 // Cache -
 type Cache struct {
 	mux    sync.RWMutex
-	lookup map[string]int64
+	lookup map[string]cacheItem
 	ticker *time.Ticker
 	ttl    time.Duration
 
@@ -21,7 +26,7 @@ type Cache struct {
 // NewCache -
 func NewCache(ttl time.Duration) *Cache {
 	return &Cache{
-		lookup: make(map[string]int64),
+		lookup: make(map[string]cacheItem),
 		ttl:    ttl,
 		ticker: time.NewTicker(time.Minute),
 		g:      workerpool.NewGroup(),
@@ -38,9 +43,48 @@ func (c *Cache) Has(key string) bool {
 
 // Set -
 func (c *Cache) Set(key string) {
+	c.SetValue(key, "")
+}
+
+// Get - returns value stored by key and flag whether the key exists
+func (c *Cache) Get(key string) (string, bool) {
+	c.mux.RLock()
+	item, ok := c.lookup[key]
+	c.mux.RUnlock()
+	return item.value, ok
+}
+
+// SetValue -
+func (c *Cache) SetValue(key, value string) {
+	expires := time.Now().Add(c.ttl).UnixNano()
+	c.mux.Lock()
+	c.lookup[key] = cacheItem{
+		value:   value,
+		expires: expires,
+	}
+	c.mux.Unlock()
+}
+
+// Swap - stores the value by key unless the stored one equals `keep`. Returns the previous value and flag whether the key existed.
+func (c *Cache) Swap(key, value, keep string) (string, bool) {
 	expires := time.Now().Add(c.ttl).UnixNano()
 	c.mux.Lock()
-	c.lookup[key] = expires
+	defer c.mux.Unlock()
+
+	prev, ok := c.lookup[key]
+	if !ok || prev.value != keep {
+		c.lookup[key] = cacheItem{
+			value:   value,
+			expires: expires,
+		}
+	}
+	return prev.value, ok
+}
+
+// Delete -
+func (c *Cache) Delete(key string) {
+	c.mux.Lock()
+	delete(c.lookup, key)
 	c.mux.Unlock()
 }
 
@@ -63,8 +107,8 @@ func (c *Cache) checkExpiration(ctx context.Context) {
 
 		case <-c.ticker.C:
 			c.mux.Lock()
-			for key, expiration := range c.lookup {
-				if time.Now().UnixNano() <= expiration {
+			for key, item := range c.lookup {
+				if time.Now().UnixNano() <= item.expires {
 					continue
 				}
 				delete(c.lookup, key)
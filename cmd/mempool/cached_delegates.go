@@ -4,13 +4,12 @@ import (
 	"context"
 
 	"github.com/dipdup-net/mempool/cmd/mempool/endorsement"
-	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
 )
 
 // CachedDelegates -
 type CachedDelegates struct {
 	Delegates map[string]PublicKey
-	tzkt      *tzkt.TzKT
+	tzkt      ChainSource
 
 	blocksForCycle uint64
 }
@@ -21,7 +20,7 @@ type PublicKey struct {
 	Prefix string
 }
 
-func newCachedDelegates(tzkt *tzkt.TzKT, blocksForCycle uint64) *CachedDelegates {
+func newCachedDelegates(tzkt ChainSource, blocksForCycle uint64) *CachedDelegates {
 	return &CachedDelegates{
 		tzkt:           tzkt,
 		Delegates:      make(map[string]PublicKey),
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/api"
 	"github.com/dipdup-net/mempool/cmd/mempool/profiler"
 )
 
@@ -10,6 +14,9 @@ type Config struct {
 	config.Config `yaml:",inline"`
 	Mempool       Mempool          `validate:"required"       yaml:"mempool"`
 	Profiler      *profiler.Config `yaml:"profiler,omitempty"`
+	API           *api.Config      `yaml:"api,omitempty"`
+	Notifications []*Notification  `validate:"omitempty,dive" yaml:"notifications,omitempty"`
+	Sink          *Sink            `validate:"omitempty"      yaml:"sink,omitempty"`
 }
 
 // Mempool -
@@ -20,43 +27,276 @@ type Mempool struct {
 
 // Indexer -
 type Indexer struct {
-	Filters    Filters           `validate:"required" yaml:"filters"`
-	DataSource MempoolDataSource `validate:"required" yaml:"datasources"`
+	Filters     Filters           `validate:"required"                  yaml:"filters"`
+	DataSource  MempoolDataSource `validate:"required"                  yaml:"datasources"`
+	ChainSource string            `validate:"omitempty,oneof=tzkt node" yaml:"chain_source"`
 }
 
+// chain sources: where blocks, included operations, delegates and rights are received from
+const (
+	ChainSourceTzKT = "tzkt"
+	ChainSourceNode = "node"
+)
+
 // Filters -
 type Filters struct {
-	Accounts []*config.Alias[config.Contract] `validate:"max=50"                                                                                                                                                                                                                                    yaml:"accounts"`
-	Kinds    []string                         `validate:"required,min=1,dive,oneof=activate_account ballot delegation double_baking_evidence double_endorsement_evidence endorsement endorsement_with_slot origination proposals reveal seed_nonce_revelation transaction register_global_constant" yaml:"kinds"`
+	Accounts    []*config.Alias[config.Contract] `validate:"omitempty"                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                     yaml:"accounts"`
+	Rules       []AccountRule                    `validate:"omitempty,dive"                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                yaml:"rules"`
+	Kinds       []string                         `validate:"required,min=1,dive,oneof=activate_account ballot delegation double_baking_evidence double_endorsement_evidence endorsement endorsement_with_slot endorsement_with_dal origination proposals reveal seed_nonce_revelation transaction register_global_constant preendorsement set_deposits_limit double_preendorsement_evidence tx_rollup_origination tx_rollup_submit_batch tx_rollup_commit tx_rollup_return_bond tx_rollup_finalize_commitment tx_rollup_remove_commitment tx_rollup_rejection tx_rollup_dispatch_tickets transfer_ticket vdf_revelation Increase_paid_storage update_consensus_key drain_delegate smart_rollup_add_messages smart_rollup_originate smart_rollup_execute_outbox_message smart_rollup_refute smart_rollup_publish smart_rollup_recover_bond smart_rollup_timeout smart_rollup_cement dal_publish_commitment" yaml:"kinds"`
+	Entrypoints []string                         `validate:"omitempty,dive,required"                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                       yaml:"entrypoints"`
+	Parameters  []ParameterFilter                `validate:"omitempty,dive"                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                                yaml:"parameters"`
 }
 
-// Addresses -
+// AccountRule - matches operations of the kinds by the address in the role. Accounts are addresses, aliases of `contracts`
+// or patterns ending with `*` which match addresses by prefix.
+type AccountRule struct {
+	Kinds    []string `validate:"omitempty,dive,required"                         yaml:"kinds"`
+	Role     string   `validate:"omitempty,oneof=any source destination delegate" yaml:"role"`
+	Accounts []string `validate:"required,min=1,dive,required"                    yaml:"accounts"`
+}
+
+// ParameterFilter - predicate on the value of transaction parameters. `Path` is JSONPath-style path in the Micheline value
+// of the parameters: `$` is the value, `.field` and `[index]` select fields and array items, e.g. `$.args[0].string`.
+type ParameterFilter struct {
+	Path   string   `validate:"required"                                      yaml:"path"`
+	Op     string   `validate:"omitempty,oneof=eq ne in exists gt gte lt lte" yaml:"op"`
+	Values []string `validate:"omitempty"                                     yaml:"values"`
+}
+
+// parameter filter operators
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpIn     = "in"
+	OpExists = "exists"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+)
+
+// roles of the address in the operation
+const (
+	RoleAny         = "any"
+	RoleSource      = "source"
+	RoleDestination = "destination"
+	RoleDelegate    = "delegate"
+)
+
+// maxSubscribedAccounts - the chain source subscribes to operations of every account separately, so long lists are matched in memory only
+const maxSubscribedAccounts = 50
+
+// Addresses - returns addresses which operations of the chain source can be limited by. It's empty if operations of all accounts
+// are needed: the rules contain patterns, don't cover all indexed kinds or there are too many addresses.
 func (f Filters) Addresses() []string {
 	addresses := make([]string, 0)
 	for i := range f.Accounts {
 		addresses = append(addresses, f.Accounts[i].Struct().Address)
 	}
+	if len(f.Rules) == 0 {
+		return addresses
+	}
+	if len(f.Accounts) == 0 {
+		for _, kind := range f.Kinds {
+			if !f.hasRuleFor(kind) {
+				return nil
+			}
+		}
+	}
+	for i := range f.Rules {
+		for _, account := range f.Rules[i].Accounts {
+			if strings.HasSuffix(account, "*") {
+				return nil
+			}
+			addresses = append(addresses, account)
+		}
+	}
+	if len(addresses) > maxSubscribedAccounts {
+		return nil
+	}
 	return addresses
 }
 
+func (f Filters) hasRuleFor(kind string) bool {
+	for i := range f.Rules {
+		if len(f.Rules[i].Kinds) == 0 || slices.Contains(f.Rules[i].Kinds, kind) {
+			return true
+		}
+	}
+	return false
+}
+
 // MempoolDataSource -
 type MempoolDataSource struct {
-	Tzkt *config.Alias[config.DataSource] `validate:"required,url"            yaml:"tzkt"`
-	RPC  *config.Alias[config.DataSource] `validate:"required,min=1,dive,url" yaml:"rpc"`
+	Tzkt *config.Alias[config.DataSource] `validate:"omitempty,url"           yaml:"tzkt"`
+	RPC  RPCDataSources                   `validate:"required,min=1,dive,url" yaml:"rpc"`
 }
 
-// URLs -
+// URL - returns URL of the preferred (first) node
 func (ds MempoolDataSource) URL() string {
-	if ds.RPC == nil {
+	if len(ds.RPC) == 0 || ds.RPC[0] == nil {
 		return ""
 	}
-	return ds.RPC.Struct().URL
+	return ds.RPC[0].Struct().URL
+}
+
+// URLs - returns URLs of all nodes in declaration order
+func (ds MempoolDataSource) URLs() []string {
+	urls := make([]string, 0, len(ds.RPC))
+	for i := range ds.RPC {
+		if ds.RPC[i] == nil {
+			continue
+		}
+		if url := ds.RPC[i].Struct().URL; url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// RPCDataSources - list of node data sources. A single alias is accepted as well for backward compatibility.
+type RPCDataSources []*config.Alias[config.DataSource]
+
+// UnmarshalYAML -
+func (rpc *RPCDataSources) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []*config.Alias[config.DataSource]
+	if err := unmarshal(&list); err == nil {
+		*rpc = list
+		return nil
+	}
+
+	var single config.Alias[config.DataSource]
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*rpc = RPCDataSources{&single}
+	return nil
 }
 
 // Settings -
 type Settings struct {
-	KeepOperations    uint64 `validate:"required,min=1" yaml:"keep_operations_seconds"`
-	ExpiredAfter      uint64 `validate:"required,min=1" yaml:"expired_after_blocks"`
-	KeepInChainBlocks uint64 `validate:"required,min=1" yaml:"keep_in_chain_blocks"`
-	GasStatsLifetime  uint64 `validate:"required,min=1" yaml:"gas_stats_lifetime"`
+	KeepOperations    uint64       `validate:"required,min=1"                  yaml:"keep_operations_seconds"`
+	ExpiredAfter      uint64       `validate:"required,min=1"                  yaml:"expired_after_blocks"`
+	KeepInChainBlocks uint64       `validate:"required,min=1"                  yaml:"keep_in_chain_blocks"`
+	GasStatsLifetime  uint64       `validate:"required,min=1"                  yaml:"gas_stats_lifetime"`
+	RPC               RPC          `validate:"omitempty"                       yaml:"rpc"`
+	FeeEstimator      FeeEstimator `validate:"omitempty"                       yaml:"fee_estimator"`
+	Storage           string       `validate:"omitempty,oneof=postgres memory" yaml:"storage"`
+	Batch             Batch        `validate:"omitempty"                       yaml:"batch"`
+	Workers           int          `validate:"omitempty,min=1"                 yaml:"workers"`
+	Retention         Retention    `validate:"omitempty"                       yaml:"retention"`
+	Archive           Archive      `validate:"omitempty"                       yaml:"archive"`
+	Record            Record       `validate:"omitempty"                       yaml:"record"`
+	Replay            Replay       `validate:"omitempty"                       yaml:"replay"`
+	DecodeMichelson   bool         `validate:"omitempty"                       yaml:"decode_michelson"`
+	Simulation        Simulation   `validate:"omitempty"                       yaml:"simulation"`
+	Mev               Mev          `validate:"omitempty"                       yaml:"mev"`
+}
+
+// storage backends
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// RPC - settings of RPC nodes pool
+type RPC struct {
+	ActiveNodes   int    `validate:"omitempty,min=0" yaml:"active_nodes"`
+	MaxErrors     uint64 `validate:"omitempty,min=1" yaml:"max_errors"`
+	StalledBlocks uint64 `validate:"omitempty,min=1" yaml:"stalled_blocks"`
+}
+
+// Notification - webhook which is called when operation involving any of the accounts changes its status
+type Notification struct {
+	URL        string                           `validate:"required,url"                                                                                 yaml:"url"`
+	Secret     string                           `validate:"omitempty"                                                                                    yaml:"secret"`
+	Accounts   []*config.Alias[config.Contract] `validate:"required,min=1"                                                                               yaml:"accounts"`
+	Networks   []string                         `validate:"omitempty"                                                                                    yaml:"networks"`
+	Statuses   []string                         `validate:"omitempty,dive,oneof=applied branch_delayed branch_refused refused outdated in_chain expired" yaml:"statuses"`
+	MaxRetries uint64                           `validate:"omitempty"                                                                                    yaml:"max_retries"`
+	Timeout    uint64                           `validate:"omitempty"                                                                                    yaml:"timeout_seconds"`
+	Workers    int                              `validate:"omitempty,min=1"                                                                              yaml:"workers"`
+	QueueSize  int                              `validate:"omitempty,min=1"                                                                              yaml:"queue_size"`
+}
+
+// Addresses -
+func (n Notification) Addresses() []string {
+	addresses := make([]string, 0, len(n.Accounts))
+	for i := range n.Accounts {
+		addresses = append(addresses, n.Accounts[i].Struct().Address)
+	}
+	return addresses
+}
+
+// Sink - message broker which processed operations are published to
+type Sink struct {
+	Type          string `validate:"required,oneof=nats" yaml:"type"`
+	URL           string `validate:"required,url"        yaml:"url"`
+	SubjectPrefix string `validate:"omitempty"           yaml:"subject_prefix"`
+	BatchSize     int    `validate:"omitempty,min=1"     yaml:"batch_size"`
+	MaxPayload    int    `validate:"omitempty,min=1024"  yaml:"max_payload_bytes"`
+}
+
+// Batch - settings of mempool operations batching
+type Batch struct {
+	Size          int    `validate:"omitempty,min=1" yaml:"size"`
+	FlushInterval uint64 `validate:"omitempty,min=1" yaml:"flush_interval_ms"`
+}
+
+// Retention - settings of old data cleanup
+type Retention struct {
+	Interval  uint64 `validate:"omitempty,min=1" yaml:"interval_seconds"`
+	BatchSize int    `validate:"omitempty,min=1" yaml:"batch_size"`
+}
+
+// Simulation - settings of simulator which predicts results of applied operations of watched accounts by the node RPC
+type Simulation struct {
+	Enabled bool   `validate:"omitempty"       yaml:"enabled"`
+	Workers int    `validate:"omitempty,min=1" yaml:"workers"`
+	Timeout uint64 `validate:"omitempty,min=1" yaml:"timeout_seconds"`
+}
+
+// Mev - settings of detector of front-running and sandwiching over pending transactions
+type Mev struct {
+	Enabled      bool   `validate:"omitempty"                yaml:"enabled"`
+	WindowBlocks uint64 `validate:"omitempty,min=1"          yaml:"window_blocks"`
+	RateMargin   uint64 `validate:"omitempty,min=1,max=1000" yaml:"rate_margin_percent"`
+	MaxDelay     uint64 `validate:"omitempty,min=1"          yaml:"max_delay_seconds"`
+}
+
+// Archive - settings of archive mode. Operations are never deleted and operation tables are partitioned by creation time.
+type Archive struct {
+	Enabled      bool   `validate:"omitempty"                      yaml:"enabled"`
+	Period       string `validate:"omitempty,oneof=day week month" yaml:"period"`
+	Premake      int    `validate:"omitempty,min=1"                yaml:"premake"`
+	DetachAfter  int    `validate:"omitempty,min=1"                yaml:"detach_after"`
+	ExportDir    string `validate:"omitempty"                      yaml:"export_dir"`
+	DropExported bool   `validate:"omitempty"                      yaml:"drop_exported"`
+}
+
+// archive partition periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Record - settings of data sources recording. Every network is recorded to `<dir>/<network>.jsonl.gz`.
+// Existing recording isn't overwritten unless `Overwrite` is set.
+type Record struct {
+	Dir       string `validate:"omitempty" yaml:"dir"`
+	Overwrite bool   `validate:"omitempty" yaml:"overwrite"`
+}
+
+// Replay - settings of replaying recorded data sources instead of the node and TzKT. Recordings are read from `<dir>/<network>.jsonl.gz`.
+type Replay struct {
+	Dir     string  `validate:"omitempty"       yaml:"dir"`
+	Speed   float64 `validate:"omitempty,min=0" yaml:"speed"`
+	Instant bool    `validate:"omitempty"       yaml:"instant"`
+}
+
+// FeeEstimator - settings of fee estimates calculation
+type FeeEstimator struct {
+	Blocks     []uint64 `validate:"omitempty,dive,min=1"         yaml:"blocks"`
+	Confidence []uint64 `validate:"omitempty,dive,min=1,max=100" yaml:"confidence"`
 }
@@ -14,6 +14,13 @@ func (c *Config) Substitute() error {
 			return err
 		}
 	}
+	for _, notification := range c.Notifications {
+		for i, address := range notification.Accounts {
+			if contract, ok := c.Contracts[address.Name()]; ok {
+				notification.Accounts[i].SetStruct(contract)
+			}
+		}
+	}
 	return nil
 }
 
@@ -25,25 +32,36 @@ func substituteContracts(c *Config, filters *Filters) error {
 		}
 		filters.Accounts[i].SetStruct(contract)
 	}
+	for i := range filters.Rules {
+		for j, account := range filters.Rules[i].Accounts {
+			if contract, ok := c.Contracts[account]; ok {
+				filters.Rules[i].Accounts[j] = contract.Address
+			}
+		}
+	}
 	return nil
 }
 
 func substituteDataSources(c *Config, dataSource *MempoolDataSource) error {
-	if source, ok := c.DataSources[dataSource.Tzkt.Name()]; ok {
-		if source.Kind != DataSourceKindTzKT {
-			return errors.Errorf("Invalid tzkt data source kind. Expected `tzkt`, got `%s`", source.Kind)
+	if dataSource.Tzkt != nil {
+		if source, ok := c.DataSources[dataSource.Tzkt.Name()]; ok {
+			if source.Kind != DataSourceKindTzKT {
+				return errors.Errorf("Invalid tzkt data source kind. Expected `tzkt`, got `%s`", source.Kind)
+			}
+			dataSource.Tzkt.SetStruct(source)
 		}
-		dataSource.Tzkt.SetStruct(source)
 	}
 
-	source, ok := c.DataSources[dataSource.RPC.Name()]
-	if !ok {
-		return errors.Errorf("invalid rpc datasource: %s", dataSource.RPC.Name())
-	}
-	if source.Kind != DataSourceKindNode {
-		return errors.Errorf("Invalid RPC data source kind. Expected `tezos-node`, got `%s`", source.Kind)
+	for i := range dataSource.RPC {
+		source, ok := c.DataSources[dataSource.RPC[i].Name()]
+		if !ok {
+			return errors.Errorf("invalid rpc datasource: %s", dataSource.RPC[i].Name())
+		}
+		if source.Kind != DataSourceKindNode {
+			return errors.Errorf("Invalid RPC data source kind. Expected `tezos-node`, got `%s`", source.Kind)
+		}
+		dataSource.RPC[i].SetStruct(source)
 	}
-	dataSource.RPC.SetStruct(source)
 
 	return nil
 }
@@ -0,0 +1,687 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/mev"
+	"github.com/dipdup-net/mempool/cmd/mempool/michelson"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/simulator"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+)
+
+const e2eTimeout = 5 * time.Second
+
+// e2e - indexer running on fake sources and memory storage
+type e2e struct {
+	t            *testing.T
+	db           *storage.Memory
+	indexer      *Indexer
+	operations   *fakeOperationSource
+	chain        *fakeChainSource
+	cancel       context.CancelFunc
+	expiredAfter uint64
+	kinds        []string
+	rules        []config.AccountRule
+	simulator    OperationSimulator
+	scripts      michelson.ScriptSource
+	mev          bool
+}
+
+func newE2E(t *testing.T, chain *fakeChainSource, expiredAfter uint64, kinds ...string) *e2e {
+	t.Helper()
+
+	e := &e2e{
+		t:            t,
+		db:           storage.NewMemory(),
+		expiredAfter: expiredAfter,
+		kinds:        kinds,
+	}
+	e.start(chain)
+	t.Cleanup(e.stop)
+	return e
+}
+
+// start - starts the indexer on the chain source and a new operation source
+func (e *e2e) start(chain *fakeChainSource) {
+	e.t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.operations = newFakeOperationSource()
+	e.chain = chain
+
+	settings := config.Settings{
+		KeepOperations:    3600,
+		ExpiredAfter:      e.expiredAfter,
+		KeepInChainBlocks: 10,
+		GasStatsLifetime:  3600,
+		Batch:             config.Batch{Size: 1},
+		Workers:           2,
+		Mev:               config.Mev{Enabled: e.mev},
+	}
+	indexerCfg := config.Indexer{
+		Filters: config.Filters{Kinds: e.kinds, Rules: e.rules},
+	}
+	opts := []IndexerOption{
+		WithChainInfo(newFakeChainInfo()),
+		WithOperationSource(e.operations),
+		WithChainSource(e.chain),
+	}
+	if e.simulator != nil {
+		settings.Simulation.Enabled = true
+		opts = append(opts, WithSimulator(e.simulator))
+	}
+	if e.scripts != nil {
+		settings.DecodeMichelson = true
+		opts = append(opts, WithScriptSource(e.scripts))
+	}
+
+	indexer, err := NewIndexer(ctx, "mainnet", indexerCfg, e.db, settings, nil, stream.NewHub(), nil, opts...)
+	if err != nil {
+		cancel()
+		e.t.Fatal(err)
+	}
+	if err := indexer.Start(ctx); err != nil {
+		cancel()
+		e.t.Fatal(err)
+	}
+	e.indexer = indexer
+	e.cancel = cancel
+}
+
+func (e *e2e) stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	e.indexer.Close()
+	e.cancel = nil
+}
+
+// restart - stops the indexer and starts a new one on the same storage
+func (e *e2e) restart(chain *fakeChainSource) {
+	e.t.Helper()
+
+	e.stop()
+	e.start(chain)
+}
+
+func (e *e2e) waitFor(what string, condition func() bool) {
+	e.t.Helper()
+
+	deadline := time.Now().Add(e2eTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			e.t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// block - sends the block and waits until it's added to the block queue
+func (e *e2e) block(level uint64, hash string) {
+	e.t.Helper()
+	e.child(level, hash, "")
+}
+
+// child - sends the block with predecessor and waits until it's added to the block queue
+func (e *e2e) child(level uint64, hash, predecessor string) {
+	e.t.Helper()
+
+	e.chain.blocks <- tzkt.BlockMessage{
+		Type:        events.MessageTypeData,
+		Level:       level,
+		Hash:        hash,
+		Predecessor: predecessor,
+		Timestamp:   time.Now().UTC(),
+	}
+	e.waitFor("block "+hash, func() bool { return e.indexer.branches.Contains(hash) })
+}
+
+// mempool - sends the mempool operation as it is received from the node
+func (e *e2e) mempool(status receiver.Status, body any) {
+	e.operations.operations <- receiver.Message{
+		Status:     status,
+		Body:       body,
+		Node:       "http://node",
+		ReceivedAt: time.Now(),
+	}
+}
+
+// operation - returns stored operation by hash or nil
+func (e *e2e) operation(kind, hash string) *models.MempoolOperation {
+	e.t.Helper()
+
+	stored, err := e.db.Operations("mainnet", kind)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	for i := range stored {
+		var operation *models.MempoolOperation
+		switch typed := stored[i].(type) {
+		case *models.Transaction:
+			operation = &typed.MempoolOperation
+		case *models.Endorsement:
+			operation = &typed.MempoolOperation
+		}
+		if operation != nil && operation.Hash == hash {
+			return operation
+		}
+	}
+	return nil
+}
+
+func (e *e2e) waitStatus(kind, hash, status string) *models.MempoolOperation {
+	e.t.Helper()
+
+	var operation *models.MempoolOperation
+	e.waitFor(hash+" in status "+status, func() bool {
+		operation = e.operation(kind, hash)
+		return operation != nil && operation.Status == status
+	})
+	return operation
+}
+
+func TestE2E_AppliedToInChain(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.block(100, "BL100")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL100"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+
+	e.block(101, "BL101")
+	e.chain.operations <- newOperationMessage(101, "BL101", data.Operation{
+		Hash:  "oo1",
+		Type:  node.KindTransaction,
+		Level: 101,
+	})
+	operation := e.waitStatus(node.KindTransaction, "oo1", models.StatusInChain)
+	if operation.Level != 101 {
+		t.Errorf("level = %d, want 101", operation.Level)
+	}
+	if operation.IncludedAt == 0 {
+		t.Error("included_at is not set")
+	}
+	if level := e.indexer.level(); level != 101 {
+		t.Errorf("indexer level = %d, want 101", level)
+	}
+}
+
+func TestE2E_Expiry(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 2, node.KindTransaction)
+
+	e.block(100, "BL100")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL100"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+
+	e.block(101, "BL101")
+	if operation := e.operation(node.KindTransaction, "oo1"); operation.Status != models.StatusApplied {
+		t.Fatalf("operation is expired too early: %s", operation.Status)
+	}
+	e.block(102, "BL102")
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusExpired)
+
+	// operations with unknown branch aren't stored
+	unknown := newAppliedTransaction("oo2", "2")
+	unknown.Branch = "BL100"
+	e.mempool(receiver.StatusApplied, unknown)
+	known := newAppliedTransaction("oo3", "3")
+	known.Branch = "BL102"
+	e.mempool(receiver.StatusApplied, known)
+	e.waitStatus(node.KindTransaction, "oo3", models.StatusApplied)
+	if operation := e.operation(node.KindTransaction, "oo2"); operation != nil {
+		t.Errorf("operation with expired branch is stored: %s", operation.Status)
+	}
+}
+
+func TestE2E_ReorgRollback(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.block(100, "BL100")
+	e.block(101, "BL101")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+
+	e.chain.blocks <- tzkt.BlockMessage{
+		Type:  events.MessageTypeReorg,
+		Level: 100,
+	}
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusBranchRefused)
+
+	if e.indexer.branches.Contains("BL101") {
+		t.Error("rolled back block is still in the queue")
+	}
+	if !e.indexer.branches.Contains("BL100") {
+		t.Error("block below reorg level is removed from the queue")
+	}
+	e.waitFor("indexer level 100", func() bool { return e.indexer.level() == 100 })
+}
+
+func TestE2E_ForkByPredecessor(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+	events := e.indexer.hub.Subscribe(stream.Filter{}, 16)
+
+	e.child(100, "BL100", "BL99")
+	e.child(101, "BL101", "BL100")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+
+	e.child(101, "BL101b", "BL100")
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusBranchRefused)
+	if e.indexer.branches.Contains("BL101") {
+		t.Error("orphaned block is still in the queue")
+	}
+
+	timeout := time.After(e2eTimeout)
+	for {
+		select {
+		case event := <-events.Events():
+			if event.Type != stream.EventTypeReorg {
+				continue
+			}
+			reorg, ok := event.Data.(stream.Reorg)
+			if event.Level != 100 || event.Hash != "BL100" || !ok || len(reorg.Orphaned) != 1 || reorg.Orphaned[0] != "BL101" {
+				t.Errorf("unexpected reorg event: %+v", event)
+			}
+			e.waitFor("indexer level 101", func() bool { return e.indexer.level() == 101 })
+			return
+		case <-timeout:
+			t.Fatal("timeout waiting for reorg event")
+		}
+	}
+}
+
+func TestE2E_DeepFork(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.child(100, "BL100", "BL99")
+	e.child(101, "BL101", "BL100")
+	e.child(102, "BL102", "BL101")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+	included := newAppliedTransaction("oo2", "2")
+	included.Branch = "BL100"
+	e.mempool(receiver.StatusApplied, included)
+	e.waitStatus(node.KindTransaction, "oo2", models.StatusApplied)
+	e.chain.operations <- newOperationMessage(102, "BL102", data.Operation{
+		Hash:  "oo2",
+		Type:  node.KindTransaction,
+		Level: 102,
+	})
+	e.waitStatus(node.KindTransaction, "oo2", models.StatusInChain)
+
+	// only the head of the new branch is sent, its predecessors are requested from the chain source
+	e.chain.headers.Store("BL101b", tzkt.BlockMessage{Type: events.MessageTypeData, Level: 101, Hash: "BL101b", Predecessor: "BL100"})
+	e.chain.headers.Store("BL102b", tzkt.BlockMessage{Type: events.MessageTypeData, Level: 102, Hash: "BL102b", Predecessor: "BL101b"})
+	e.child(103, "BL103b", "BL102b")
+
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusBranchRefused)
+	operation := e.waitStatus(node.KindTransaction, "oo2", models.StatusApplied)
+	if operation.Level != 0 || operation.IncludedAt != 0 {
+		t.Errorf("inclusion of the returned operation is not reset: level %d, included_at %d", operation.Level, operation.IncludedAt)
+	}
+	for _, hash := range []string{"BL101", "BL102"} {
+		if e.indexer.branches.Contains(hash) {
+			t.Errorf("orphaned block %s is still in the queue", hash)
+		}
+	}
+	for _, hash := range []string{"BL100", "BL101b", "BL102b"} {
+		if !e.indexer.branches.Contains(hash) {
+			t.Errorf("block %s is not in the queue", hash)
+		}
+	}
+
+	blocks, err := e.db.Blocks(context.Background(), "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := make([]string, 0, len(blocks))
+	for i := range blocks {
+		stored = append(stored, blocks[i].Hash)
+	}
+	slices.Sort(stored)
+	if want := []string{"BL100", "BL101b", "BL102b", "BL103b"}; !slices.Equal(stored, want) {
+		t.Errorf("stored blocks = %v, want %v", stored, want)
+	}
+	e.waitFor("indexer level 103", func() bool { return e.indexer.level() == 103 })
+}
+
+func TestE2E_PendingBranch(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.block(100, "BL100")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitFor("pending operation", func() bool { return e.indexer.pending.Len() == 1 })
+
+	e.block(101, "BL101")
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+}
+
+func TestE2E_PendingStale(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.block(100, "BL100")
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	e.waitFor("pending operation", func() bool { return e.indexer.pending.Len() == 1 })
+
+	delayed := node.FailedMonitor{
+		Hash:     applied.Hash,
+		Branch:   applied.Branch,
+		Contents: applied.Contents,
+	}
+	e.mempool(receiver.StatusBranchDelayed, delayed)
+	e.waitFor("stale pending operation is dropped", func() bool { return e.indexer.pending.Len() == 0 })
+
+	e.block(101, "BL101")
+	e.mempool(receiver.StatusBranchDelayed, delayed)
+	e.waitStatus(node.KindTransaction, "oo1", models.StatusBranchDelayed)
+}
+
+func TestE2E_RestoreBlocks(t *testing.T) {
+	e := newE2E(t, newFakeChainSource(), 60, node.KindTransaction)
+
+	e.child(100, "BL100", "BL99")
+	e.child(101, "BL101", "BL100")
+	e.restart(newFakeChainSource())
+
+	for _, hash := range []string{"BL100", "BL101"} {
+		if !e.indexer.branches.Contains(hash) {
+			t.Errorf("block %s isn't restored", hash)
+		}
+	}
+	if level := e.indexer.branches.ExpirationLevel("BL101"); level != 161 {
+		t.Errorf("expiration level = %d, want 161", level)
+	}
+
+	applied := newAppliedTransaction("oo1", "1")
+	applied.Branch = "BL101"
+	e.mempool(receiver.StatusApplied, applied)
+	operation := e.waitStatus(node.KindTransaction, "oo1", models.StatusApplied)
+	if operation.ExpirationLevel == nil || *operation.ExpirationLevel != 161 {
+		t.Errorf("expiration level of the operation = %v, want 161", operation.ExpirationLevel)
+	}
+
+	// the restored tail is the predecessor of the next block, so it's not a fork
+	e.child(102, "BL102", "BL101")
+	e.waitFor("indexer level 102", func() bool { return e.indexer.level() == 102 })
+	if !e.indexer.branches.Contains("BL101") {
+		t.Error("restored block is rolled back")
+	}
+}
+
+func TestE2E_EndorsementBaker(t *testing.T) {
+	const (
+		branch    = "BMbpxQAU7Jat7g9ZnKrP3brgqFX6r2VX8PPXCxNbFZeURA6DbEF"
+		signature = "siggEYDRoz7tiECt2fc1M75ieJNeVAP6MGHLhpyPpPue8EU3QYjYSJLnDoDPgxkmrjr6R33qGrAxLASwkyQqa1r3tc5mGPwT"
+		baker     = "tz1baker"
+	)
+
+	chain := newFakeChainSource()
+	chain.delegates = []data.Delegate{
+		{Address: "tz1other", PublicKey: "sppk7bMuoa8w2LSKz3XEuPsKx1WavsMLCWgbWG9CZNAsJg9eTmkXRPd"},
+		{Address: baker, PublicKey: "edpkuEhzJqdFBCWMw6TU3deADRK2fq3GuwWFUphwyH7ero1Na4oGFP"},
+	}
+	chain.rights[751292] = []data.Right{
+		{Baker: data.Address{Address: baker}, Slots: 3},
+		{Baker: data.Address{Address: "tz1other"}, Slots: 5},
+	}
+	e := newE2E(t, chain, 60, node.KindEndorsement)
+
+	e.block(751293, branch)
+	e.mempool(receiver.StatusRefused, node.FailedMonitor{
+		Hash:      "ooEndorsement",
+		Branch:    branch,
+		Signature: signature,
+		Contents: []node.Content{{
+			Kind: node.KindEndorsement,
+			Body: []byte(`{"kind":"endorsement","level":751292}`),
+		}},
+		Error: []byte(`[{"kind":"temporary","id":"proto.alpha.validate.consensus_operation_for_old_level"}]`),
+	})
+	e.waitStatus(node.KindEndorsement, "ooEndorsement", models.StatusRefused)
+
+	e.waitFor("endorsement baker", func() bool {
+		stored, err := e.db.Operations("mainnet", node.KindEndorsement)
+		if err != nil || len(stored) != 1 {
+			return false
+		}
+		return stored[0].(*models.Endorsement).Baker != ""
+	})
+	stored, err := e.db.Operations("mainnet", node.KindEndorsement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored[0].(*models.Endorsement).Baker; got != baker {
+		t.Errorf("baker = %s, want %s", got, baker)
+	}
+}
+
+// fakeSimulator - predicts that every operation is applied and records simulated hashes
+type fakeSimulator struct {
+	hashes chan string
+}
+
+func (f *fakeSimulator) Simulate(ctx context.Context, operation simulator.Operation) (models.Simulation, error) {
+	f.hashes <- operation.Hash
+	return models.Simulation{
+		Hash:             operation.Hash,
+		Status:           simulator.StatusApplied,
+		ConsumedMilligas: 1000,
+	}, nil
+}
+
+func TestE2E_Simulation(t *testing.T) {
+	fake := &fakeSimulator{hashes: make(chan string, 16)}
+	e := &e2e{
+		t:            t,
+		db:           storage.NewMemory(),
+		expiredAfter: 60,
+		kinds:        []string{node.KindTransaction},
+		rules:        []config.AccountRule{{Role: config.RoleSource, Accounts: []string{"tz1watched"}}},
+		simulator:    fake,
+	}
+	e.start(newFakeChainSource())
+	t.Cleanup(e.stop)
+
+	e.block(100, "BL100")
+	watched := node.Applied{
+		Hash:   "ooWatched",
+		Branch: "BL100",
+		Contents: []node.Content{{
+			Kind: node.KindTransaction,
+			Body: []byte(`{"kind":"transaction","source":"tz1watched","destination":"KT1","counter":"1"}`),
+		}},
+	}
+	other := newAppliedTransaction("ooOther", "2")
+	other.Branch = "BL100"
+	e.mempool(receiver.StatusApplied, other)
+	e.mempool(receiver.StatusApplied, watched)
+
+	e.waitFor("simulation", func() bool { return len(e.db.Simulations("mainnet")) == 1 })
+	simulation := e.db.Simulations("mainnet")[0]
+	if simulation.Hash != "ooWatched" || simulation.Level != 100 || simulation.Status != simulator.StatusApplied {
+		t.Errorf("unexpected simulation: %+v", simulation)
+	}
+	if len(fake.hashes) != 1 {
+		t.Errorf("simulated operations = %d, want 1", len(fake.hashes))
+	}
+}
+
+func TestE2E_FrontRunning(t *testing.T) {
+	e := &e2e{
+		t:            t,
+		db:           storage.NewMemory(),
+		expiredAfter: 60,
+		kinds:        []string{node.KindTransaction},
+		mev:          true,
+	}
+	e.start(newFakeChainSource())
+	t.Cleanup(e.stop)
+
+	swap := func(hash, source, fee string) node.Applied {
+		return node.Applied{
+			Hash:   hash,
+			Branch: "BL100",
+			Contents: []node.Content{{
+				Kind: node.KindTransaction,
+				Body: []byte(`{"kind":"transaction","source":"` + source + `","destination":"KT1dex","fee":"` + fee + `","counter":"1","gas_limit":"1000",
+					"parameters":{"entrypoint":"swap","value":{"int":"1"}}}`),
+			}},
+		}
+	}
+
+	e.block(100, "BL100")
+	e.mempool(receiver.StatusApplied, swap("ooVictim", "tz1victim", "1000"))
+	e.waitStatus(node.KindTransaction, "ooVictim", models.StatusApplied)
+	e.mempool(receiver.StatusApplied, swap("ooFront", "tz1attacker", "5000"))
+
+	e.waitFor("suspect", func() bool { return len(e.db.MevSuspects("mainnet")) == 1 })
+	suspect := e.db.MevSuspects("mainnet")[0]
+	if suspect.Kind != mev.KindFrontRunning || suspect.Victim != "ooVictim" || suspect.Front != "ooFront" || suspect.Entrypoint != "swap" {
+		t.Errorf("unexpected suspect: %+v", suspect)
+	}
+}
+
+// fakeScriptSource - returns the script of the contract taking a nat parameter after release
+type fakeScriptSource struct {
+	release chan struct{}
+}
+
+func (f *fakeScriptSource) ContractScript(ctx context.Context, blockID, contract string) (node.Script, error) {
+	select {
+	case <-ctx.Done():
+		return node.Script{}, ctx.Err()
+	case <-f.release:
+	}
+	return node.Script{
+		Code: []byte(`[{"prim":"parameter","args":[{"prim":"nat","annots":["%amount"]}]},{"prim":"storage","args":[{"prim":"unit"}]},{"prim":"code","args":[[]]}]`),
+	}, nil
+}
+
+func TestE2E_DecodeParametersLater(t *testing.T) {
+	scripts := &fakeScriptSource{release: make(chan struct{})}
+	e := &e2e{
+		t:            t,
+		db:           storage.NewMemory(),
+		expiredAfter: 60,
+		kinds:        []string{node.KindTransaction},
+		scripts:      scripts,
+	}
+	e.start(newFakeChainSource())
+	t.Cleanup(e.stop)
+
+	e.block(100, "BL100")
+	e.mempool(receiver.StatusApplied, node.Applied{
+		Hash:   "ooCall",
+		Branch: "BL100",
+		Contents: []node.Content{{
+			Kind: node.KindTransaction,
+			Body: []byte(`{"kind":"transaction","source":"tz1source","destination":"KT1contract","counter":"1",
+				"parameters":{"entrypoint":"default","value":{"int":"5"}}}`),
+		}},
+	})
+
+	// the script source is blocked, so the transaction is stored without decoded parameters
+	e.waitStatus(node.KindTransaction, "ooCall", models.StatusApplied)
+	if decoded := e.parametersDecoded("ooCall"); decoded != nil {
+		t.Fatalf("parameters are decoded before the script is received: %s", decoded)
+	}
+
+	close(scripts.release)
+	e.waitFor("decoded parameters", func() bool { return e.parametersDecoded("ooCall") != nil })
+	if got := string(e.parametersDecoded("ooCall")); got != `"5"` {
+		t.Errorf("parameters_decoded = %s, want \"5\"", got)
+	}
+}
+
+// parametersDecoded - returns decoded parameters of the stored transaction
+func (e *e2e) parametersDecoded(hash string) models.JSONB {
+	e.t.Helper()
+
+	stored, err := e.db.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	for i := range stored {
+		if transaction, ok := stored[i].(*models.Transaction); ok && transaction.Hash == hash {
+			return transaction.ParametersDecoded
+		}
+	}
+	return nil
+}
+
+func TestE2E_FilteredStatuses(t *testing.T) {
+	e := &e2e{
+		t:            t,
+		db:           storage.NewMemory(),
+		expiredAfter: 60,
+		kinds:        []string{node.KindTransaction},
+		rules:        []config.AccountRule{{Role: config.RoleSource, Accounts: []string{"tz1watched"}}},
+	}
+	e.start(newFakeChainSource())
+	t.Cleanup(e.stop)
+
+	e.block(100, "BL100")
+	watched := node.Applied{
+		Hash:   "ooWatched",
+		Branch: "BL100",
+		Contents: []node.Content{{
+			Kind: node.KindTransaction,
+			Body: []byte(`{"kind":"transaction","source":"tz1watched","destination":"KT1","counter":"1"}`),
+		}},
+	}
+	other := newAppliedTransaction("ooOther", "2")
+	other.Branch = "BL100"
+
+	e.mempool(receiver.StatusApplied, other)
+	e.mempool(receiver.StatusApplied, watched)
+	e.waitStatus(node.KindTransaction, "ooWatched", models.StatusApplied)
+
+	for _, applied := range []node.Applied{other, watched} {
+		e.mempool(receiver.StatusRefused, node.FailedMonitor{
+			Hash:     applied.Hash,
+			Branch:   applied.Branch,
+			Contents: applied.Contents,
+		})
+	}
+	e.waitStatus(node.KindTransaction, "ooWatched", models.StatusRefused)
+	if err := e.indexer.barrier(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if status, _ := e.indexer.cache.Get("hash:ooOther"); status != statusFiltered {
+		t.Errorf("cached status of filtered operation = %q, want %q", status, statusFiltered)
+	}
+	for _, history := range e.db.StatusHistory("mainnet") {
+		if history.Hash == "ooOther" {
+			t.Errorf("status of filtered operation is recorded: %+v", history)
+		}
+	}
+	if operation := e.operation(node.KindTransaction, "ooOther"); operation != nil {
+		t.Errorf("filtered operation is stored: %+v", operation)
+	}
+}
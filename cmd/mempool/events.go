@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/pkg/errors"
+)
+
+// eventFields - fields of operation content which are used by stream filters
+type eventFields struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Parameters  *struct {
+		Entrypoint string `json:"entrypoint"`
+	} `json:"parameters,omitempty"`
+}
+
+func (indexer *Indexer) newEvent(typ stream.EventType, hash, status string, content node.Content) stream.Event {
+	event := stream.Event{
+		Type:      typ,
+		Network:   indexer.network,
+		Hash:      hash,
+		Kind:      content.Kind,
+		Status:    status,
+		Level:     indexer.level(),
+		Timestamp: time.Now().UTC(),
+	}
+
+	if len(content.Body) > 0 {
+		var fields eventFields
+		if err := json.Unmarshal(content.Body, &fields); err == nil {
+			event.Source = fields.Source
+			event.Destination = fields.Destination
+			if fields.Parameters != nil {
+				event.Entrypoint = fields.Parameters.Entrypoint
+			}
+		}
+	}
+	return event
+}
+
+// enqueue - adds event which will be published after the current transaction is committed
+func (w *worker) enqueue(events ...stream.Event) {
+	w.events = append(w.events, events...)
+}
+
+// runInTx - flushes pending batch of mempool operations to keep the order of writes and runs `fn` in transaction.
+// `fn` isn't run if the batch wasn't written: it may rely on rows of the batch.
+func (w *worker) runInTx(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) error {
+	if err := w.flush(ctx); err != nil {
+		return errors.Wrap(err, "flush")
+	}
+	return w.commit(ctx, fn)
+}
+
+// commit - runs `fn` in database transaction and publishes events enqueued by `fn` if the transaction is committed.
+// If sink is enabled the events are written to its outbox in the same transaction.
+func (w *worker) commit(ctx context.Context, fn func(ctx context.Context, tx storage.Tx) error) error {
+	w.events = w.events[:0]
+	defer func() {
+		w.events = w.events[:0]
+	}()
+
+	if err := w.db.RunInTx(ctx, func(ctx context.Context, tx storage.Tx) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		if w.outbox == nil {
+			return nil
+		}
+		return w.outbox.Write(ctx, tx, w.events...)
+	}); err != nil {
+		return err
+	}
+	w.hub.Publish(w.events...)
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/uptrace/bun"
+
+	libCfg "github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/export"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+)
+
+// exportOptions - command line arguments of `export` command
+type exportOptions struct {
+	network string
+	kinds   []string
+	from    string
+	to      string
+	format  string
+	out     string
+}
+
+func newExportCommand() *cobra.Command {
+	var opts exportOptions
+	cmd := &cobra.Command{
+		Use:          "export",
+		Short:        "Export mempool tables of the network to files",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath, err := cmd.Flags().GetString("config")
+			if err != nil {
+				return err
+			}
+			var cfg config.Config
+			if err := libCfg.Parse(configPath, &cfg); err != nil {
+				return errors.Wrap(err, "parse config")
+			}
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			return runExport(ctx, cfg, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.network, "network", "n", "", "network to export")
+	cmd.Flags().StringSliceVarP(&opts.kinds, "kinds", "k", nil, "operation kinds to export. Kinds of the network indexer are exported by default")
+	cmd.Flags().StringVar(&opts.from, "from", "", "export rows created since the time (RFC3339, date or UNIX seconds)")
+	cmd.Flags().StringVar(&opts.to, "to", "", "export rows created before the time (RFC3339, date or UNIX seconds)")
+	cmd.Flags().StringVarP(&opts.format, "format", "f", export.FormatParquet, "output format: parquet, csv or jsonl")
+	cmd.Flags().StringVarP(&opts.out, "out", "o", ".", "output directory")
+	_ = cmd.MarkFlagRequired("network")
+	return cmd
+}
+
+// exportTable - table or view which is exported with its time column
+type exportTable struct {
+	name  string
+	query *bun.SelectQuery
+	field string
+}
+
+func runExport(ctx context.Context, cfg config.Config, opts exportOptions) error {
+	from, err := parseExportTime(opts.from)
+	if err != nil {
+		return errors.Wrap(err, "from")
+	}
+	to, err := parseExportTime(opts.to)
+	if err != nil {
+		return errors.Wrap(err, "to")
+	}
+	if err := export.ValidateFormat(opts.format); err != nil {
+		return err
+	}
+
+	kinds := opts.kinds
+	if len(kinds) == 0 {
+		indexer, ok := cfg.Mempool.Indexers[opts.network]
+		if !ok {
+			return errors.Errorf("unknown network: %s", opts.network)
+		}
+		kinds = indexer.Filters.Kinds
+	}
+
+	db := database.NewBun()
+	if err := db.Connect(ctx, cfg.Database); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := os.MkdirAll(opts.out, 0o755); err != nil {
+		return err
+	}
+
+	tables := make([]exportTable, 0, len(kinds)+2)
+	for _, kind := range kinds {
+		model, err := models.ModelByKind(kind)
+		if err != nil {
+			return err
+		}
+		name := db.DB().Table(reflect.TypeOf(model)).Name
+		if slices.ContainsFunc(tables, func(table exportTable) bool { return table.name == name }) {
+			continue
+		}
+		tables = append(tables, exportTable{
+			name:  name,
+			query: db.DB().NewSelect().Model(model),
+			field: "created_at",
+		})
+	}
+	tables = append(tables,
+		exportTable{
+			name:  "gas_stats",
+			query: db.DB().NewSelect().Model((*models.GasStats)(nil)),
+			field: "updated_at",
+		},
+		exportTable{
+			name:  "operation_groups",
+			query: db.DB().NewSelect().TableExpr("operation_groups"),
+			field: "created_at",
+		},
+	)
+
+	for _, table := range tables {
+		var exists bool
+		if err := db.DB().NewSelect().ColumnExpr("to_regclass(?) IS NOT NULL", table.name).Scan(ctx, &exists); err != nil {
+			return err
+		}
+		if !exists {
+			log.Warn().Str("table", table.name).Msg("table doesn't exist and is skipped")
+			continue
+		}
+
+		query := table.query.Where("network = ?", opts.network)
+		if from > 0 {
+			query = query.Where("? >= ?", bun.Ident(table.field), from)
+		}
+		if to > 0 {
+			query = query.Where("? < ?", bun.Ident(table.field), to)
+		}
+
+		path := filepath.Join(opts.out, table.name+export.Extension(opts.format))
+		count, err := exportQuery(ctx, query, opts.format, table.name, path)
+		if err != nil {
+			return errors.Wrapf(err, "export %s", table.name)
+		}
+		log.Info().Str("table", table.name).Int("rows", count).Str("file", path).Msg("exported")
+	}
+	return nil
+}
+
+// exportQuery - streams rows of the query to the file
+func exportQuery(ctx context.Context, query *bun.SelectQuery, format, name, path string) (int, error) {
+	rows, err := query.Rows(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	return export.ToFile(path, format, name, rows)
+}
+
+// parseExportTime - parses time in RFC3339, date or UNIX seconds format to UNIX seconds. Empty string is zero.
+func parseExportTime(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ts, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, errors.Errorf("invalid time: %s", value)
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"database/sql"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// formats
+const (
+	FormatParquet = "parquet"
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+)
+
+// Column - column of the exported table
+type Column struct {
+	Name string
+	Type string
+}
+
+// Writer - writes rows in the file format
+type Writer interface {
+	Write(values []any) error
+	Close() error
+}
+
+// ValidateFormat - returns error if the format is not supported
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatParquet, FormatCSV, FormatJSONL:
+		return nil
+	default:
+		return errors.Errorf("unknown export format: %s", format)
+	}
+}
+
+// NewWriter - creates writer of the format. `name` is the name of the exported table.
+func NewWriter(format string, w io.Writer, name string, columns []Column) (Writer, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatParquet:
+		return newParquetWriter(w, name, columns), nil
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	default:
+		return newJSONLWriter(w, columns), nil
+	}
+}
+
+// Columns - returns columns of the query result
+func Columns(rows *sql.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, len(types))
+	for i := range types {
+		columns[i] = Column{
+			Name: types[i].Name(),
+			Type: types[i].DatabaseTypeName(),
+		}
+	}
+	return columns, nil
+}
+
+// Rows - streams the query result to `w` in the format. Returns count of written rows.
+func Rows(format string, w io.Writer, name string, rows *sql.Rows) (int, error) {
+	columns, err := Columns(rows)
+	if err != nil {
+		return 0, err
+	}
+	writer, err := NewWriter(format, w, name, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var count int
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		if err := writer.Write(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
+
+// ToFile - streams the query result to the file at `path`. The file is written under temporary name
+// and renamed after it's completed, so incomplete files never appear at `path`. Returns count of written rows.
+func ToFile(path, format, name string, rows *sql.Rows) (int, error) {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	count, err := Rows(format, file, name, rows)
+	if err != nil {
+		file.Close()
+		return count, err
+	}
+	if err := file.Close(); err != nil {
+		return count, err
+	}
+	return count, os.Rename(tmp, path)
+}
+
+// Extension - returns file extension of the format
+func Extension(format string) string {
+	return "." + format
+}
+
+func isJSON(typ string) bool {
+	return typ == "JSON" || typ == "JSONB"
+}
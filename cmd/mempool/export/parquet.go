@@ -0,0 +1,68 @@
+package export
+
+import (
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// columnNode - returns parquet type of the column by its database type. Unknown types are exported as strings.
+func columnNode(typ string) parquet.Node {
+	switch typ {
+	case "INT2", "INT4", "INT8":
+		return parquet.Optional(parquet.Int(64))
+	case "FLOAT4", "FLOAT8":
+		return parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	case "BOOL":
+		return parquet.Optional(parquet.Leaf(parquet.BooleanType))
+	case "JSON", "JSONB":
+		return parquet.Optional(parquet.JSON())
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATE":
+		return parquet.Optional(parquet.Timestamp(parquet.Millisecond))
+	default:
+		return parquet.Optional(parquet.String())
+	}
+}
+
+func parquetSchema(name string, columns []Column) *parquet.Schema {
+	group := make(parquet.Group, len(columns))
+	for i := range columns {
+		group[columns[i].Name] = columnNode(columns[i].Type)
+	}
+	return parquet.NewSchema(name, group)
+}
+
+// parquetValue - converts value scanned from the database to the type which is expected by parquet column
+func parquetValue(typ string, value any) any {
+	b, ok := value.([]byte)
+	if !ok || isJSON(typ) {
+		return value
+	}
+	return string(b)
+}
+
+type parquetWriter struct {
+	writer  *parquet.Writer
+	columns []Column
+}
+
+func newParquetWriter(w io.Writer, name string, columns []Column) *parquetWriter {
+	return &parquetWriter{
+		writer:  parquet.NewWriter(w, parquetSchema(name, columns)),
+		columns: columns,
+	}
+}
+
+// Write -
+func (pw *parquetWriter) Write(values []any) error {
+	row := make(map[string]any, len(pw.columns))
+	for i := range pw.columns {
+		row[pw.columns[i].Name] = parquetValue(pw.columns[i].Type, values[i])
+	}
+	return pw.writer.Write(row)
+}
+
+// Close -
+func (pw *parquetWriter) Close() error {
+	return pw.writer.Close()
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "hash", Type: "VARCHAR"},
+		{Name: "level", Type: "INT8"},
+		{Name: "raw", Type: "JSONB"},
+		{Name: "amount", Type: "NUMERIC"},
+	}
+	rows := [][]any{
+		{"oo1", int64(10), []byte(`{"kind":"transaction"}`), []byte("100")},
+		{"oo2", nil, nil, nil},
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatParquet, &buf, "transactions", columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		if err := writer.Write(rows[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.NumRows() != int64(len(rows)) {
+		t.Errorf("rows count = %d, want %d", file.NumRows(), len(rows))
+	}
+
+	reader := parquet.NewReader(bytes.NewReader(buf.Bytes()))
+	got := make(map[string]any)
+	if err := reader.Read(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["hash"] != "oo1" || got["amount"] != "100" {
+		t.Errorf("first row = %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// textValue - formats value scanned from the database as string. NULL is an empty string.
+func textValue(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(typed)
+	case time.Time:
+		return typed.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	cw := &csvWriter{
+		writer: csv.NewWriter(w),
+		record: make([]string, len(columns)),
+	}
+	for i := range columns {
+		cw.record[i] = columns[i].Name
+	}
+	if err := cw.writer.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write -
+func (cw *csvWriter) Write(values []any) error {
+	for i := range values {
+		cw.record[i] = textValue(values[i])
+	}
+	return cw.writer.Write(cw.record)
+}
+
+// Close -
+func (cw *csvWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+type jsonlWriter struct {
+	buf     *bufio.Writer
+	encoder *jsoniter.Encoder
+	columns []Column
+}
+
+func newJSONLWriter(w io.Writer, columns []Column) *jsonlWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlWriter{
+		buf:     buf,
+		encoder: json.NewEncoder(buf),
+		columns: columns,
+	}
+}
+
+// Write -
+func (jw *jsonlWriter) Write(values []any) error {
+	row := make(map[string]any, len(jw.columns))
+	for i := range jw.columns {
+		switch typed := values[i].(type) {
+		case []byte:
+			if isJSON(jw.columns[i].Type) {
+				row[jw.columns[i].Name] = jsoniter.RawMessage(typed)
+			} else {
+				row[jw.columns[i].Name] = string(typed)
+			}
+		default:
+			row[jw.columns[i].Name] = typed
+		}
+	}
+	return jw.encoder.Encode(row)
+}
+
+// Close -
+func (jw *jsonlWriter) Close() error {
+	return jw.buf.Flush()
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTextWriters(t *testing.T) {
+	columns := []Column{
+		{Name: "hash", Type: "VARCHAR"},
+		{Name: "level", Type: "INT8"},
+		{Name: "raw", Type: "JSONB"},
+		{Name: "timestamp", Type: "TIMESTAMPTZ"},
+	}
+	values := []any{"oo1", int64(10), []byte(`{"kind":"transaction"}`), time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			want:   "hash,level,raw,timestamp\noo1,10,\"{\"\"kind\"\":\"\"transaction\"\"}\",2024-03-01T00:00:00Z\n,,,\n",
+		}, {
+			name:   "jsonl",
+			format: FormatJSONL,
+			want:   "{\"hash\":\"oo1\",\"level\":10,\"raw\":{\"kind\":\"transaction\"},\"timestamp\":\"2024-03-01T00:00:00Z\"}\n{\"hash\":null,\"level\":null,\"raw\":null,\"timestamp\":null}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(tt.format, &buf, "transactions", columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Write(values); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Write(make([]any, len(columns))); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewWriter("xml", &bytes.Buffer{}, "transactions", columns); err == nil {
+		t.Error("NewWriter() expected error for unknown format")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestParseExportTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: 0},
+		{name: "unix seconds", value: "1709251200", want: 1709251200},
+		{name: "date", value: "2024-03-01", want: 1709251200},
+		{name: "RFC3339", value: "2024-03-01T01:00:00+01:00", want: 1709251200},
+		{name: "invalid", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExportTime(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseExportTime() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+	"github.com/pkg/errors"
+)
+
+// fakeChainInfo - chain parameters which are returned instead of the node ones
+type fakeChainInfo struct {
+	chainID          string
+	level            uint64
+	blockTime        int64
+	blocksPerCycle   uint64
+	maxOperationsTTL uint64
+}
+
+func newFakeChainInfo() fakeChainInfo {
+	return fakeChainInfo{
+		chainID:          "NetXdQprcVkpaWU",
+		level:            1,
+		blockTime:        8,
+		blocksPerCycle:   8192,
+		maxOperationsTTL: 60,
+	}
+}
+
+// Constants -
+func (info fakeChainInfo) Constants(ctx context.Context, blockID string) (node.Constants, error) {
+	return node.Constants{
+		MinimalBlockDelay: info.blockTime,
+		BlocksPerCycle:    info.blocksPerCycle,
+	}, nil
+}
+
+// Header -
+func (info fakeChainInfo) Header(ctx context.Context, blockID string) (node.Header, error) {
+	return node.Header{
+		ChainID: info.chainID,
+		Level:   info.level,
+	}, nil
+}
+
+// Metadata -
+func (info fakeChainInfo) Metadata(ctx context.Context, blockID string) (node.BlockMetadata, error) {
+	return node.BlockMetadata{
+		MaxOperationsTTL: info.maxOperationsTTL,
+	}, nil
+}
+
+// fakeOperationSource - mempool source which is fed by the test
+type fakeOperationSource struct {
+	operations chan receiver.Message
+}
+
+func newFakeOperationSource() *fakeOperationSource {
+	return &fakeOperationSource{
+		operations: make(chan receiver.Message, 16),
+	}
+}
+
+// Start -
+func (source *fakeOperationSource) Start(ctx context.Context) {}
+
+// Operations -
+func (source *fakeOperationSource) Operations() <-chan receiver.Message {
+	return source.operations
+}
+
+// Close -
+func (source *fakeOperationSource) Close() error {
+	close(source.operations)
+	return nil
+}
+
+// fakeChainSource - chain source which is fed by the test. Delegates, rights and blocks requested by hash are returned from its fields.
+type fakeChainSource struct {
+	operations chan tzkt.OperationMessage
+	blocks     chan tzkt.BlockMessage
+	delegates  []data.Delegate
+	rights     map[uint64][]data.Right
+	headers    sync.Map
+}
+
+func newFakeChainSource() *fakeChainSource {
+	return &fakeChainSource{
+		operations: make(chan tzkt.OperationMessage, 16),
+		blocks:     make(chan tzkt.BlockMessage, 16),
+		rights:     make(map[uint64][]data.Right),
+	}
+}
+
+// Connect -
+func (source *fakeChainSource) Connect(ctx context.Context) error { return nil }
+
+// Subscribe -
+func (source *fakeChainSource) Subscribe() error { return nil }
+
+// Sync -
+func (source *fakeChainSource) Sync(ctx context.Context, indexerLevel uint64) {}
+
+// Close -
+func (source *fakeChainSource) Close() error {
+	close(source.operations)
+	close(source.blocks)
+	return nil
+}
+
+// Operations -
+func (source *fakeChainSource) Operations() <-chan tzkt.OperationMessage {
+	return source.operations
+}
+
+// Blocks -
+func (source *fakeChainSource) Blocks() <-chan tzkt.BlockMessage {
+	return source.blocks
+}
+
+// GetBlocks -
+func (source *fakeChainSource) GetBlocks(ctx context.Context, limit, state uint64) ([]tzkt.BlockMessage, error) {
+	return nil, nil
+}
+
+// GetBlock -
+func (source *fakeChainSource) GetBlock(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error) {
+	block, ok := source.headers.Load(hash)
+	if !ok {
+		return tzkt.BlockMessage{}, errors.Errorf("unknown block %s", hash)
+	}
+	return block.(tzkt.BlockMessage), nil
+}
+
+// Delegates -
+func (source *fakeChainSource) Delegates(ctx context.Context, limit, offset int64) ([]data.Delegate, error) {
+	if offset >= int64(len(source.delegates)) {
+		return nil, nil
+	}
+	delegates := source.delegates[offset:]
+	if limit < int64(len(delegates)) {
+		delegates = delegates[:limit]
+	}
+	return delegates, nil
+}
+
+// Rights -
+func (source *fakeChainSource) Rights(ctx context.Context, level uint64) ([]data.Right, error) {
+	return source.rights[level], nil
+}
+
+// newOperationMessage - returns message of TzKT with operations included into the block
+func newOperationMessage(level uint64, block string, operations ...data.Operation) tzkt.OperationMessage {
+	msg := tzkt.OperationMessage{
+		Level: level,
+		Block: block,
+		Hash:  new(sync.Map),
+	}
+	for i := range operations {
+		msg.Hash.Store(operations[i].Hash, operations[i])
+	}
+	return msg
+}
@@ -0,0 +1,138 @@
+package fees
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+)
+
+// default fee parameters of Tezos nodes
+const (
+	defaultBaseFee      = 100
+	defaultMutezPerByte = 1
+)
+
+// Storage - storage of gas statistics and fee estimates
+type Storage interface {
+	IncludedGasStats(ctx context.Context, network string) ([]models.GasStats, error)
+	SaveFeeEstimates(ctx context.Context, estimates ...models.FeeEstimate) error
+}
+
+// Estimator - calculates fee which is needed to include manager operation within count of blocks with the confidence
+type Estimator struct {
+	db      Storage
+	network string
+
+	blocks       []uint64
+	confidence   []uint64
+	baseFee      uint64
+	mutezPerByte uint64
+}
+
+// NewEstimator -
+func NewEstimator(network string, db Storage, opts ...EstimatorOption) *Estimator {
+	estimator := Estimator{
+		db:           db,
+		network:      network,
+		blocks:       []uint64{1, 2, 3, 5, 10},
+		confidence:   []uint64{50, 80, 95},
+		baseFee:      defaultBaseFee,
+		mutezPerByte: defaultMutezPerByte,
+	}
+
+	for i := range opts {
+		opts[i](&estimator)
+	}
+
+	return &estimator
+}
+
+// Refresh - recalculates estimates by statistics of included operations and saves them
+func (e *Estimator) Refresh(ctx context.Context, level uint64) error {
+	stats, err := e.db.IncludedGasStats(ctx, e.network)
+	if err != nil {
+		return errors.Wrap(err, "IncludedGasStats")
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+
+	samples := e.samples(stats)
+	estimates := make([]models.FeeEstimate, 0, len(e.blocks)*len(e.confidence))
+	for _, blocks := range e.blocks {
+		for _, confidence := range e.confidence {
+			price, ok := estimate(samples, blocks, confidence)
+			if !ok {
+				continue
+			}
+			estimates = append(estimates, models.FeeEstimate{
+				Network:         e.network,
+				Blocks:          blocks,
+				Confidence:      confidence,
+				MutezPerGasUnit: price,
+				MutezPerByte:    e.mutezPerByte,
+				BaseFee:         e.baseFee,
+				Samples:         uint64(len(samples)),
+				Level:           level,
+			})
+		}
+	}
+
+	return e.db.SaveFeeEstimates(ctx, estimates...)
+}
+
+type sample struct {
+	price   float64
+	waiting uint64
+}
+
+// samples - converts statistics to price of gas unit excluding base fee and fee for operation size
+func (e *Estimator) samples(stats []models.GasStats) []sample {
+	samples := make([]sample, 0, len(stats))
+	for i := range stats {
+		if stats[i].LevelInChain < stats[i].LevelInMempool {
+			continue
+		}
+
+		var price float64
+		if fixed := e.baseFee + e.mutezPerByte*stats[i].Size; stats[i].TotalFee > fixed {
+			price = float64(stats[i].TotalFee-fixed) / float64(stats[i].TotalGasUsed)
+		}
+		samples = append(samples, sample{
+			price:   price,
+			waiting: stats[i].LevelInChain - stats[i].LevelInMempool,
+		})
+	}
+	return samples
+}
+
+// estimate - returns the lowest price of gas unit at which at least `confidence` percent of operations paying
+// the same or more were included within `blocks` blocks. Returns false if there is no such price.
+func estimate(samples []sample, blocks, confidence uint64) (float64, bool) {
+	sorted := make([]sample, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].price > sorted[j].price
+	})
+
+	var (
+		price    float64
+		found    bool
+		included uint64
+	)
+	for i := range sorted {
+		if sorted[i].waiting <= blocks {
+			included++
+		}
+		if i+1 < len(sorted) && sorted[i+1].price == sorted[i].price {
+			continue
+		}
+		if included*100 >= confidence*uint64(i+1) {
+			price = sorted[i].price
+			found = true
+		}
+	}
+	return price, found
+}
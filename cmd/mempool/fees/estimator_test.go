@@ -0,0 +1,100 @@
+package fees
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []sample
+		blocks     uint64
+		confidence uint64
+		want       float64
+		wantOk     bool
+	}{
+		{
+			name:       "empty",
+			blocks:     1,
+			confidence: 50,
+		}, {
+			name: "all included in next block",
+			samples: []sample{
+				{price: 0.3, waiting: 1},
+				{price: 0.1, waiting: 1},
+				{price: 0.2, waiting: 1},
+			},
+			blocks:     1,
+			confidence: 95,
+			want:       0.1,
+			wantOk:     true,
+		}, {
+			name: "cheap operations wait longer",
+			samples: []sample{
+				{price: 0.4, waiting: 1},
+				{price: 0.3, waiting: 1},
+				{price: 0.2, waiting: 3},
+				{price: 0.1, waiting: 5},
+			},
+			blocks:     1,
+			confidence: 95,
+			want:       0.3,
+			wantOk:     true,
+		}, {
+			name: "lower confidence allows cheaper price",
+			samples: []sample{
+				{price: 0.4, waiting: 1},
+				{price: 0.3, waiting: 1},
+				{price: 0.2, waiting: 3},
+				{price: 0.1, waiting: 5},
+			},
+			blocks:     1,
+			confidence: 50,
+			want:       0.1,
+			wantOk:     true,
+		}, {
+			name: "nothing included in time",
+			samples: []sample{
+				{price: 0.4, waiting: 2},
+				{price: 0.3, waiting: 3},
+			},
+			blocks:     1,
+			confidence: 50,
+		}, {
+			name: "equal prices are evaluated together",
+			samples: []sample{
+				{price: 0.2, waiting: 1},
+				{price: 0.1, waiting: 1},
+				{price: 0.1, waiting: 4},
+				{price: 0.1, waiting: 4},
+			},
+			blocks:     1,
+			confidence: 80,
+			want:       0.2,
+			wantOk:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := estimate(tt.samples, tt.blocks, tt.confidence)
+			if ok != tt.wantOk {
+				t.Errorf("estimate() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("estimate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSize(t *testing.T) {
+	raw := []byte(`{"hash":"opAA","branch":"BLockGenesisGenesisGenesisGenesisGenesisf79b5d1CoW2","signature":"sig","contents":[{"kind":"transaction","source":"tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx","fee":"400","counter":"1000","gas_limit":"1520","storage_limit":"0","amount":"1000000","destination":"tz1gjaF81ZRRvdzjobyfVNsAeSC6PScjfQwN"}]}`)
+	if got := Size(raw); got != 151 {
+		t.Errorf("Size() = %d, want 151", got)
+	}
+	if got := Size(nil); got != 0 {
+		t.Errorf("Size(nil) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,22 @@
+package fees
+
+// EstimatorOption -
+type EstimatorOption func(*Estimator)
+
+// WithBlocks - sets counts of blocks within which operations should be included
+func WithBlocks(blocks ...uint64) EstimatorOption {
+	return func(e *Estimator) {
+		if len(blocks) > 0 {
+			e.blocks = blocks
+		}
+	}
+}
+
+// WithConfidence - sets shares of included operations in percent
+func WithConfidence(confidence ...uint64) EstimatorOption {
+	return func(e *Estimator) {
+		if len(confidence) > 0 {
+			e.confidence = confidence
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package fees
+
+import (
+	stdJSON "encoding/json"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tools/forge"
+)
+
+// binary lengths of branch and signature
+const (
+	branchLength    = 32
+	signatureLength = 64
+)
+
+type rawGroup struct {
+	Contents []stdJSON.RawMessage `json:"contents"`
+}
+
+// Size - returns size of forged operation group in bytes by its raw JSON. Transactions are forged,
+// size of other contents is approximated by half length of their JSON.
+func Size(raw []byte) uint64 {
+	if len(raw) == 0 {
+		return 0
+	}
+
+	var group rawGroup
+	if err := stdJSON.Unmarshal(raw, &group); err != nil {
+		return 0
+	}
+
+	size := uint64(branchLength + signatureLength)
+	for i := range group.Contents {
+		size += contentSize(group.Contents[i])
+	}
+	return size
+}
+
+func contentSize(content stdJSON.RawMessage) uint64 {
+	var operation node.Operation
+	if err := stdJSON.Unmarshal(content, &operation); err == nil {
+		if tx, ok := operation.Body.(node.Transaction); ok {
+			tx.Metadata = nil
+			if forged, err := forge.Transaction(tx); err == nil {
+				return uint64(len(forged))
+			}
+		}
+	}
+	return uint64(len(content)) / 2
+}
@@ -0,0 +1,154 @@
+package filter
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Accounts - matches operation contents by addresses of accounts. Content passes if no rule is applied to its kind
+// or any applied rule matches it.
+type Accounts struct {
+	rules []rule
+}
+
+type rule struct {
+	kinds    []string
+	role     string
+	accounts map[string]struct{}
+	prefixes []string
+}
+
+// NewAccounts - creates matcher by accounts and rules of the filters. Accounts are converted to the rule which matches manager operations
+// by any address.
+func NewAccounts(filters config.Filters) (*Accounts, error) {
+	a := &Accounts{
+		rules: make([]rule, 0, len(filters.Rules)+1),
+	}
+
+	if len(filters.Accounts) > 0 {
+		addresses := make([]string, len(filters.Accounts))
+		for i := range filters.Accounts {
+			addresses[i] = filters.Accounts[i].Struct().Address
+		}
+		a.rules = append(a.rules, newRule(nil, config.RoleAny, addresses))
+	}
+
+	for i := range filters.Rules {
+		for _, kind := range filters.Rules[i].Kinds {
+			if !slices.Contains(filters.Kinds, kind) {
+				return nil, errors.Errorf("kind of the account rule isn't indexed: %s", kind)
+			}
+		}
+		role := filters.Rules[i].Role
+		if role == "" {
+			role = config.RoleAny
+		}
+		a.rules = append(a.rules, newRule(filters.Rules[i].Kinds, role, filters.Rules[i].Accounts))
+	}
+	return a, nil
+}
+
+func newRule(kinds []string, role string, accounts []string) rule {
+	r := rule{
+		kinds:    kinds,
+		role:     role,
+		accounts: make(map[string]struct{}, len(accounts)),
+	}
+	for _, account := range accounts {
+		if prefix, ok := strings.CutSuffix(account, "*"); ok {
+			r.prefixes = append(r.prefixes, prefix)
+		} else {
+			r.accounts[account] = struct{}{}
+		}
+	}
+	return r
+}
+
+// appliedTo - rules without kinds are applied to manager operations and account activations
+func (r rule) appliedTo(kind string) bool {
+	if len(r.kinds) > 0 {
+		return slices.Contains(r.kinds, kind)
+	}
+	return node.IsManager(kind) || kind == node.KindTransferTicket || kind == node.KindActivation
+}
+
+func (r rule) match(addresses addresses) bool {
+	for _, address := range addresses.byRole(r.role) {
+		if address == "" {
+			continue
+		}
+		if _, ok := r.accounts[address]; ok {
+			return true
+		}
+		for i := range r.prefixes {
+			if strings.HasPrefix(address, r.prefixes[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Match - returns true if the content of the kind should be indexed
+func (a *Accounts) Match(kind string, body []byte) (bool, error) {
+	matched, applied, err := a.match(kind, body)
+	return matched || !applied, err
+}
+
+// Watches - returns true if any rule applied to the kind matches the content. Contents of kinds without rules aren't watched.
+func (a *Accounts) Watches(kind string, body []byte) (bool, error) {
+	matched, _, err := a.match(kind, body)
+	return matched, err
+}
+
+// match - the second value is true if any rule is applied to the kind
+func (a *Accounts) match(kind string, body []byte) (bool, bool, error) {
+	var (
+		parsed  bool
+		content addresses
+	)
+	for i := range a.rules {
+		if !a.rules[i].appliedTo(kind) {
+			continue
+		}
+		if !parsed {
+			if err := json.Unmarshal(body, &content); err != nil {
+				return false, true, errors.Wrap(err, "parse addresses of operation")
+			}
+			parsed = true
+		}
+		if a.rules[i].match(content) {
+			return true, true, nil
+		}
+	}
+	return false, parsed, nil
+}
+
+// addresses - fields of operation content which contain addresses
+type addresses struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Delegate    string `json:"delegate"`
+	Pkh         string `json:"pkh"`
+	Rollup      string `json:"rollup"`
+}
+
+func (a addresses) byRole(role string) []string {
+	switch role {
+	case config.RoleSource:
+		return []string{a.Source, a.Pkh}
+	case config.RoleDestination:
+		return []string{a.Destination, a.Rollup}
+	case config.RoleDelegate:
+		return []string{a.Delegate}
+	default:
+		return []string{a.Source, a.Pkh, a.Destination, a.Rollup, a.Delegate}
+	}
+}
@@ -0,0 +1,156 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+)
+
+func TestAccounts_Match(t *testing.T) {
+	kinds := []string{node.KindTransaction, node.KindDelegation, node.KindOrigination, node.KindReveal, node.KindEndorsement, node.KindSrExecute}
+	transaction := `{"kind":"transaction","source":"tz1sender","destination":"KT1target"}`
+	delegation := `{"kind":"delegation","source":"tz1sender","delegate":"tz1baker"}`
+	origination := `{"kind":"origination","source":"tz1origin"}`
+
+	list := make([]string, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		list = append(list, fmt.Sprintf("KT1contract%d", i))
+	}
+
+	tests := []struct {
+		name  string
+		rules []config.AccountRule
+		kind  string
+		body  string
+		want  bool
+	}{
+		{
+			name: "no rules",
+			kind: node.KindTransaction,
+			body: transaction,
+			want: true,
+		}, {
+			name:  "transaction to the contract",
+			rules: []config.AccountRule{{Kinds: []string{node.KindTransaction}, Role: config.RoleDestination, Accounts: []string{"KT1target"}}},
+			kind:  node.KindTransaction,
+			body:  transaction,
+			want:  true,
+		}, {
+			name:  "transaction from the contract",
+			rules: []config.AccountRule{{Kinds: []string{node.KindTransaction}, Role: config.RoleDestination, Accounts: []string{"tz1sender"}}},
+			kind:  node.KindTransaction,
+			body:  transaction,
+		}, {
+			name:  "delegation to the baker",
+			rules: []config.AccountRule{{Kinds: []string{node.KindDelegation}, Role: config.RoleDelegate, Accounts: []string{"tz1baker"}}},
+			kind:  node.KindDelegation,
+			body:  delegation,
+			want:  true,
+		}, {
+			name:  "kind without rules",
+			rules: []config.AccountRule{{Kinds: []string{node.KindDelegation}, Role: config.RoleDelegate, Accounts: []string{"tz1other"}}},
+			kind:  node.KindTransaction,
+			body:  transaction,
+			want:  true,
+		}, {
+			name:  "origination from the account",
+			rules: []config.AccountRule{{Role: config.RoleSource, Accounts: []string{"tz1origin"}}},
+			kind:  node.KindOrigination,
+			body:  origination,
+			want:  true,
+		}, {
+			name:  "rule without kinds is applied to every manager kind",
+			rules: []config.AccountRule{{Accounts: []string{"tz1origin"}}},
+			kind:  node.KindDelegation,
+			body:  delegation,
+		}, {
+			name:  "rule without kinds isn't applied to consensus operations",
+			rules: []config.AccountRule{{Accounts: []string{"tz1origin"}}},
+			kind:  node.KindEndorsement,
+			body:  `{"kind":"endorsement","level":1}`,
+			want:  true,
+		}, {
+			name:  "pattern",
+			rules: []config.AccountRule{{Role: config.RoleDestination, Accounts: []string{"KT1*"}}},
+			kind:  node.KindTransaction,
+			body:  transaction,
+			want:  true,
+		}, {
+			name:  "smart rollup is a destination",
+			rules: []config.AccountRule{{Role: config.RoleDestination, Accounts: []string{"sr1*"}}},
+			kind:  node.KindSrExecute,
+			body:  `{"kind":"smart_rollup_execute_outbox_message","source":"tz1sender","rollup":"sr1rollup"}`,
+			want:  true,
+		}, {
+			name:  "long list",
+			rules: []config.AccountRule{{Accounts: append(list, "KT1target")}},
+			kind:  node.KindTransaction,
+			body:  transaction,
+			want:  true,
+		}, {
+			name: "any rule matches",
+			rules: []config.AccountRule{
+				{Role: config.RoleSource, Accounts: []string{"tz1other"}},
+				{Role: config.RoleDestination, Accounts: []string{"KT1target"}},
+			},
+			kind: node.KindTransaction,
+			body: transaction,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := NewAccounts(config.Filters{Kinds: kinds, Rules: tt.rules})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := accounts.Match(tt.kind, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAccounts_NotIndexedKind(t *testing.T) {
+	_, err := NewAccounts(config.Filters{
+		Kinds: []string{node.KindTransaction},
+		Rules: []config.AccountRule{{Kinds: []string{node.KindDelegation}, Accounts: []string{"tz1baker"}}},
+	})
+	if err == nil {
+		t.Error("rule of not indexed kind is accepted")
+	}
+}
+
+func TestAccounts_Watches(t *testing.T) {
+	accounts, err := NewAccounts(config.Filters{
+		Kinds: []string{node.KindTransaction, node.KindDelegation},
+		Rules: []config.AccountRule{{Kinds: []string{node.KindTransaction}, Role: config.RoleSource, Accounts: []string{"tz1sender"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		kind string
+		body string
+		want bool
+	}{
+		{node.KindTransaction, `{"kind":"transaction","source":"tz1sender","destination":"KT1target"}`, true},
+		{node.KindTransaction, `{"kind":"transaction","source":"tz1other","destination":"KT1target"}`, false},
+		{node.KindDelegation, `{"kind":"delegation","source":"tz1sender","delegate":"tz1baker"}`, false},
+	} {
+		got, err := accounts.Watches(tt.kind, []byte(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Watches(%s) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
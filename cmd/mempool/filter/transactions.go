@@ -0,0 +1,264 @@
+package filter
+
+import (
+	stdJSON "encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// EntrypointDefault - entrypoint of transactions without parameters
+const EntrypointDefault = "default"
+
+var numberJSON = jsoniter.Config{UseNumber: true}.Froze()
+
+// Transactions - matches transactions by entrypoint and parameters. Transaction passes if the entrypoint list is empty or contains its entrypoint
+// and all parameter predicates are true.
+type Transactions struct {
+	entrypoints map[string]struct{}
+	predicates  []predicate
+}
+
+type predicate struct {
+	path   []step
+	op     string
+	values map[string]struct{}
+	number *big.Int
+	raw    string
+}
+
+// step - field name or array index of the path. Index -1 matches every item.
+type step struct {
+	field string
+	index int
+}
+
+// NewTransactions -
+func NewTransactions(filters config.Filters) (*Transactions, error) {
+	t := &Transactions{
+		entrypoints: make(map[string]struct{}, len(filters.Entrypoints)),
+		predicates:  make([]predicate, 0, len(filters.Parameters)),
+	}
+	for _, entrypoint := range filters.Entrypoints {
+		t.entrypoints[entrypoint] = struct{}{}
+	}
+	for i := range filters.Parameters {
+		p, err := newPredicate(filters.Parameters[i])
+		if err != nil {
+			return nil, errors.Wrap(err, filters.Parameters[i].Path)
+		}
+		t.predicates = append(t.predicates, p)
+	}
+	return t, nil
+}
+
+func newPredicate(filter config.ParameterFilter) (predicate, error) {
+	path, err := parsePath(filter.Path)
+	if err != nil {
+		return predicate{}, err
+	}
+	p := predicate{
+		path:   path,
+		op:     filter.Op,
+		values: make(map[string]struct{}, len(filter.Values)),
+	}
+	if p.op == "" {
+		p.op = config.OpEq
+	}
+
+	switch p.op {
+	case config.OpExists:
+		if len(filter.Values) > 0 {
+			return p, errors.Errorf("%s doesn't expect values", p.op)
+		}
+	case config.OpIn:
+		if len(filter.Values) == 0 {
+			return p, errors.Errorf("%s expects at least one value", p.op)
+		}
+	default:
+		if len(filter.Values) != 1 {
+			return p, errors.Errorf("%s expects exactly one value", p.op)
+		}
+		p.raw = filter.Values[0]
+	}
+	for _, value := range filter.Values {
+		p.values[value] = struct{}{}
+	}
+
+	switch p.op {
+	case config.OpGt, config.OpGte, config.OpLt, config.OpLte:
+		number, ok := new(big.Int).SetString(p.raw, 10)
+		if !ok {
+			return p, errors.Errorf("%s expects integer value: %s", p.op, p.raw)
+		}
+		p.number = number
+	}
+	return p, nil
+}
+
+// parsePath - parses path like `$.args[0].args[*].string`
+func parsePath(path string) ([]step, error) {
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, errors.New("path should start with $")
+	}
+
+	steps := make([]step, 0)
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.New("empty field name")
+			}
+			steps = append(steps, step{field: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, errors.New("unclosed bracket")
+			}
+			index := -1
+			if item := rest[1:end]; item != "*" {
+				value, err := strconv.Atoi(item)
+				if err != nil || value < 0 {
+					return nil, errors.Errorf("invalid index: %s", item)
+				}
+				index = value
+			}
+			steps = append(steps, step{index: index})
+			rest = rest[end+1:]
+		default:
+			return nil, errors.Errorf("unexpected symbol: %c", rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// Empty - returns true if every transaction passes
+func (t *Transactions) Empty() bool {
+	return len(t.entrypoints) == 0 && len(t.predicates) == 0
+}
+
+// Match - returns true if the transaction with the entrypoint and the Micheline value of parameters should be indexed.
+// Entrypoint of the transaction without parameters is `default`.
+func (t *Transactions) Match(entrypoint string, value []byte) (bool, error) {
+	if entrypoint == "" {
+		entrypoint = EntrypointDefault
+	}
+	if len(t.entrypoints) > 0 {
+		if _, ok := t.entrypoints[entrypoint]; !ok {
+			return false, nil
+		}
+	}
+	if len(t.predicates) == 0 {
+		return true, nil
+	}
+
+	var root any
+	if len(value) > 0 {
+		if err := numberJSON.Unmarshal(value, &root); err != nil {
+			return false, errors.Wrap(err, "parse parameters")
+		}
+	}
+	for i := range t.predicates {
+		if !t.predicates[i].match(resolve(root, len(value) > 0, t.predicates[i].path)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// resolve - returns values found by the path. Wildcard index can return several values.
+func resolve(root any, exists bool, path []step) []any {
+	if !exists {
+		return nil
+	}
+	current := []any{root}
+	for _, s := range path {
+		next := make([]any, 0, len(current))
+		for _, value := range current {
+			switch typed := value.(type) {
+			case map[string]any:
+				if s.field == "" {
+					continue
+				}
+				if item, ok := typed[s.field]; ok {
+					next = append(next, item)
+				}
+			case []any:
+				switch {
+				case s.field != "":
+				case s.index == -1:
+					next = append(next, typed...)
+				case s.index < len(typed):
+					next = append(next, typed[s.index])
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func (p predicate) match(found []any) bool {
+	switch p.op {
+	case config.OpExists:
+		return len(found) > 0
+	case config.OpNe:
+		for i := range found {
+			if toString(found[i]) == p.raw {
+				return false
+			}
+		}
+		return len(found) > 0
+	}
+
+	for i := range found {
+		value := toString(found[i])
+		switch p.op {
+		case config.OpEq, config.OpIn:
+			if _, ok := p.values[value]; ok {
+				return true
+			}
+		default:
+			number, ok := new(big.Int).SetString(value, 10)
+			if !ok {
+				continue
+			}
+			cmp := number.Cmp(p.number)
+			if (p.op == config.OpGt && cmp > 0) || (p.op == config.OpGte && cmp >= 0) ||
+				(p.op == config.OpLt && cmp < 0) || (p.op == config.OpLte && cmp <= 0) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case stdJSON.Number:
+		return typed.String()
+	case bool:
+		return strconv.FormatBool(typed)
+	case nil:
+		return "null"
+	default:
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+)
+
+func TestTransactions_Match(t *testing.T) {
+	// transfer(from, [(to, token_id, amount)]) of FA2 token
+	transfer := `[{"prim":"Pair","args":[{"string":"tz1from"},[
+		{"prim":"Pair","args":[{"string":"tz1to"},{"prim":"Pair","args":[{"int":"0"},{"int":"1000"}]}]},
+		{"prim":"Pair","args":[{"string":"tz1other"},{"prim":"Pair","args":[{"int":"3"},{"int":"5"}]}]}
+	]]}]`
+
+	tests := []struct {
+		name       string
+		filters    config.Filters
+		entrypoint string
+		value      string
+		want       bool
+	}{
+		{
+			name:       "entrypoint is in the list",
+			filters:    config.Filters{Entrypoints: []string{"transfer", "update_operators"}},
+			entrypoint: "transfer",
+			value:      transfer,
+			want:       true,
+		}, {
+			name:       "entrypoint isn't in the list",
+			filters:    config.Filters{Entrypoints: []string{"update_operators"}},
+			entrypoint: "transfer",
+			value:      transfer,
+		}, {
+			name:    "transaction without parameters",
+			filters: config.Filters{Entrypoints: []string{"default"}},
+			want:    true,
+		}, {
+			name:       "equal",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[0].args[0].string", Values: []string{"tz1from"}}}},
+			entrypoint: "transfer",
+			value:      transfer,
+			want:       true,
+		}, {
+			name:       "wildcard",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[0].args[1][*].args[0].string", Op: config.OpIn, Values: []string{"tz1other", "tz1third"}}}},
+			entrypoint: "transfer",
+			value:      transfer,
+			want:       true,
+		}, {
+			name:       "greater than",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[0].args[1][*].args[1].args[1].int", Op: config.OpGt, Values: []string{"999"}}}},
+			entrypoint: "transfer",
+			value:      transfer,
+			want:       true,
+		}, {
+			name:       "less than",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[0].args[1][*].args[1].args[1].int", Op: config.OpLt, Values: []string{"5"}}}},
+			entrypoint: "transfer",
+			value:      transfer,
+		}, {
+			name:       "not equal",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[0].args[0].string", Op: config.OpNe, Values: []string{"tz1from"}}}},
+			entrypoint: "transfer",
+			value:      transfer,
+		}, {
+			name:       "missing path",
+			filters:    config.Filters{Parameters: []config.ParameterFilter{{Path: "$[1].args[0].string", Op: config.OpExists}}},
+			entrypoint: "transfer",
+			value:      transfer,
+		}, {
+			name: "all predicates should be true",
+			filters: config.Filters{
+				Entrypoints: []string{"transfer"},
+				Parameters: []config.ParameterFilter{
+					{Path: "$[0].args[0].string", Values: []string{"tz1from"}},
+					{Path: "$[0].args[1][0].args[0].string", Values: []string{"tz1other"}},
+				},
+			},
+			entrypoint: "transfer",
+			value:      transfer,
+		}, {
+			name:    "predicate on transaction without parameters",
+			filters: config.Filters{Parameters: []config.ParameterFilter{{Path: "$.int", Op: config.OpExists}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, err := NewTransactions(tt.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := transactions.Match(tt.entrypoint, []byte(tt.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTransactions_InvalidFilter(t *testing.T) {
+	for _, filter := range []config.ParameterFilter{
+		{Path: "args[0]", Values: []string{"1"}},
+		{Path: "$.args[x]", Values: []string{"1"}},
+		{Path: "$.args[0", Values: []string{"1"}},
+		{Path: "$..args", Values: []string{"1"}},
+		{Path: "$.int", Op: config.OpGt, Values: []string{"one"}},
+		{Path: "$.int", Op: config.OpEq},
+		{Path: "$.int", Op: config.OpExists, Values: []string{"1"}},
+	} {
+		if _, err := NewTransactions(config.Filters{Parameters: []config.ParameterFilter{filter}}); err == nil {
+			t.Errorf("invalid filter is accepted: %+v", filter)
+		}
+	}
+}
@@ -2,22 +2,29 @@ package main
 
 import (
 	"context"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/dipdup-net/go-lib/node"
 	"github.com/dipdup-net/go-lib/tzkt/data"
 	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/fees"
+	"github.com/dipdup-net/mempool/cmd/mempool/mev"
+	"github.com/dipdup-net/mempool/cmd/mempool/michelson"
 	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
 	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
-	"github.com/uptrace/bun"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
 func (indexer *Indexer) handleBlock(ctx context.Context, block tzkt.BlockMessage) error {
-	if err := indexer.handleOldOperations(ctx); err != nil {
+	if err := indexer.barrier(ctx); err != nil {
 		return err
 	}
 
@@ -26,82 +33,157 @@ func (indexer *Indexer) handleBlock(ctx context.Context, block tzkt.BlockMessage
 		if indexer.state.Level < block.Level {
 			indexer.sync(ctx)
 		}
-		if space := indexer.branches.Space(); space > 0 {
-			blocks, err := indexer.tzkt.GetBlocks(ctx, space, indexer.state.Level)
-			if err != nil {
-				return err
-			}
-
-			for i := len(blocks) - 1; i > -1; i-- {
-				if err := indexer.branches.Add(ctx, blocks[i]); err != nil {
-					return err
-				}
-			}
-		}
-		return nil
+		return indexer.fillBlockQueue(ctx, indexer.state.Level)
 	case events.MessageTypeData:
-		if block.Level > indexer.state.Level {
+		if err := indexer.fillBlockQueue(ctx, block.Level-1); err != nil {
+			return err
+		}
+		// the block is added first: if it's a fork, the state is rolled back to the common ancestor before it's updated
+		added, err := indexer.branches.Add(ctx, block)
+		if err != nil {
+			return err
+		}
+		stored := make([]models.Block, 0, len(added))
+		for i := range added {
+			stored = append(stored, added[i].model(indexer.network))
+		}
+		if err := indexer.db.SaveBlocks(ctx, stored...); err != nil {
+			return errors.Wrap(err, "SaveBlocks")
+		}
+		if block.Level > indexer.level() {
+			indexer.stateMx.Lock()
 			indexer.state.Level = block.Level
 			indexer.state.Hash = block.Hash
 			indexer.state.Timestamp = block.Timestamp
+			indexer.stateMx.Unlock()
 			indexer.info().Msg("indexer state was updated")
 			if err := indexer.db.UpdateState(ctx, indexer.state); err != nil {
 				return err
 			}
 		}
+		if err := indexer.db.SetIncludedAt(ctx, indexer.network, block.Level, block.Timestamp.UnixMilli(), indexer.filters.Kinds...); err != nil {
+			return errors.Wrap(err, "SetIncludedAt")
+		}
+		if indexer.fees != nil {
+			if err := indexer.fees.Refresh(ctx, block.Level); err != nil {
+				return errors.Wrap(err, "fee estimates refresh")
+			}
+		}
+		return nil
 	}
-	return indexer.branches.Add(ctx, block)
-}
-
-func (indexer *Indexer) handleOldOperations(ctx context.Context) error {
-	return indexer.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		return indexer.processOldOperations(ctx, tx)
-	})
+	_, err := indexer.branches.Add(ctx, block)
+	return err
 }
 
-func (indexer *Indexer) processOldOperations(ctx context.Context, db bun.IDB) error {
-	if err := models.DeleteOldOperations(ctx, db, indexer.keepInChain, models.StatusInChain, indexer.filters.Kinds...); err != nil {
-		return errors.Wrap(err, "DeleteOldOperations in_chain")
+// fillBlockQueue - adds blocks up to `level` which are missed in the queue. The whole queue is filled if it's empty.
+// Otherwise blocks above the tail are added: the gap appears if the queue was restored after restart.
+func (indexer *Indexer) fillBlockQueue(ctx context.Context, level uint64) error {
+	limit := indexer.branches.Space()
+	tail, ok := indexer.branches.tail()
+	if ok {
+		if tail.Level >= level {
+			return nil
+		}
+		limit = min(level-tail.Level, indexer.branches.capacity)
 	}
-	if err := models.DeleteOldOperations(ctx, db, indexer.keepOperations, "", indexer.filters.Kinds...); err != nil {
-		return errors.Wrap(err, "DeleteOldOperations")
+	if limit == 0 || level == 0 {
+		return nil
 	}
-	if indexer.hasManager {
-		if err := models.DeleteOldGasStats(ctx, db, indexer.gasStatsLifetime); err != nil {
-			return errors.Wrap(err, "DeleteOldGasStats")
+
+	blocks, err := indexer.tzkt.GetBlocks(ctx, limit, level)
+	if err != nil {
+		return err
+	}
+
+	stored := make([]models.Block, 0, len(blocks))
+	for i := len(blocks) - 1; i > -1; i-- {
+		if ok && blocks[i].Level <= tail.Level {
+			continue
+		}
+		added, err := indexer.branches.Add(ctx, blocks[i])
+		if err != nil {
+			return err
+		}
+		for j := range added {
+			stored = append(stored, added[j].model(indexer.network))
 		}
 	}
+	if err := indexer.db.SaveBlocks(ctx, stored...); err != nil {
+		return errors.Wrap(err, "SaveBlocks")
+	}
 	return nil
 }
 
-func (indexer *Indexer) handleInChain(ctx context.Context, operations tzkt.OperationMessage) error {
-	return indexer.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		return indexer.inChainOperationProcess(ctx, tx, operations)
+func (w *worker) handleInChain(ctx context.Context, operations tzkt.OperationMessage) error {
+	hashes := make([]string, 0)
+	operations.Hash.Range(func(_, operation interface{}) bool {
+		if apiOperation, ok := operation.(data.Operation); ok {
+			hashes = append(hashes, apiOperation.Hash)
+		}
+		return true
 	})
+	w.dropPending(hashes)
+	if w.mev != nil {
+		w.mev.Remove(hashes...)
+	}
+	if err := w.barrier(ctx); err != nil {
+		return err
+	}
+	return w.runInTx(ctx, func(ctx context.Context, tx storage.Tx) error {
+		return w.inChainOperationProcess(ctx, tx, operations)
+	})
+}
+
+// dropPending - drops buffered applied operations which are included in the block
+func (w *worker) dropPending(hashes []string) {
+	if w.pending.Len() == 0 {
+		return
+	}
+
+	w.pendingMx.Lock()
+	w.pending.Remove(hashes...)
+	w.pendingMx.Unlock()
 }
 
-func (indexer *Indexer) inChainOperationProcess(ctx context.Context, tx bun.IDB, operations tzkt.OperationMessage) error {
+func (w *worker) inChainOperationProcess(ctx context.Context, tx storage.Tx, operations tzkt.OperationMessage) error {
+	var includedAt int64
+	if ts, ok := w.branches.Timestamp(operations.Level); ok {
+		includedAt = ts.UnixMilli()
+	}
+
+	history := make([]models.StatusHistory, 0)
 	operations.Hash.Range(func(_, operation interface{}) bool {
 		apiOperation, ok := operation.(data.Operation)
 		if !ok {
 			return false
 		}
-		if err := models.SetInChain(ctx, tx, indexer.network, apiOperation.Hash, apiOperation.Type, operations.Level); err != nil {
-			indexer.error(err).Msg("models.SetInChain")
+		found, err := tx.SetInChain(ctx, w.network, apiOperation.Hash, apiOperation.Type, operations.Level, includedAt)
+		if err != nil {
+			w.error(err).Msg("SetInChain")
 			return false
 		}
+		if found {
+			history = append(history, w.newStatusHistory(apiOperation.Hash, models.StatusInChain, operations.Level))
+
+			event := w.newEvent(stream.EventTypeStatus, apiOperation.Hash, models.StatusInChain, node.Content{Kind: apiOperation.Type})
+			event.Level = operations.Level
+			if apiOperation.Parameters != nil {
+				event.Entrypoint = apiOperation.Parameters.Entrypoint
+			}
+			w.enqueue(event)
+		}
 
-		if indexer.prom != nil {
-			indexer.prom.IncrementCounter(operationCountMetricName, map[string]string{
+		if w.prom != nil {
+			w.prom.IncrementCounter(operationCountMetricName, map[string]string{
 				"kind":    apiOperation.Type,
 				"status":  models.StatusInChain,
-				"network": indexer.network,
+				"network": w.network,
 			})
 		}
 
-		if indexer.hasManager {
+		if w.hasManager {
 			gasStats := models.GasStats{
-				Network:      indexer.network,
+				Network:      w.network,
 				Hash:         apiOperation.Hash,
 				LevelInChain: operations.Level,
 			}
@@ -111,233 +193,339 @@ func (indexer *Indexer) inChainOperationProcess(ctx context.Context, tx bun.IDB,
 			if apiOperation.BakerFee != nil {
 				gasStats.TotalFee = *apiOperation.BakerFee
 			}
-			if err := gasStats.Save(ctx, tx); err != nil {
-				indexer.error(err).Msg("gasStats.Save")
+			if err := tx.SaveGasStats(ctx, &gasStats); err != nil {
+				w.error(err).Msg("SaveGasStats")
 				return false
 			}
 		}
 
 		return true
 	})
-	return nil
+	return tx.SaveStatusHistory(ctx, history...)
 }
 
-func (indexer *Indexer) handleFailedOperation(ctx context.Context, operation node.FailedMonitor, status, protocol string) error {
-	return indexer.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		return indexer.failedOperationProcess(ctx, tx, operation, status, protocol)
-	})
+func (w *worker) handleFailedOperation(ctx context.Context, operation node.FailedMonitor, msg receiver.Message) error {
+	if err := w.failedOperationProcess(ctx, operation, msg); err != nil {
+		return err
+	}
+	return w.flushIfFull(ctx)
 }
 
-func (indexer *Indexer) failedOperationProcess(ctx context.Context, tx bun.IDB, operation node.FailedMonitor, status, protocol string) error {
+func (w *worker) failedOperationProcess(ctx context.Context, operation node.FailedMonitor, msg receiver.Message) error {
+	status := string(msg.Status)
+	if w.mev != nil {
+		w.mev.Remove(operation.Hash)
+	}
+
+	var stored bool
 	for i := range operation.Contents {
 		mempoolOperation := models.MempoolOperation{
-			Network:   indexer.network,
-			Status:    status,
-			Hash:      operation.Hash,
-			Branch:    operation.Branch,
-			Signature: operation.Signature,
-			Errors:    models.JSONB(operation.Error),
-			Raw:       models.JSONB(operation.Raw),
-			Protocol:  protocol,
-		}
-		if !indexer.isKindAvailiable(operation.Contents[i].Kind) {
+			Network:     w.network,
+			Status:      status,
+			Hash:        operation.Hash,
+			Branch:      operation.Branch,
+			Signature:   operation.Signature,
+			Errors:      models.JSONB(operation.Error),
+			Raw:         models.JSONB(operation.Raw),
+			Protocol:    msg.Protocol,
+			Node:        msg.Node,
+			FirstSeenAt: msg.ReceivedAt.UnixMilli(),
+		}
+		if !w.isKindAvailiable(operation.Contents[i].Kind) {
 			continue
 		}
-		if err := indexer.handleContent(ctx, tx, operation.Contents[i], mempoolOperation); err != nil {
+		ok, err := w.handleContent(ctx, operation.Contents[i], mempoolOperation)
+		if err != nil {
 			return err
 		}
+		stored = stored || ok
+	}
+	if !stored {
+		w.filterStatus(operation.Hash)
+		return nil
 	}
+
+	history := w.newStatusHistory(operation.Hash, status, w.level())
+	history.Node = msg.Node
+	history.Protocol = msg.Protocol
+	history.Errors = models.JSONB(operation.Error)
+	history.Timestamp = msg.ReceivedAt.UTC()
+	w.batch.addHistory(history)
 	return nil
 }
 
-func (indexer *Indexer) handleAppliedOperation(ctx context.Context, operation node.Applied, protocol string) error {
-	return indexer.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		return indexer.appliedOperationProcess(ctx, tx, operation, protocol)
-	})
+func (w *worker) handleAppliedOperation(ctx context.Context, operation node.Applied, msg receiver.Message) error {
+	if err := w.appliedOperationProcess(ctx, operation, msg); err != nil {
+		return err
+	}
+	return w.flushIfFull(ctx)
 }
 
-func (indexer *Indexer) appliedOperationProcess(ctx context.Context, tx bun.IDB, operation node.Applied, protocol string) error {
+func (w *worker) appliedOperationProcess(ctx context.Context, operation node.Applied, msg receiver.Message) error {
+	var stored bool
 	for i := range operation.Contents {
 		mempoolOperation := models.MempoolOperation{
-			Network:   indexer.network,
-			Status:    models.StatusApplied,
-			Hash:      operation.Hash,
-			Branch:    operation.Branch,
-			Signature: operation.Signature,
-			Raw:       models.JSONB(operation.Raw),
-			Protocol:  protocol,
-		}
-		expirationLevel := indexer.branches.ExpirationLevel(operation.Branch)
+			Network:     w.network,
+			Status:      models.StatusApplied,
+			Hash:        operation.Hash,
+			Branch:      operation.Branch,
+			Signature:   operation.Signature,
+			Raw:         models.JSONB(operation.Raw),
+			Protocol:    msg.Protocol,
+			Node:        msg.Node,
+			FirstSeenAt: msg.ReceivedAt.UnixMilli(),
+			AppliedAt:   msg.ReceivedAt.UnixMilli(),
+		}
+		expirationLevel := w.branches.ExpirationLevel(operation.Branch)
 		if expirationLevel > 0 {
 			mempoolOperation.ExpirationLevel = &expirationLevel
 		}
-		if !indexer.isKindAvailiable(operation.Contents[i].Kind) {
+		if !w.isKindAvailiable(operation.Contents[i].Kind) {
 			continue
 		}
-		if err := indexer.handleContent(ctx, tx, operation.Contents[i], mempoolOperation); err != nil {
+		ok, err := w.handleContent(ctx, operation.Contents[i], mempoolOperation)
+		if err != nil {
 			return err
 		}
+		if !ok {
+			continue
+		}
 
-		if indexer.hasManager {
-			gasStats := models.GasStats{
-				Network:        indexer.network,
+		if w.hasManager {
+			w.batch.addGasStats(models.GasStats{
+				Network:        w.network,
 				Hash:           operation.Hash,
-				LevelInMempool: indexer.state.Level,
-			}
-			if err := gasStats.Save(ctx, tx); err != nil {
-				return err
-			}
+				LevelInMempool: w.level(),
+				Size:           fees.Size(operation.Raw),
+			})
 		}
+		stored = true
+	}
+	if !stored {
+		w.filterStatus(operation.Hash)
+		return nil
+	}
+
+	history := w.newStatusHistory(operation.Hash, models.StatusApplied, w.level())
+	history.Node = msg.Node
+	history.Protocol = msg.Protocol
+	history.Timestamp = msg.ReceivedAt.UTC()
+	w.batch.addHistory(history)
+
+	if w.simulator != nil {
+		w.enqueueSimulation(operation)
 	}
 	return nil
 }
 
-func (indexer *Indexer) handleContent(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleStatusUpdate(ctx context.Context, hash string, contents []node.Content, errs models.JSONB, msg receiver.Message) error {
+	kinds := make([]string, 0, len(contents))
+	for i := range contents {
+		if !w.isKindAvailiable(contents[i].Kind) {
+			continue
+		}
+		kind := contents[i].Kind
+		if kind == node.KindEndorsementWithSlot {
+			kind = node.KindEndorsement
+		}
+		if !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	if w.mev != nil && msg.Status != receiver.StatusApplied {
+		w.mev.Remove(hash)
+	}
+
+	update := statusUpdate{
+		StatusUpdate: models.StatusUpdate{
+			Hash:      hash,
+			Status:    string(msg.Status),
+			Errors:    errs,
+			Timestamp: msg.ReceivedAt.UnixMilli(),
+		},
+		kinds: kinds,
+	}
+	for i := range contents {
+		if !w.isKindAvailiable(contents[i].Kind) {
+			continue
+		}
+		event := w.newEvent(stream.EventTypeStatus, hash, string(msg.Status), contents[i])
+		event.Node = msg.Node
+		event.Timestamp = msg.ReceivedAt.UTC()
+		update.events = append(update.events, event)
+	}
+
+	update.history = w.newStatusHistory(hash, string(msg.Status), w.level())
+	update.history.Node = msg.Node
+	update.history.Protocol = msg.Protocol
+	update.history.Errors = errs
+	update.history.Timestamp = msg.ReceivedAt.UTC()
+	w.batch.addStatus(update)
+	return w.flushIfFull(ctx)
+}
+
+// handleContent - adds the content to the batch. Returns false if the content was rejected by filters and nothing was added.
+func (w *worker) handleContent(ctx context.Context, content node.Content, operation models.MempoolOperation) (bool, error) {
 	operation.Kind = content.Kind
-	if indexer.prom != nil {
-		indexer.prom.IncrementCounter(operationCountMetricName, map[string]string{
+	if w.prom != nil {
+		w.prom.IncrementCounter(operationCountMetricName, map[string]string{
 			"kind":    content.Kind,
 			"status":  operation.Status,
-			"network": indexer.network,
+			"network": w.network,
 		})
 	}
 
-	addresses := indexer.filters.Addresses()
+	count := w.batch.Len()
+	if err := w.saveContent(ctx, content, operation); err != nil {
+		return false, err
+	}
+	return w.batch.Len() > count, nil
+}
+
+func (w *worker) saveContent(ctx context.Context, content node.Content, operation models.MempoolOperation) error {
+	ok, err := w.accounts.Match(content.Kind, content.Body)
+	if err != nil || !ok {
+		return err
+	}
 
 	switch content.Kind {
 	case node.KindActivation:
-		return handleActivateAccount(ctx, tx, content, operation, addresses...)
+		return w.handleActivateAccount(content, operation)
 	case node.KindBallot:
 		var model models.Ballot
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindDelegation:
 		var model models.Delegation
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindDoubleBaking:
-		return handleDoubleBaking(ctx, tx, content, operation)
+		return w.handleDoubleBaking(content, operation)
 	case node.KindDoubleEndorsing:
-		return handleDoubleEndorsing(ctx, tx, content, operation)
+		return w.handleDoubleEndorsing(content, operation)
 	case node.KindEndorsement:
-		return indexer.handleEndorsement(ctx, tx, content, operation)
+		return w.handleEndorsement(content, operation)
 	case node.KindEndorsementWithSlot:
-		return indexer.handleEndorsementWithSlot(ctx, tx, content, operation)
+		return w.handleEndorsementWithSlot(content, operation)
 	case node.KindEndorsementWithDal:
-		return indexer.handleEndorsement(ctx, tx, content, operation)
+		return w.handleEndorsement(content, operation)
 	case node.KindNonceRevelation:
 		var model models.NonceRevelation
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindOrigination:
-		return handleOrigination(ctx, tx, content, operation)
+		return w.handleOrigination(content, operation)
 	case node.KindProposal:
-		return handleProposal(ctx, tx, content, operation)
+		return w.handleProposal(content, operation)
 	case node.KindReveal:
-		return handleReveal(ctx, tx, content, operation, addresses...)
+		return w.handleReveal(content, operation)
 	case node.KindTransaction:
-		return handleTransaction(ctx, tx, content, operation, addresses...)
+		return w.handleTransaction(ctx, content, operation)
 	case node.KindRegisterGlobalConstant:
 		var model models.RegisterGlobalConstant
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindDoublePreendorsement:
 		var model models.DoublePreendorsing
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindPreendorsement:
 		var model models.Preendorsement
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSetDepositsLimit:
-		return handleSetDepositsLimit(ctx, tx, content, operation, addresses...)
+		return w.handleSetDepositsLimit(content, operation)
 	case node.KindTransferTicket:
 		var model models.TransferTicket
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupCommit:
 		var model models.TxRollupCommit
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupDispatchTickets:
 		var model models.TxRollupDispatchTickets
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupFinalizeCommitment:
 		var model models.TxRollupFinalizeCommitment
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupOrigination:
 		var model models.TxRollupOrigination
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupRejection:
 		var model models.TxRollupRejection
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupRemoveCommitment:
 		var model models.TxRollupRemoveCommitment
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupReturnBond:
 		var model models.TxRollupReturnBond
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindTxRollupSubmitBatch:
 		var model models.TxRollupSubmitBatch
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindIncreasePaidStorage:
 		var model models.IncreasePaidStorage
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindVdfRevelation:
 		var model models.VdfRevelation
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindUpdateConsensusKey:
 		var model models.UpdateConsensusKey
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindDrainDelegate:
 		var model models.DelegateDrain
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrAddMessages:
 		var model models.SmartRollupAddMessage
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrCement:
 		var model models.SmartRollupCement
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrExecute:
 		var model models.SmartRollupExecute
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrOriginate:
 		var model models.SmartRollupOriginate
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrPublish:
 		var model models.SmartRollupPublish
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrRecoverBond:
 		var model models.SmartRollupRecoverBond
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrRefute:
 		var model models.SmartRollupRefute
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindSrTimeout:
 		var model models.SmartRollupTimeout
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindDalPublishCommitment:
 		var model models.DalPublishCommitment
-		return defaultHandler(ctx, tx, content, operation, &model)
+		return w.defaultHandler(content, operation, &model)
 	case node.KindEvent:
 	default:
-		indexer.warn().Str("kind", content.Kind).Msg("unknown operation kind")
+		w.warn().Str("kind", content.Kind).Msg("unknown operation kind")
 	}
 	return nil
 }
 
-func createModel(ctx context.Context, tx bun.IDB, model any) error {
-	_, err := tx.NewInsert().Model(model).On("CONFLICT DO NOTHING").Exec(ctx)
-	return err
+// saveModel - adds the model to the batch. The event about the model is published if the model is stored when the batch is flushed.
+func (w *worker) saveModel(content node.Content, operation models.MempoolOperation, model any) {
+	event := w.newEvent(stream.EventTypeOperation, operation.Hash, operation.Status, content)
+	event.Kind = operation.Kind
+	event.Node = operation.Node
+	event.Data = model
+	w.batch.addOperation(model, event)
 }
 
-func (indexer *Indexer) handleEndorsement(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleEndorsement(content node.Content, operation models.MempoolOperation) error {
 	var endorsement models.Endorsement
 	if err := json.Unmarshal(content.Body, &endorsement); err != nil {
 		return err
 	}
 	endorsement.MempoolOperation = operation
 
-	if err := createModel(ctx, tx, &endorsement); err != nil {
-		return err
-	}
-	indexer.endorsements <- &endorsement
+	w.saveModel(content, operation, &endorsement)
 	return nil
 }
 
-func (indexer *Indexer) handleEndorsementWithSlot(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleEndorsementWithSlot(content node.Content, operation models.MempoolOperation) error {
 	var endorsementWithSlot node.EndorsementWithSlot
 	if err := json.Unmarshal(content.Body, &endorsementWithSlot); err != nil {
 		return err
@@ -349,101 +537,127 @@ func (indexer *Indexer) handleEndorsementWithSlot(ctx context.Context, tx bun.ID
 		Level:            endorsementWithSlot.Endorsement.Operation.Level,
 	}
 
-	if err := createModel(ctx, tx, &endorsement); err != nil {
-		return err
-	}
-	indexer.endorsements <- &endorsement
+	w.saveModel(content, operation, &endorsement)
 	return nil
 }
 
-func handleActivateAccount(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation, accounts ...string) error {
+func (w *worker) handleActivateAccount(content node.Content, operation models.MempoolOperation) error {
 	var activateAccount models.ActivateAccount
 	if err := json.Unmarshal(content.Body, &activateAccount); err != nil {
 		return err
 	}
-
-	if len(accounts) > 0 {
-		for _, account := range accounts {
-			if account == activateAccount.Pkh {
-				activateAccount.MempoolOperation = operation
-				return createModel(ctx, tx, &activateAccount)
-			}
-		}
-		return nil
-	}
-
 	activateAccount.MempoolOperation = operation
-	return createModel(ctx, tx, &activateAccount)
+	w.saveModel(content, operation, &activateAccount)
+	return nil
 }
 
-func handleTransaction(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation, accounts ...string) error {
+func (w *worker) handleTransaction(ctx context.Context, content node.Content, operation models.MempoolOperation) error {
 	var transaction models.Transaction
 	if err := json.Unmarshal(content.Body, &transaction); err != nil {
 		return err
 	}
-
-	if len(accounts) > 0 {
-		for _, account := range accounts {
-			if account == transaction.Source || account == transaction.Destination {
-				transaction.MempoolOperation = operation
-				return createModel(ctx, tx, &transaction)
-			}
+	value, err := transaction.Fill()
+	if err != nil {
+		return errors.Wrap(err, "transaction parameters")
+	}
+	if !w.transactions.Empty() {
+		ok, err := w.transactions.Match(transaction.Entrypoint, value)
+		if err != nil || !ok {
+			return err
 		}
-		return nil
 	}
-
+	var decodeLater bool
+	if w.scripts != nil && len(value) > 0 && strings.HasPrefix(transaction.Destination, "KT1") {
+		var cached bool
+		transaction.ParametersDecoded, cached = w.decodeCachedParameters(transaction.Destination, transaction.Entrypoint, value)
+		decodeLater = !cached
+	}
+	if w.mev != nil && operation.Status == models.StatusApplied && transaction.Entrypoint != "" {
+		w.batch.addSuspects(w.mev.Add(mev.Transaction{
+			Hash:        operation.Hash,
+			Source:      transaction.Source,
+			Destination: transaction.Destination,
+			Entrypoint:  transaction.Entrypoint,
+			Fee:         transaction.Fee,
+			GasLimit:    transaction.GasLimit,
+			Counter:     transaction.Counter,
+			Level:       w.level(),
+			Received:    time.UnixMilli(operation.FirstSeenAt),
+		})...)
+	}
 	transaction.MempoolOperation = operation
-	return createModel(ctx, tx, &transaction)
+	w.saveModel(content, operation, &transaction)
+	if decodeLater {
+		w.batch.addParameters(parametersTask{
+			hash:        operation.Hash,
+			counter:     transaction.Counter,
+			destination: transaction.Destination,
+			entrypoint:  transaction.Entrypoint,
+			value:       value,
+		})
+	}
+	return nil
 }
 
-func handleReveal(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation, accounts ...string) error {
+func (w *worker) handleReveal(content node.Content, operation models.MempoolOperation) error {
 	var reveal models.Reveal
 	if err := json.Unmarshal(content.Body, &reveal); err != nil {
 		return err
 	}
-
-	if len(accounts) > 0 {
-		for _, account := range accounts {
-			if account == reveal.Source {
-				reveal.MempoolOperation = operation
-				return createModel(ctx, tx, &reveal)
-			}
-		}
-		return nil
-	}
-
 	reveal.MempoolOperation = operation
-	return createModel(ctx, tx, &reveal)
+	w.saveModel(content, operation, &reveal)
+	return nil
 }
 
-func handleDoubleBaking(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleDoubleBaking(content node.Content, operation models.MempoolOperation) error {
 	var doubleBaking models.DoubleBaking
 	if err := json.Unmarshal(content.Body, &doubleBaking); err != nil {
 		return err
 	}
 	doubleBaking.Fill()
 	doubleBaking.MempoolOperation = operation
-	return createModel(ctx, tx, &doubleBaking)
+	w.saveModel(content, operation, &doubleBaking)
+	return nil
 }
 
-func handleDoubleEndorsing(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleDoubleEndorsing(content node.Content, operation models.MempoolOperation) error {
 	var doubleEndorsing models.DoubleEndorsing
 	if err := json.Unmarshal(content.Body, &doubleEndorsing); err != nil {
 		return err
 	}
 	doubleEndorsing.Fill()
 	doubleEndorsing.MempoolOperation = operation
-	return createModel(ctx, tx, &doubleEndorsing)
+	w.saveModel(content, operation, &doubleEndorsing)
+	return nil
 }
 
-func handleOrigination(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleOrigination(content node.Content, operation models.MempoolOperation) error {
 	var origination models.Origination
 	if err := json.Unmarshal(content.Body, &origination); err != nil {
 		return err
 	}
 	origination.Fill()
+	if w.scripts != nil && len(origination.Script.Code) > 0 {
+		origination.StorageDecoded = w.decodeStorage(origination.Script.Code, origination.Script.Storage)
+	}
 	origination.MempoolOperation = operation
-	return createModel(ctx, tx, &origination)
+	w.saveModel(content, operation, &origination)
+	return nil
+}
+
+// decodeStorage - decodes initial storage by the script of the origination
+func (w *worker) decodeStorage(code, storage []byte) models.JSONB {
+	script, err := michelson.ParseScript(code)
+	if err != nil {
+		w.warn().Err(err).Msg("parse origination script")
+		return nil
+	}
+	decoded, err := script.DecodeStorage(storage)
+	if err != nil {
+		w.warn().Err(err).Msg("decode storage")
+		return nil
+	}
+	return models.JSONB(decoded)
 }
 
 type proposals struct {
@@ -451,7 +665,7 @@ type proposals struct {
 	Proposals []string `json:"proposals"`
 }
 
-func handleProposal(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation) error {
+func (w *worker) handleProposal(content node.Content, operation models.MempoolOperation) error {
 	var proposal proposals
 	if err := json.Unmarshal(content.Body, &proposal); err != nil {
 		return err
@@ -461,39 +675,28 @@ func handleProposal(ctx context.Context, tx bun.IDB, content node.Content, opera
 		p.MempoolOperation = operation
 		p.Proposals = proposal.Proposals[i]
 		p.Period = proposal.Period
-		if err := createModel(ctx, tx, &p); err != nil {
-			return err
-		}
+		w.saveModel(content, operation, &p)
 	}
 	return nil
 }
 
-func handleSetDepositsLimit(ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation, accounts ...string) error {
+func (w *worker) handleSetDepositsLimit(content node.Content, operation models.MempoolOperation) error {
 	var setDepositsLimit models.SetDepositsLimit
 	if err := json.Unmarshal(content.Body, &setDepositsLimit); err != nil {
 		return err
 	}
-
-	if len(accounts) > 0 {
-		for _, account := range accounts {
-			if account == setDepositsLimit.Source {
-				setDepositsLimit.MempoolOperation = operation
-				return createModel(ctx, tx, &setDepositsLimit)
-			}
-		}
-		return nil
-	}
-
 	setDepositsLimit.MempoolOperation = operation
-	return createModel(ctx, tx, &setDepositsLimit)
+	w.saveModel(content, operation, &setDepositsLimit)
+	return nil
 }
 
-func defaultHandler[M models.ChangableMempoolOperation](ctx context.Context, tx bun.IDB, content node.Content, operation models.MempoolOperation, model M) error {
+func (w *worker) defaultHandler(content node.Content, operation models.MempoolOperation, model models.ChangableMempoolOperation) error {
 	if err := json.Unmarshal(content.Body, model); err != nil {
 		return err
 	}
 	model.SetMempoolOperation(operation)
-	return createModel(ctx, tx, model)
+	w.saveModel(content, operation, model)
+	return nil
 }
 
 func (indexer *Indexer) isKindAvailiable(kind string) bool {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/dipdup-io/workerpool"
@@ -11,104 +13,262 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"github.com/uptrace/bun"
 
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/dipdup-net/go-lib/node"
 	"github.com/dipdup-net/go-lib/prometheus"
 	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/fees"
+	"github.com/dipdup-net/mempool/cmd/mempool/filter"
+	"github.com/dipdup-net/mempool/cmd/mempool/mev"
+	"github.com/dipdup-net/mempool/cmd/mempool/michelson"
 	"github.com/dipdup-net/mempool/cmd/mempool/models"
 	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+	"github.com/dipdup-net/mempool/cmd/mempool/rpc"
+	"github.com/dipdup-net/mempool/cmd/mempool/simulator"
+	"github.com/dipdup-net/mempool/cmd/mempool/sink"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
 	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
 )
 
 // Indexer -
 type Indexer struct {
-	db               *database.Bun
-	tzkt             *tzkt.TzKT
-	mempool          *receiver.Receiver
-	prom             *prometheus.Service
-	branches         *BlockQueue
-	cache            *Cache
-	rights           *ccache.Cache
-	delegates        *CachedDelegates
-	state            *database.State
-	logger           zerolog.Logger
-	filters          config.Filters
-	endorsements     chan *models.Endorsement
-	network          string
-	indexName        string
-	chainID          string
-	keepInChain      uint64
-	keepOperations   uint64
-	gasStatsLifetime uint64
-	hasManager       bool
-
-	g workerpool.Group
+	db                 storage.Storage
+	tzkt               ChainSource
+	mempool            OperationSource
+	recorder           *record.Recorder
+	prom               *prometheus.Service
+	branches           *BlockQueue
+	accounts           *filter.Accounts
+	transactions       *filter.Transactions
+	scripts            *michelson.Scripts
+	simulator          OperationSimulator
+	simulations        chan simulator.Operation
+	parameters         chan parametersTask
+	mev                *mev.Detector
+	pending            *pendingOperations
+	pendingMx          sync.Mutex
+	fees               *fees.Estimator
+	hub                *stream.Hub
+	outbox             *sink.Outbox
+	workers            []*worker
+	chain              *worker
+	cache              *Cache
+	rights             *ccache.Cache
+	delegates          *CachedDelegates
+	state              *database.State
+	stateMx            sync.RWMutex
+	logger             zerolog.Logger
+	filters            config.Filters
+	endorsements       chan *models.Endorsement
+	network            string
+	indexName          string
+	chainID            string
+	keepInChain        uint64
+	keepOperations     uint64
+	gasStatsLifetime   uint64
+	batchSize          int
+	flushInterval      time.Duration
+	retentionInterval  time.Duration
+	retentionBatchSize int
+	simulationWorkers  int
+	hasManager         bool
+	archive            bool
+
+	g        workerpool.Group
+	pipeline workerpool.Group
 }
 
 // NewIndexer -
-func NewIndexer(ctx context.Context, network string, indexerCfg config.Indexer, db *database.Bun, settings config.Settings, prom *prometheus.Service) (*Indexer, error) {
-	rpc := node.NewMainRPC(indexerCfg.DataSource.RPC.Struct().URL)
-	constants, err := rpc.Constants(ctx, "head")
+func NewIndexer(ctx context.Context, network string, indexerCfg config.Indexer, db storage.Storage, settings config.Settings, prom *prometheus.Service, hub *stream.Hub, outbox *sink.Outbox, opts ...IndexerOption) (*Indexer, error) {
+	if settings.Record.Dir != "" && settings.Replay.Dir != "" {
+		return nil, errors.Errorf("record and replay modes can't be enabled together: %s", network)
+	}
+	accounts, err := filter.NewAccounts(indexerCfg.Filters)
+	if err != nil {
+		return nil, errors.Wrap(err, network)
+	}
+	transactions, err := filter.NewTransactions(indexerCfg.Filters)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, network)
+	}
+
+	var src sources
+	for i := range opts {
+		opts[i](&src)
 	}
-	delay := constants.MinimalBlockDelay
-	if delay == 0 {
-		if len(constants.TimeBetweenBlocks) == 0 {
-			return nil, errors.Errorf("Empty time_between_blocks in node response: %s", network)
+
+	if src.info == nil {
+		if settings.Replay.Dir != "" {
+			head, err := record.ReadHead(record.FileName(settings.Replay.Dir, network))
+			if err != nil {
+				return nil, err
+			}
+			src.info = recordedChainInfo{head}
+		} else {
+			src.info = node.NewMainRPC(indexerCfg.DataSource.URL())
 		}
-		delay = constants.TimeBetweenBlocks[0]
 	}
 
-	head, err := rpc.Header(ctx, "head")
-	if err != nil {
-		return nil, err
+	var scripts *michelson.Scripts
+	if settings.DecodeMichelson {
+		if src.scripts == nil {
+			src.scripts = node.NewMainRPC(indexerCfg.DataSource.URL())
+		}
+		scripts = michelson.NewScripts(src.scripts)
 	}
 
-	memInd, err := receiver.New(indexerCfg.DataSource.URL(), network, db,
-		receiver.WithPrometheus(prom),
-		receiver.WithBlockTime(delay),
-	)
+	head, err := fetchHead(ctx, src.info)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, network)
+	}
+	delay := head.BlockTime
+
+	if settings.Simulation.Enabled && src.simulator == nil {
+		timeout := time.Duration(settings.Simulation.Timeout) * time.Second
+		src.simulator = simulator.New(indexerCfg.DataSource.URL(), head.ChainID, simulator.WithTimeout(timeout))
 	}
 
 	expiredAfter := settings.ExpiredAfter
 	if expiredAfter == 0 {
-		metadata, err := rpc.Metadata(ctx, "head")
+		metadata, err := src.info.Metadata(ctx, "head")
 		if err != nil {
 			return nil, err
 		}
 		expiredAfter = metadata.MaxOperationsTTL
 	}
 
+	var recorder *record.Recorder
+	if settings.Replay.Dir != "" && src.operations == nil && src.chain == nil {
+		src.operations, src.chain = newReplaySources(network, settings.Replay, delay)
+	}
+	if src.chain == nil && indexerCfg.ChainSource != config.ChainSourceNode && indexerCfg.DataSource.Tzkt == nil {
+		return nil, errors.Errorf("tzkt data source is required when chain source isn't node: %s", network)
+	}
+	if src.operations == nil || src.chain == nil {
+		receiverOpts := []receiver.ReceiverOption{
+			receiver.WithPrometheus(prom),
+			receiver.WithBlockTime(delay),
+			receiver.WithActiveNodes(settings.RPC.ActiveNodes),
+			receiver.WithFailover(settings.RPC.MaxErrors, settings.RPC.StalledBlocks),
+		}
+		var (
+			tzktOpts []tzkt.TzKTOption
+			rpcOpts  []rpc.ChainOption
+		)
+		if settings.Record.Dir != "" {
+			recorder, err = newRecorder(record.FileName(settings.Record.Dir, network), settings.Record.Overwrite, head)
+			if err != nil {
+				return nil, err
+			}
+			receiverOpts = append(receiverOpts, receiver.WithRecorder(recorder))
+			tzktOpts = append(tzktOpts, tzkt.WithRecorder(recorder))
+			rpcOpts = append(rpcOpts, rpc.WithRecorder(recorder))
+		}
+
+		if src.operations == nil {
+			memInd, err := receiver.New(indexerCfg.DataSource.URLs(), network, db, receiverOpts...)
+			if err != nil {
+				if recorder != nil {
+					recorder.Close()
+				}
+				return nil, err
+			}
+			src.operations = memInd
+		}
+		if src.chain == nil {
+			switch indexerCfg.ChainSource {
+			case config.ChainSourceNode:
+				src.chain = rpc.NewChain(indexerCfg.DataSource.URL(), indexerCfg.Filters.Addresses(), indexerCfg.Filters.Kinds, rpcOpts...)
+			default:
+				src.chain = tzkt.NewTzKT(indexerCfg.DataSource.Tzkt.Struct().URL, indexerCfg.Filters.Addresses(), indexerCfg.Filters.Kinds, tzktOpts...)
+			}
+		}
+	}
+
 	gasStatsLifetime := settings.GasStatsLifetime
 	if gasStatsLifetime == 0 {
 		gasStatsLifetime = 3600
 	}
 
+	batchSize := settings.Batch.Size
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+	flushInterval := time.Duration(settings.Batch.FlushInterval) * time.Millisecond
+	if flushInterval == 0 {
+		flushInterval = defaultBatchFlushInterval
+	}
+	retentionInterval := time.Duration(settings.Retention.Interval) * time.Second
+	if retentionInterval == 0 {
+		retentionInterval = defaultRetentionInterval
+	}
+	retentionBatchSize := settings.Retention.BatchSize
+	if retentionBatchSize == 0 {
+		retentionBatchSize = defaultRetentionBatchSize
+	}
+	workersCount := settings.Workers
+	if workersCount == 0 {
+		workersCount = defaultWorkersCount
+	}
+	simulationWorkers := settings.Simulation.Workers
+	if simulationWorkers == 0 {
+		simulationWorkers = defaultSimulationWorkers
+	}
+
 	indexer := &Indexer{
-		db:               db,
-		network:          network,
-		chainID:          head.ChainID,
-		indexName:        models.MempoolIndexName(network),
-		filters:          indexerCfg.Filters,
-		tzkt:             tzkt.NewTzKT(indexerCfg.DataSource.Tzkt.Struct().URL, indexerCfg.Filters.Addresses(), indexerCfg.Filters.Kinds),
-		mempool:          memInd,
-		prom:             prom,
-		cache:            NewCache(2 * time.Hour),
-		keepInChain:      uint64(delay) * settings.KeepInChainBlocks,
-		keepOperations:   uint64(delay) * settings.ExpiredAfter,
-		gasStatsLifetime: gasStatsLifetime,
-		endorsements:     make(chan *models.Endorsement, 1024*32),
-		rights:           ccache.New(ccache.Configure().MaxSize(60)),
-		logger:           log.Logger.With().Str("network", network).Logger(),
-		g:                workerpool.NewGroup(),
+		db:                 db,
+		network:            network,
+		chainID:            head.ChainID,
+		indexName:          models.MempoolIndexName(network),
+		filters:            indexerCfg.Filters,
+		accounts:           accounts,
+		transactions:       transactions,
+		scripts:            scripts,
+		simulationWorkers:  simulationWorkers,
+		tzkt:               src.chain,
+		mempool:            src.operations,
+		recorder:           recorder,
+		prom:               prom,
+		hub:                hub,
+		outbox:             outbox,
+		cache:              NewCache(2 * time.Hour),
+		pending:            newPendingOperations(2*time.Duration(delay)*time.Second, defaultPendingLimit),
+		keepInChain:        uint64(delay) * settings.KeepInChainBlocks,
+		keepOperations:     uint64(delay) * settings.ExpiredAfter,
+		gasStatsLifetime:   gasStatsLifetime,
+		batchSize:          batchSize,
+		flushInterval:      flushInterval,
+		retentionInterval:  retentionInterval,
+		retentionBatchSize: retentionBatchSize,
+		archive:            settings.Archive.Enabled,
+		endorsements:       make(chan *models.Endorsement, 1024*32),
+		parameters:         make(chan parametersTask, parametersQueueSize),
+		rights:             ccache.New(ccache.Configure().MaxSize(60)),
+		logger:             log.Logger.With().Str("network", network).Logger(),
+		g:                  workerpool.NewGroup(),
+		pipeline:           workerpool.NewGroup(),
 	}
+	indexer.workers = make([]*worker, workersCount)
+	for i := range indexer.workers {
+		indexer.workers[i] = newWorker(indexer, batchSize)
+	}
+	indexer.chain = newWorker(indexer, 0)
 	indexer.cache.Start(ctx)
 
+	if settings.Mev.Enabled && slices.Contains(indexerCfg.Filters.Kinds, node.KindTransaction) {
+		indexer.mev = mev.NewDetector(network, settings.Mev.WindowBlocks,
+			mev.WithRateMargin(settings.Mev.RateMargin),
+			mev.WithMaxDelay(time.Duration(settings.Mev.MaxDelay)*time.Second),
+		)
+	}
+	if settings.Simulation.Enabled {
+		indexer.simulator = src.simulator
+		indexer.simulations = make(chan simulator.Operation, simulationQueueSize)
+	}
+
 	indexer.state = &database.State{
 		IndexType: models.IndexTypeMempool,
 		IndexName: indexer.indexName,
@@ -121,11 +281,17 @@ func NewIndexer(ctx context.Context, network string, indexerCfg config.Indexer,
 			break
 		}
 	}
-	indexer.branches = newBlockQueue(expiredAfter, indexer.onPopBlockQueue, indexer.onRollbackBlockQueue)
+	if indexer.hasManager {
+		indexer.fees = fees.NewEstimator(network, db,
+			fees.WithBlocks(settings.FeeEstimator.Blocks...),
+			fees.WithConfidence(settings.FeeEstimator.Confidence...),
+		)
+	}
+	indexer.branches = newBlockQueue(expiredAfter, indexer.chain.onPopBlockQueue, indexer.chain.onRollbackBlockQueue, indexer.chain.onReorgBlockQueue, indexer.tzkt.GetBlock)
 
 	for _, kind := range indexer.filters.Kinds {
 		if kind == node.KindEndorsement {
-			indexer.delegates = newCachedDelegates(indexer.tzkt, constants.BlocksPerCycle)
+			indexer.delegates = newCachedDelegates(indexer.tzkt, head.BlocksPerCycle)
 			break
 		}
 	}
@@ -140,8 +306,22 @@ func (indexer *Indexer) Start(ctx context.Context) error {
 	if err := indexer.initState(ctx); err != nil {
 		return err
 	}
+	if err := indexer.restoreBlocks(ctx); err != nil {
+		return err
+	}
 
+	for i := range indexer.workers {
+		indexer.pipeline.GoCtx(ctx, indexer.workers[i].run)
+	}
+	indexer.pipeline.GoCtx(ctx, indexer.listenChain)
 	indexer.g.GoCtx(ctx, indexer.listen)
+	indexer.g.GoCtx(ctx, indexer.retention)
+	for i := 0; i < indexer.simulationWorkers && indexer.simulator != nil; i++ {
+		indexer.g.GoCtx(ctx, indexer.simulate)
+	}
+	for i := 0; i < parametersWorkers && indexer.scripts != nil; i++ {
+		indexer.g.GoCtx(ctx, indexer.decodeParameters)
+	}
 
 	if indexer.delegates != nil {
 		if err := indexer.delegates.Init(ctx); err != nil {
@@ -152,7 +332,7 @@ func (indexer *Indexer) Start(ctx context.Context) error {
 
 		var offset int
 		for {
-			endorsements, err := models.EndorsementsWithoutBaker(ctx, indexer.db.DB(), indexer.network, 100, offset)
+			endorsements, err := indexer.db.EndorsementsWithoutBaker(ctx, indexer.network, 100, offset)
 			if err != nil {
 				indexer.error(err).Msg("get endorsements without baker")
 				break
@@ -184,7 +364,7 @@ func (indexer *Indexer) Start(ctx context.Context) error {
 func (indexer *Indexer) sync(ctx context.Context) {
 	indexer.info().Msg("start syncing...")
 	indexer.g.GoCtx(ctx, func(ctx context.Context) {
-		indexer.tzkt.Sync(ctx, indexer.state.Level)
+		indexer.tzkt.Sync(ctx, indexer.level())
 	})
 }
 
@@ -207,6 +387,30 @@ func (indexer *Indexer) initState(ctx context.Context) error {
 	return nil
 }
 
+// restoreBlocks - loads stored blocks into the queue, so branches of mempool operations are known right after restart
+func (indexer *Indexer) restoreBlocks(ctx context.Context) error {
+	blocks, err := indexer.db.Blocks(ctx, indexer.network)
+	if err != nil {
+		return errors.Wrap(err, "restore blocks")
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	skipped := indexer.branches.Load(blocks)
+	if len(skipped) > 0 {
+		hashes := make([]string, len(skipped))
+		for i := range skipped {
+			hashes[i] = skipped[i].Hash
+		}
+		if err := indexer.db.DeleteBlocks(ctx, indexer.network, hashes...); err != nil {
+			return errors.Wrap(err, "delete skipped blocks")
+		}
+	}
+	indexer.info().Int("count", len(blocks)-len(skipped)).Msg("blocks were restored")
+	return nil
+}
+
 // Close -
 func (indexer *Indexer) Close() {
 	indexer.g.Wait()
@@ -226,6 +430,13 @@ func (indexer *Indexer) close() error {
 		return err
 	}
 
+	if indexer.recorder != nil {
+		indexer.info().Msg("closing recorder...")
+		if err := indexer.recorder.Close(); err != nil {
+			return err
+		}
+	}
+
 	indexer.info().Msg("closing database...")
 	if err := indexer.db.Close(); err != nil {
 		return err
@@ -241,22 +452,19 @@ func (indexer *Indexer) close() error {
 	return nil
 }
 
+// listen - decodes and filters mempool operations and dispatches them to workers
 func (indexer *Indexer) listen(ctx context.Context) {
+	ticker := time.NewTicker(pendingReleaseInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
+			indexer.pipeline.Wait()
 			indexer.close()
 			return
-		case operations := <-indexer.tzkt.Operations():
-			if err := indexer.handleInChain(ctx, operations); err != nil {
-				indexer.error(err).Msg("handleInChain")
-				continue
-			}
-		case block := <-indexer.tzkt.Blocks():
-			if err := indexer.handleBlock(ctx, block); err != nil {
-				indexer.error(err).Msg("handleBlock")
-				continue
-			}
+		case <-ticker.C:
+			indexer.releasePending(ctx)
 		case msg := <-indexer.mempool.Operations():
 			switch msg.Status {
 			case receiver.StatusApplied:
@@ -266,32 +474,33 @@ func (indexer *Indexer) listen(ctx context.Context) {
 					continue
 				}
 				if !indexer.branches.Contains(applied.Branch) {
+					indexer.pending.Add(msg, applied, time.Now())
 					continue
 				}
-				if indexer.isHashProcessed(applied.Hash) {
-					continue
-				}
-				if err := indexer.handleAppliedOperation(ctx, applied, msg.Protocol); err != nil {
-					indexer.error(err).Msg("handleAppliedOperation")
-					continue
-				}
+				indexer.pending.Remove(applied.Hash)
+				indexer.dispatchApplied(ctx, msg, applied)
 			case receiver.StatusBranchDelayed, receiver.StatusBranchRefused, receiver.StatusRefused, receiver.StatusUnprocessed, receiver.StatusOutdated:
 				failed, ok := msg.Body.(node.FailedMonitor)
 				if !ok {
 					indexer.error(nil).Msgf("invalid %s operation %v", msg.Status, failed)
 					continue
 				}
+				// the buffered applied status is stale now
+				indexer.pending.Remove(failed.Hash)
 
 				if !indexer.branches.Contains(failed.Branch) {
 					continue
 				}
-				if indexer.isHashProcessed(failed.Hash) {
-					continue
-				}
-				if err := indexer.handleFailedOperation(ctx, failed, string(msg.Status), msg.Protocol); err != nil {
-					indexer.error(err).Msg("handleFailedOperation")
+				prev, processed := indexer.swapStatus(failed.Hash, string(msg.Status))
+				if isDuplicate(prev, string(msg.Status), processed) {
 					continue
 				}
+				indexer.dispatch(ctx, task{
+					msg:      msg,
+					hash:     failed.Hash,
+					failed:   &failed,
+					isUpdate: processed,
+				})
 			default:
 				indexer.error(nil).Msgf("invalid mempool operation status %s", msg.Status)
 			}
@@ -299,46 +508,207 @@ func (indexer *Indexer) listen(ctx context.Context) {
 	}
 }
 
-func (indexer *Indexer) isHashProcessed(hash string) bool {
-	key := fmt.Sprintf("hash:%s", hash)
-	if !indexer.cache.Has(key) {
-		indexer.cache.Set(key)
-		return false
+func (indexer *Indexer) dispatchApplied(ctx context.Context, msg receiver.Message, applied node.Applied) {
+	prev, processed := indexer.swapStatus(applied.Hash, string(msg.Status))
+	if isDuplicate(prev, string(msg.Status), processed) {
+		return
+	}
+	indexer.dispatch(ctx, task{
+		msg:      msg,
+		hash:     applied.Hash,
+		applied:  &applied,
+		isUpdate: processed,
+	})
+}
+
+// releasePending - dispatches buffered applied operations which branch became known and drops expired ones.
+// It's serialized with dropping of included operations, so the applied status isn't dispatched after the inclusion.
+func (indexer *Indexer) releasePending(ctx context.Context) {
+	if indexer.pending.Len() == 0 {
+		return
+	}
+	indexer.pendingMx.Lock()
+	defer indexer.pendingMx.Unlock()
+
+	released, expired := indexer.pending.Release(indexer.branches.Contains, time.Now())
+	for i := range released {
+		indexer.dispatchApplied(ctx, released[i].msg, released[i].applied)
+	}
+	if expired > 0 {
+		indexer.warn().Int("count", expired).Msg("applied operations with unknown branch were dropped")
+	}
+}
+
+// listenChain - handles TzKT operations and blocks. It's separated from mempool processing so slow block handling doesn't stall mempool ingestion.
+func (indexer *Indexer) listenChain(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case operations := <-indexer.tzkt.Operations():
+			if err := indexer.chain.handleInChain(ctx, operations); err != nil {
+				indexer.error(err).Msg("handleInChain")
+				continue
+			}
+		case block := <-indexer.tzkt.Blocks():
+			if err := indexer.handleBlock(ctx, block); err != nil {
+				indexer.error(err).Msg("handleBlock")
+				continue
+			}
+		}
+	}
+}
+
+// flushOnStop - writes the rest of the batch when the indexer is stopping. The context of indexer is already cancelled at that moment.
+func (w *worker) flushOnStop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := w.flush(ctx); err != nil {
+		w.error(err).Msg("flush")
 	}
-	return true
 }
 
-func (indexer *Indexer) onPopBlockQueue(ctx context.Context, block Block) error {
-	indexer.info().Uint64("block", block.Level).Msgf("operations with branch %s is expired", block.Branch)
-	return models.SetExpired(ctx, indexer.db.DB(), indexer.network, block.Branch, indexer.filters.Kinds...)
+// statusFiltered - marker of operations rejected by filters. Their statuses aren't processed anymore.
+const statusFiltered = "filtered"
+
+// swapStatus - stores the last mempool status of the operation and returns the previous one with flag whether the operation was processed before.
+// The marker of filtered operation is kept.
+func (indexer *Indexer) swapStatus(hash, status string) (string, bool) {
+	return indexer.cache.Swap(fmt.Sprintf("hash:%s", hash), status, statusFiltered)
+}
+
+// isDuplicate - returns true if the status of processed operation doesn't have to be dispatched
+func isDuplicate(prev, status string, processed bool) bool {
+	return processed && (prev == status || prev == statusFiltered)
+}
+
+// filterStatus - marks the operation as rejected by filters, so its next statuses are skipped
+func (indexer *Indexer) filterStatus(hash string) {
+	indexer.cache.SetValue(fmt.Sprintf("hash:%s", hash), statusFiltered)
+}
+
+// forgetStatus - removes the last mempool status of the operation, so the operation is processed as new when it's received again
+func (indexer *Indexer) forgetStatus(hash string) {
+	indexer.cache.Delete(fmt.Sprintf("hash:%s", hash))
 }
 
-func (indexer *Indexer) onRollbackBlockQueue(ctx context.Context, block Block) error {
-	indexer.warn().Msgf("Rollback to %d level", block.Level)
-	indexer.state.Level = block.Level
-	indexer.state.Timestamp = block.Timestamp
+func (w *worker) onPopBlockQueue(ctx context.Context, block Block) error {
+	w.info().Uint64("block", block.Level).Msgf("operations with branch %s is expired", block.Branch)
 
-	return indexer.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		if err := models.Rollback(ctx, tx, indexer.network, block.Branch, block.Level, indexer.filters.Kinds...); err != nil {
+	return w.runInTx(ctx, func(ctx context.Context, tx storage.Tx) error {
+		hashes, err := tx.SetExpired(ctx, w.network, block.Branch, w.filters.Kinds...)
+		if err != nil {
 			return err
 		}
-		_, err := tx.NewUpdate().Model(indexer.state).WherePK().Exec(ctx)
-		return err
+		if err := tx.DeleteBlocks(ctx, w.network, block.Branch); err != nil {
+			return err
+		}
+
+		history := make([]models.StatusHistory, 0, len(hashes))
+		for i := range hashes {
+			history = append(history, w.newStatusHistory(hashes[i], models.StatusExpired, w.state.Level))
+			w.enqueue(w.newEvent(stream.EventTypeStatus, hashes[i], models.StatusExpired, node.Content{}))
+		}
+		return tx.SaveStatusHistory(ctx, history...)
+	})
+}
+
+func (w *worker) onRollbackBlockQueue(ctx context.Context, block Block) error {
+	w.warn().Msgf("Rollback block %s of %d level", block.Branch, block.Level)
+	w.stateMx.Lock()
+	w.state.Level = block.Level - 1
+	w.stateMx.Unlock()
+
+	return w.runInTx(ctx, func(ctx context.Context, tx storage.Tx) error {
+		changes, err := tx.Rollback(ctx, w.network, block.Branch, block.Level, w.filters.Kinds...)
+		if err != nil {
+			return err
+		}
+		if err := tx.DeleteBlocks(ctx, w.network, block.Branch); err != nil {
+			return err
+		}
+
+		history := make([]models.StatusHistory, 0, len(changes))
+		for i := range changes {
+			history = append(history, w.newStatusHistory(changes[i].Hash, changes[i].Status, block.Level))
+			w.enqueue(w.newEvent(stream.EventTypeStatus, changes[i].Hash, changes[i].Status, node.Content{}))
+		}
+		if err := tx.SaveStatusHistory(ctx, history...); err != nil {
+			return err
+		}
+		return tx.UpdateState(ctx, w.state)
+	})
+
+}
+
+// onReorgBlockQueue - is called when orphaned blocks are rolled back. It moves the state to the common ancestor and publishes reorg event.
+func (w *worker) onReorgBlockQueue(ctx context.Context, ancestor Block, orphaned []Block) error {
+	w.warn().Uint64("ancestor", ancestor.Level).Int("depth", len(orphaned)).Msg("chain reorganization")
+	if w.prom != nil {
+		w.prom.IncrementCounter(reorgCountMetricName, map[string]string{
+			"network": w.network,
+		})
+	}
+
+	w.stateMx.Lock()
+	w.state.Level = ancestor.Level
+	if ancestor.Branch != "" {
+		w.state.Hash = ancestor.Branch
+	}
+	if !ancestor.Timestamp.IsZero() {
+		w.state.Timestamp = ancestor.Timestamp
+	}
+	w.stateMx.Unlock()
+
+	reorg := stream.Reorg{
+		Orphaned: make([]string, 0, len(orphaned)),
+	}
+	for i := range orphaned {
+		reorg.Orphaned = append(reorg.Orphaned, orphaned[i].Branch)
+	}
+
+	return w.runInTx(ctx, func(ctx context.Context, tx storage.Tx) error {
+		w.enqueue(stream.Event{
+			Type:      stream.EventTypeReorg,
+			Network:   w.network,
+			Hash:      ancestor.Branch,
+			Level:     ancestor.Level,
+			Timestamp: time.Now().UTC(),
+			Data:      reorg,
+		})
+		return tx.UpdateState(ctx, w.state)
 	})
+}
+
+// level - returns current level of the indexer state. It's safe for concurrent use.
+func (indexer *Indexer) level() uint64 {
+	indexer.stateMx.RLock()
+	defer indexer.stateMx.RUnlock()
+	return indexer.state.Level
+}
 
+func (indexer *Indexer) newStatusHistory(hash, status string, level uint64) models.StatusHistory {
+	return models.StatusHistory{
+		Network:   indexer.network,
+		Hash:      hash,
+		Status:    status,
+		Level:     level,
+		Timestamp: time.Now().UTC(),
+	}
 }
 
 func (indexer *Indexer) error(err error) *zerolog.Event {
 	if err == nil {
-		return indexer.logger.Error().Uint64("state", indexer.state.Level)
+		return indexer.logger.Error().Uint64("state", indexer.level())
 	}
-	return indexer.logger.Err(err).Uint64("state", indexer.state.Level)
+	return indexer.logger.Err(err).Uint64("state", indexer.level())
 }
 
 func (indexer *Indexer) info() *zerolog.Event {
-	return indexer.logger.Info().Uint64("state", indexer.state.Level)
+	return indexer.logger.Info().Uint64("state", indexer.level())
 }
 
 func (indexer *Indexer) warn() *zerolog.Event {
-	return indexer.logger.Warn().Uint64("state", indexer.state.Level)
+	return indexer.logger.Warn().Uint64("state", indexer.level())
 }
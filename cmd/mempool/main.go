@@ -11,6 +11,7 @@ import (
 
 	"github.com/dipdup-io/workerpool"
 	"github.com/grafana/pyroscope-go"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -19,9 +20,15 @@ import (
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/dipdup-net/go-lib/hasura"
 	"github.com/dipdup-net/go-lib/prometheus"
+	"github.com/dipdup-net/mempool/cmd/mempool/api"
+	"github.com/dipdup-net/mempool/cmd/mempool/archive"
 	"github.com/dipdup-net/mempool/cmd/mempool/config"
 	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/notify"
 	"github.com/dipdup-net/mempool/cmd/mempool/profiler"
+	"github.com/dipdup-net/mempool/cmd/mempool/sink"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
 )
 
 type startResult struct {
@@ -43,10 +50,15 @@ func main() {
 	}).Level(zerolog.InfoLevel)
 
 	configPath := rootCmd.PersistentFlags().StringP("config", "c", "dipdup.yml", "path to YAML config file")
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.AddCommand(newExportCommand())
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
 		log.Panic().Err(err).Msg("command line execute")
 		return
 	}
+	if cmd != rootCmd {
+		return
+	}
 	if err := rootCmd.MarkFlagRequired("config"); err != nil {
 		log.Panic().Err(err).Msg("config command line arg is required")
 		return
@@ -90,10 +102,69 @@ func main() {
 		}
 	}
 
-	db, err := models.OpenDatabaseConnection(ctx, cfg.Database, filters...)
-	if err != nil {
-		log.Err(err).Msg("open database connection")
-		return
+	var (
+		db    *database.Bun
+		store storage.Storage
+	)
+	if cfg.Mempool.Settings.Storage == config.StorageMemory {
+		if err := checkMemoryStorage(cfg); err != nil {
+			log.Err(err).Msg("memory storage")
+			return
+		}
+		store = storage.NewMemory()
+	} else {
+		open := models.OpenDatabaseConnection
+		if cfg.Mempool.Settings.Archive.Enabled {
+			open = models.OpenArchiveDatabaseConnection
+		}
+		conn, err := open(ctx, cfg.Database, filters...)
+		if err != nil {
+			log.Err(err).Msg("open database connection")
+			return
+		}
+		db = conn
+
+		var opts []storage.PostgresOption
+		if cfg.Mempool.Settings.Archive.Enabled {
+			opts = append(opts, storage.WithArchive())
+		}
+		store = storage.NewPostgres(db, opts...)
+	}
+
+	var arch *archive.Archive
+	if cfg.Mempool.Settings.Archive.Enabled {
+		a, err := archive.New(db, cfg.Mempool.Settings.Archive, filters...)
+		if err != nil {
+			log.Err(err).Msg("create archive")
+			return
+		}
+		if err := a.Init(ctx); err != nil {
+			log.Err(err).Msg("init archive partitions")
+			return
+		}
+		a.Start(ctx)
+		arch = a
+	}
+
+	hub := stream.NewHub()
+
+	var (
+		outbox *sink.Outbox
+		relay  *sink.Relay
+	)
+	if cfg.Sink != nil {
+		s, err := sink.New(cfg.Sink)
+		if err != nil {
+			log.Err(err).Msg("create sink")
+			return
+		}
+		relay = sink.NewRelay(db, s, cfg.Sink.BatchSize)
+		if err := relay.Init(ctx); err != nil {
+			log.Err(err).Msg("init sink outbox")
+			return
+		}
+		relay.Start(ctx)
+		outbox = sink.NewOutbox(cfg.Sink.SubjectPrefix, cfg.Sink.MaxPayload)
 	}
 
 	g := workerpool.NewGroup()
@@ -101,7 +172,7 @@ func main() {
 	indexers := make(map[string]*Indexer)
 
 	startFunc := func(ctx context.Context, network string, mempool *config.Indexer) error {
-		result, err := startIndexer(ctx, network, cfg, mempool, db, prometheusService)
+		result, err := startIndexer(ctx, network, cfg, mempool, store, prometheusService, hub, outbox)
 		if err != nil {
 			return err
 		}
@@ -146,14 +217,18 @@ func main() {
 
 	g.Wait()
 
-	views, err := createViews(ctx, cfg.Database)
-	if err != nil {
-		log.Err(err).Msg("creating views")
-		cancel()
-		return
+	var views []string
+	if db != nil {
+		v, err := createViews(ctx, cfg.Database)
+		if err != nil {
+			log.Err(err).Msg("creating views")
+			cancel()
+			return
+		}
+		views = v
 	}
 
-	if cfg.Hasura != nil {
+	if cfg.Hasura != nil && db != nil {
 		t := make([]string, 0)
 		for kind := range kinds {
 			t = append(t, kind)
@@ -170,9 +245,48 @@ func main() {
 		}
 	}
 
+	networks := make(map[string][]string, len(cfg.Mempool.Indexers))
+	for network, mempool := range cfg.Mempool.Indexers {
+		networks[network] = mempool.Filters.Kinds
+	}
+
+	var apiServer *api.Server
+	if cfg.API != nil {
+		server, err := api.New(cfg.API, db, hub, networks)
+		if err != nil {
+			log.Err(err).Msg("create API server")
+			cancel()
+			return
+		}
+		apiServer = server
+		apiServer.Start(ctx)
+	}
+
+	var notifier *notify.Notifier
+	if len(cfg.Notifications) > 0 {
+		n, err := notify.New(cfg.Notifications, db, hub, networks)
+		if err != nil {
+			log.Err(err).Msg("create notifier")
+			cancel()
+			return
+		}
+		if err := n.Start(ctx); err != nil {
+			log.Err(err).Msg("start notifier")
+			cancel()
+			return
+		}
+		notifier = n
+	}
+
 	<-notifyCtx.Done()
 	log.Info().Msg("Trying carefully stopping....")
 
+	if apiServer != nil {
+		if err := apiServer.Close(); err != nil {
+			log.Err(err).Msg("stopping API server")
+		}
+	}
+
 	for _, indexerCancel := range indexerCancels {
 		indexerCancel()
 	}
@@ -183,6 +297,24 @@ func main() {
 		indexer.Close()
 	}
 
+	if notifier != nil {
+		if err := notifier.Close(); err != nil {
+			log.Err(err).Msg("stopping notifier")
+		}
+	}
+
+	if relay != nil {
+		if err := relay.Close(); err != nil {
+			log.Err(err).Msg("stopping sink")
+		}
+	}
+
+	if arch != nil {
+		if err := arch.Close(); err != nil {
+			log.Err(err).Msg("stopping archive")
+		}
+	}
+
 	if prometheusService != nil {
 		if err := prometheusService.Close(); err != nil {
 			log.Err(err).Msg("stopping prometheus")
@@ -229,11 +361,11 @@ func createViews(ctx context.Context, database libCfg.Database) ([]string, error
 	return views, nil
 }
 
-func startIndexer(ctx context.Context, network string, cfg config.Config, mempool *config.Indexer, db *database.Bun, prometheusService *prometheus.Service) (startResult, error) {
+func startIndexer(ctx context.Context, network string, cfg config.Config, mempool *config.Indexer, db storage.Storage, prometheusService *prometheus.Service, hub *stream.Hub, outbox *sink.Outbox) (startResult, error) {
 	var result startResult
 
 	indexerCtx, cancel := context.WithCancel(ctx)
-	indexer, err := NewIndexer(indexerCtx, network, *mempool, db, cfg.Mempool.Settings, prometheusService)
+	indexer, err := NewIndexer(indexerCtx, network, *mempool, db, cfg.Mempool.Settings, prometheusService, hub, outbox)
 	if err != nil {
 		cancel()
 		return result, err
@@ -247,3 +379,20 @@ func startIndexer(ctx context.Context, network string, cfg config.Config, mempoo
 	result.cancel = cancel
 	return result, nil
 }
+
+// checkMemoryStorage - returns error if any configured feature reads the data from PostgreSQL which is absent with memory storage
+func checkMemoryStorage(cfg config.Config) error {
+	switch {
+	case cfg.API != nil:
+		return errors.New("HTTP API requires postgres storage")
+	case len(cfg.Notifications) > 0:
+		return errors.New("notifications require postgres storage")
+	case cfg.Sink != nil:
+		return errors.New("sink requires postgres storage")
+	case cfg.Mempool.Settings.Archive.Enabled:
+		return errors.New("archive mode requires postgres storage")
+	case cfg.Hasura != nil:
+		log.Warn().Msg("hasura is not supported by memory storage and will be skipped")
+	}
+	return nil
+}
@@ -5,6 +5,13 @@ import "github.com/dipdup-net/go-lib/prometheus"
 const (
 	operationCountMetricName = "mempool_operation_count"
 	rpcErrorsCountName       = "mempool_rpc_errors_count"
+	nodeFirstSeenCountName   = "mempool_node_first_seen_count"
+	nodeDuplicatesCountName  = "mempool_node_duplicates_count"
+	nodeActiveGaugeName      = "mempool_node_active"
+	nodeSwitchesCountName    = "mempool_node_switches_count"
+	reorgCountMetricName     = "mempool_reorg_count"
+
+	retentionPurgedRowsCountName = "mempool_retention_purged_rows_count"
 )
 
 func registerPrometheusMetrics(service *prometheus.Service) {
@@ -13,5 +20,11 @@ func registerPrometheusMetrics(service *prometheus.Service) {
 
 	service.RegisterCounter(operationCountMetricName, "The total number operations in mempool DipDup", "kind", "status", "network")
 	service.RegisterCounter(rpcErrorsCountName, "The total number of RPC errors in mempool DipDup", "code", "node", "network")
+	service.RegisterCounter(nodeFirstSeenCountName, "The total number of operations which were seen by the node first", "node", "network")
+	service.RegisterCounter(nodeDuplicatesCountName, "The total number of operations which were already received from another node", "node", "network")
+	service.RegisterGauge(nodeActiveGaugeName, "Is mempool of the node monitored now (1 or 0)", "node", "network")
+	service.RegisterCounter(nodeSwitchesCountName, "The total number of subscriptions on the node mempool", "node", "network")
+	service.RegisterCounter(reorgCountMetricName, "The total number of chain reorganizations", "network")
+	service.RegisterCounter(retentionPurgedRowsCountName, "The total number of rows deleted by retention", "table", "network")
 
 }
@@ -0,0 +1,221 @@
+package mev
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+)
+
+// kinds of suspects
+const (
+	KindFrontRunning = "front_running"
+	KindSandwich     = "sandwich"
+)
+
+const (
+	// DefaultWindow - count of blocks during which pending transactions are compared
+	DefaultWindow = 2
+
+	// DefaultRateMargin - margin in percent by which fee rate of the front-running transaction must exceed the victim's one.
+	// Wallets estimate fees with small differences, so transactions paying a bit more aren't flagged.
+	DefaultRateMargin = 10
+
+	// DefaultMaxDelay - maximum time between receiving of the victim and the transactions of the attacker
+	DefaultMaxDelay = 30 * time.Second
+
+	// maxPendingPerContract - limit of pending transactions which are kept for one contract. The oldest ones are dropped.
+	maxPendingPerContract = 1000
+)
+
+// Transaction - applied transaction with parameters which is compared with other pending transactions to the same contract
+type Transaction struct {
+	Hash        string
+	Source      string
+	Destination string
+	Entrypoint  string
+	Fee         int64
+	GasLimit    int64
+	Counter     int64
+	// Level - level of the indexer when the transaction was received
+	Level uint64
+	// Received - time when the transaction was received from the node
+	Received time.Time
+}
+
+// rate - bakers order operations by fee per gas unit, so the transaction with higher rate is included first
+func (t Transaction) rate() float64 {
+	if t.GasLimit <= 0 {
+		return float64(t.Fee)
+	}
+	return float64(t.Fee) / float64(t.GasLimit)
+}
+
+// Detector - flags pending transactions which fee ordering suggests front-running or sandwiching. Transactions are compared
+// with the ones to the same contract received within the window of blocks and the maximum delay:
+//   - front-running: transaction of another account calling the same entrypoint is received later but pays fee rate
+//     higher than the victim's one by the margin, so it will be included before the victim;
+//   - sandwich: the front-running transaction is followed by the transaction of the same account to the same contract
+//     with the greater counter and the fee rate which isn't higher than the victim's one, so it will be included after the victim.
+//     The back transaction can call any entrypoint because it usually reverses the front one.
+//
+// Transactions which are included or failed are removed, so later transactions aren't compared with them.
+type Detector struct {
+	network  string
+	window   uint64
+	margin   float64
+	maxDelay time.Duration
+	pending  map[string][]Transaction
+	// destinations - destination of pending transactions by hash
+	destinations map[string]string
+	level        uint64
+	mx           sync.Mutex
+}
+
+// NewDetector -
+func NewDetector(network string, window uint64, opts ...DetectorOption) *Detector {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	detector := Detector{
+		network:      network,
+		window:       window,
+		margin:       float64(DefaultRateMargin) / 100,
+		maxDelay:     DefaultMaxDelay,
+		pending:      make(map[string][]Transaction),
+		destinations: make(map[string]string),
+	}
+
+	for i := range opts {
+		opts[i](&detector)
+	}
+
+	return &detector
+}
+
+// Add - compares the transaction with pending transactions to the same contract and returns found suspects. The transaction
+// is considered as the latest received one.
+func (d *Detector) Add(tx Transaction) []models.MevSuspect {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if tx.Level > d.level {
+		d.level = tx.Level
+		d.evict()
+	}
+
+	var suspects []models.MevSuspect
+	pending := d.pending[tx.Destination]
+	for i := range pending {
+		victim := pending[i]
+		if victim.Source == tx.Source {
+			continue
+		}
+
+		if !d.close(victim, tx) {
+			continue
+		}
+
+		if victim.Entrypoint == tx.Entrypoint && d.outbids(tx, victim) {
+			suspects = append(suspects, d.suspect(KindFrontRunning, victim, tx, nil))
+		}
+
+		if tx.rate() > victim.rate() {
+			continue
+		}
+		for j := i + 1; j < len(pending); j++ {
+			front := pending[j]
+			if front.Source != tx.Source || front.Entrypoint != victim.Entrypoint || front.Counter >= tx.Counter || !d.outbids(front, victim) {
+				continue
+			}
+			suspects = append(suspects, d.suspect(KindSandwich, victim, front, &tx))
+		}
+	}
+
+	if len(pending) >= maxPendingPerContract {
+		delete(d.destinations, pending[0].Hash)
+		pending = slices.Delete(pending, 0, 1)
+	}
+	d.pending[tx.Destination] = append(pending, tx)
+	d.destinations[tx.Hash] = tx.Destination
+	return suspects
+}
+
+// Remove - drops pending transactions with the hashes. It's called when transactions are included or failed,
+// so they can't be front-run anymore.
+func (d *Detector) Remove(hashes ...string) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	for _, hash := range hashes {
+		destination, ok := d.destinations[hash]
+		if !ok {
+			continue
+		}
+		delete(d.destinations, hash)
+
+		pending := slices.DeleteFunc(d.pending[destination], func(tx Transaction) bool {
+			return tx.Hash == hash
+		})
+		if len(pending) == 0 {
+			delete(d.pending, destination)
+			continue
+		}
+		d.pending[destination] = pending
+	}
+}
+
+// outbids - returns true if fee rate of the transaction exceeds the other's one by the margin
+func (d *Detector) outbids(tx, other Transaction) bool {
+	return tx.rate() > other.rate()*(1+d.margin)
+}
+
+// close - returns true if the transaction is received not later than the maximum delay after the victim
+func (d *Detector) close(victim, tx Transaction) bool {
+	if victim.Received.IsZero() || tx.Received.IsZero() {
+		return true
+	}
+	return tx.Received.Sub(victim.Received) <= d.maxDelay
+}
+
+// evict - drops transactions which were received before the window
+func (d *Detector) evict() {
+	for destination, pending := range d.pending {
+		rest := pending[:0]
+		for i := range pending {
+			if pending[i].Level+d.window > d.level {
+				rest = append(rest, pending[i])
+			} else {
+				delete(d.destinations, pending[i].Hash)
+			}
+		}
+		clear(pending[len(rest):])
+		if len(rest) == 0 {
+			delete(d.pending, destination)
+			continue
+		}
+		d.pending[destination] = rest
+	}
+}
+
+func (d *Detector) suspect(kind string, victim, front Transaction, back *Transaction) models.MevSuspect {
+	suspect := models.MevSuspect{
+		Network:     d.network,
+		Kind:        kind,
+		Victim:      victim.Hash,
+		Front:       front.Hash,
+		Attacker:    front.Source,
+		Destination: victim.Destination,
+		Entrypoint:  victim.Entrypoint,
+		VictimFee:   victim.Fee,
+		FrontFee:    front.Fee,
+		Level:       d.level,
+		DetectedAt:  time.Now().Unix(),
+	}
+	if back != nil {
+		suspect.Back = back.Hash
+		suspect.BackFee = back.Fee
+	}
+	return suspect
+}
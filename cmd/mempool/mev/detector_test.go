@@ -0,0 +1,128 @@
+package mev
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetector_Add(t *testing.T) {
+	received := time.Now()
+	victim := Transaction{Hash: "ooVictim", Source: "tz1victim", Destination: "KT1dex", Entrypoint: "swap", Fee: 1000, GasLimit: 1000, Counter: 10, Level: 100, Received: received}
+	front := Transaction{Hash: "ooFront", Source: "tz1attacker", Destination: "KT1dex", Entrypoint: "swap", Fee: 5000, GasLimit: 1000, Counter: 20, Level: 100, Received: received.Add(time.Second)}
+	back := Transaction{Hash: "ooBack", Source: "tz1attacker", Destination: "KT1dex", Entrypoint: "swap_back", Fee: 500, GasLimit: 1000, Counter: 21, Level: 100, Received: received.Add(2 * time.Second)}
+
+	with := func(tx Transaction, change func(*Transaction)) Transaction {
+		change(&tx)
+		return tx
+	}
+
+	tests := []struct {
+		name         string
+		transactions []Transaction
+		want         []string
+	}{
+		{
+			name:         "front-running",
+			transactions: []Transaction{victim, front},
+			want:         []string{"front_running ooVictim ooFront "},
+		}, {
+			name:         "sandwich",
+			transactions: []Transaction{victim, front, back},
+			want:         []string{"front_running ooVictim ooFront ", "sandwich ooVictim ooFront ooBack"},
+		}, {
+			name:         "lower fee rate",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.GasLimit = 10000 })},
+		}, {
+			name:         "another entrypoint",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Entrypoint = "add_liquidity" })},
+		}, {
+			name:         "another contract",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Destination = "KT1other" })},
+		}, {
+			name:         "same account",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Source = victim.Source })},
+		}, {
+			name:         "out of window",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Level = 102 })},
+		}, {
+			name: "back is included before victim",
+			transactions: []Transaction{victim, front, with(back, func(tx *Transaction) {
+				tx.Fee = 2000
+			})},
+			want: []string{"front_running ooVictim ooFront "},
+		}, {
+			name: "back with lower counter",
+			transactions: []Transaction{victim, front, with(back, func(tx *Transaction) {
+				tx.Counter = 19
+			})},
+			want: []string{"front_running ooVictim ooFront "},
+		}, {
+			name:         "fee bump within the margin",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Fee = 1050 })},
+		}, {
+			name:         "fee bump on the margin",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Fee = 1100 })},
+		}, {
+			name: "sandwich with front within the margin",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) {
+				tx.Fee = 1050
+			}), back},
+		}, {
+			name:         "received after the max delay",
+			transactions: []Transaction{victim, with(front, func(tx *Transaction) { tx.Received = received.Add(time.Minute) })},
+		}, {
+			name: "back received after the max delay",
+			transactions: []Transaction{victim, front, with(back, func(tx *Transaction) {
+				tx.Received = received.Add(time.Minute)
+			})},
+			want: []string{"front_running ooVictim ooFront "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewDetector("mainnet", 2)
+
+			var got []string
+			for i := range tt.transactions {
+				for _, suspect := range detector.Add(tt.transactions[i]) {
+					if suspect.Network != "mainnet" || suspect.Destination != victim.Destination || suspect.Attacker != front.Source {
+						t.Errorf("unexpected suspect: %+v", suspect)
+					}
+					got = append(got, suspect.Kind+" "+suspect.Victim+" "+suspect.Front+" "+suspect.Back)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("suspects = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("suspect = %s, want %s", got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetector_Remove(t *testing.T) {
+	victim := Transaction{Hash: "ooVictim", Source: "tz1victim", Destination: "KT1dex", Entrypoint: "swap", Fee: 1000, GasLimit: 1000, Counter: 10, Level: 100}
+	front := Transaction{Hash: "ooFront", Source: "tz1attacker", Destination: "KT1dex", Entrypoint: "swap", Fee: 5000, GasLimit: 1000, Counter: 20, Level: 100}
+
+	detector := NewDetector("mainnet", 2)
+	detector.Add(victim)
+	// the victim is included before the transaction with higher fee is received
+	detector.Remove(victim.Hash, "ooUnknown")
+	if suspects := detector.Add(front); len(suspects) != 0 {
+		t.Errorf("suspects = %v, want none for removed victim", suspects)
+	}
+	if _, ok := detector.pending[victim.Destination]; !ok {
+		t.Error("pending transactions of the contract are dropped with the removed one")
+	}
+	if _, ok := detector.destinations[victim.Hash]; ok {
+		t.Error("removed transaction is still indexed")
+	}
+
+	detector.Remove(front.Hash)
+	if len(detector.pending) != 0 || len(detector.destinations) != 0 {
+		t.Errorf("pending = %v, destinations = %v, want empty", detector.pending, detector.destinations)
+	}
+}
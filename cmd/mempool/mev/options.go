@@ -0,0 +1,24 @@
+package mev
+
+import "time"
+
+// DetectorOption -
+type DetectorOption func(*Detector)
+
+// WithRateMargin - sets the margin in percent by which fee rate of the front-running transaction must exceed the victim's one
+func WithRateMargin(percent uint64) DetectorOption {
+	return func(d *Detector) {
+		if percent > 0 {
+			d.margin = float64(percent) / 100
+		}
+	}
+}
+
+// WithMaxDelay - sets the maximum time between receiving of the victim and the transactions of the attacker
+func WithMaxDelay(delay time.Duration) DetectorOption {
+	return func(d *Detector) {
+		if delay > 0 {
+			d.maxDelay = delay
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package michelson
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/pkg/errors"
+)
+
+// base58 prefixes of Tezos encodings
+var (
+	prefixTz1     = []byte{6, 161, 159}
+	prefixTz2     = []byte{6, 161, 161}
+	prefixTz3     = []byte{6, 161, 164}
+	prefixTz4     = []byte{6, 161, 166}
+	prefixKT1     = []byte{2, 90, 121}
+	prefixTxr1    = []byte{1, 128, 120, 31}
+	prefixSr1     = []byte{6, 124, 117}
+	prefixEdpk    = []byte{13, 15, 37, 217}
+	prefixSppk    = []byte{3, 254, 226, 86}
+	prefixP2pk    = []byte{3, 178, 139, 127}
+	prefixBLpk    = []byte{6, 149, 135, 204}
+	prefixSig     = []byte{4, 130, 43}
+	prefixChainID = []byte{87, 82, 0}
+)
+
+const hashLength = 20
+
+func encode(prefix, payload []byte) string {
+	data := make([]byte, 0, len(prefix)+len(payload)+4)
+	data = append(data, prefix...)
+	data = append(data, payload...)
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return base58.Encode(append(data, second[:4]...))
+}
+
+func decodeHex(value string) ([]byte, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid bytes")
+	}
+	return data, nil
+}
+
+// encodeKeyHash - encodes tagged public key hash: 1 byte of curve and 20 bytes of hash
+func encodeKeyHash(data []byte) (string, error) {
+	if len(data) != hashLength+1 {
+		return "", errors.Errorf("invalid key hash length: %d", len(data))
+	}
+	var prefix []byte
+	switch data[0] {
+	case 0:
+		prefix = prefixTz1
+	case 1:
+		prefix = prefixTz2
+	case 2:
+		prefix = prefixTz3
+	case 3:
+		prefix = prefixTz4
+	default:
+		return "", errors.Errorf("unknown key hash tag: %d", data[0])
+	}
+	return encode(prefix, data[1:]), nil
+}
+
+// encodeAddress - encodes address in binary form: 22 bytes of the tagged address with optional entrypoint name after them
+func encodeAddress(data []byte) (string, error) {
+	if len(data) < hashLength+2 {
+		return "", errors.Errorf("invalid address length: %d", len(data))
+	}
+	var (
+		address string
+		err     error
+	)
+	switch data[0] {
+	case 0:
+		address, err = encodeKeyHash(data[1 : hashLength+2])
+		if err != nil {
+			return "", err
+		}
+	case 1:
+		address = encode(prefixKT1, data[1:hashLength+1])
+	case 2:
+		address = encode(prefixTxr1, data[1:hashLength+1])
+	case 3:
+		address = encode(prefixSr1, data[1:hashLength+1])
+	default:
+		return "", errors.Errorf("unknown address tag: %d", data[0])
+	}
+	if entrypoint := data[hashLength+2:]; len(entrypoint) > 0 {
+		address += "%" + string(entrypoint)
+	}
+	return address, nil
+}
+
+// encodeKey - encodes tagged public key
+func encodeKey(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", errors.New("empty public key")
+	}
+	var (
+		prefix []byte
+		length int
+	)
+	switch data[0] {
+	case 0:
+		prefix, length = prefixEdpk, 32
+	case 1:
+		prefix, length = prefixSppk, 33
+	case 2:
+		prefix, length = prefixP2pk, 33
+	case 3:
+		prefix, length = prefixBLpk, 48
+	default:
+		return "", errors.Errorf("unknown public key tag: %d", data[0])
+	}
+	if len(data) != length+1 {
+		return "", errors.Errorf("invalid public key length: %d", len(data))
+	}
+	return encode(prefix, data[1:]), nil
+}
+
+// encodeSignature - encodes signature as generic one
+func encodeSignature(data []byte) (string, error) {
+	if len(data) != 64 {
+		return "", errors.Errorf("invalid signature length: %d", len(data))
+	}
+	return encode(prefixSig, data), nil
+}
+
+// encodeChainID -
+func encodeChainID(data []byte) (string, error) {
+	if len(data) != 4 {
+		return "", errors.Errorf("invalid chain id length: %d", len(data))
+	}
+	return encode(prefixChainID, data), nil
+}
@@ -0,0 +1,334 @@
+package michelson
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// field - named value of the decoded object
+type field struct {
+	name  string
+	value any
+}
+
+// object - decoded pair, union or map. Order of the fields is kept as it's in the type.
+type object []field
+
+func (o *object) add(name string, value any) {
+	unique := name
+	for i := 1; o.has(unique); i++ {
+		unique = name + "_" + strconv.Itoa(i)
+	}
+	*o = append(*o, field{unique, value})
+}
+
+func (o object) has(name string) bool {
+	for i := range o {
+		if o[i].name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON -
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(o[i].name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(o[i].value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Decode - converts Micheline value of the type to JSON. Pairs are converted to objects which fields are named by annotations
+// and unions to objects with the single field of the chosen branch. Numbers are strings, addresses and keys are base58 encoded.
+// Lambdas and values of unsupported types are kept as Micheline.
+func Decode(typ *Node, value []byte) (json.RawMessage, error) {
+	var v Node
+	if err := json.Unmarshal(value, &v); err != nil {
+		return nil, errors.Wrap(err, "parse value")
+	}
+	decoded, err := decode(typ, &v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(decoded)
+}
+
+func decode(typ, value *Node) (any, error) {
+	switch typ.Prim {
+	case "int", "nat", "mutez":
+		if value.Int == nil {
+			return nil, invalid(typ, value)
+		}
+		return *value.Int, nil
+	case "string":
+		if value.String == nil {
+			return nil, invalid(typ, value)
+		}
+		return *value.String, nil
+	case "bytes", "bls12_381_g1", "bls12_381_g2", "bls12_381_fr", "chest", "chest_key":
+		if value.Bytes == nil {
+			return nil, invalid(typ, value)
+		}
+		return *value.Bytes, nil
+	case "bool":
+		switch value.Prim {
+		case "True":
+			return true, nil
+		case "False":
+			return false, nil
+		}
+		return nil, invalid(typ, value)
+	case "unit":
+		return object{}, nil
+	case "timestamp":
+		switch {
+		case value.String != nil:
+			return *value.String, nil
+		case value.Int != nil:
+			seconds, err := strconv.ParseInt(*value.Int, 10, 64)
+			if err != nil {
+				return *value.Int, nil
+			}
+			return time.Unix(seconds, 0).UTC().Format(time.RFC3339), nil
+		}
+		return nil, invalid(typ, value)
+	case "address", "contract":
+		return decodeEncoded(typ, value, encodeAddress)
+	case "key_hash", "baker_hash":
+		return decodeEncoded(typ, value, encodeKeyHash)
+	case "key":
+		return decodeEncoded(typ, value, encodeKey)
+	case "signature":
+		return decodeEncoded(typ, value, encodeSignature)
+	case "chain_id":
+		return decodeEncoded(typ, value, encodeChainID)
+	case "option":
+		switch {
+		case value.Prim == "None":
+			return nil, nil
+		case value.Prim == "Some" && len(value.Args) == 1 && len(typ.Args) == 1:
+			return decode(typ.Args[0], value.Args[0])
+		}
+		return nil, invalid(typ, value)
+	case "list", "set":
+		if !value.IsSeq || len(typ.Args) != 1 {
+			return nil, invalid(typ, value)
+		}
+		items := make([]any, 0, len(value.Seq))
+		for i := range value.Seq {
+			item, err := decode(typ.Args[0], value.Seq[i])
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case "map", "big_map":
+		return decodeMap(typ, value)
+	case "pair":
+		result := make(object, 0)
+		if err := decodePair(typ, value, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	case "or":
+		return decodeOr(typ, value)
+	case "ticket":
+		return decodeTicket(typ, value)
+	default:
+		return raw(value)
+	}
+}
+
+func invalid(typ, value *Node) error {
+	data, _ := json.Marshal(value)
+	return errors.Errorf("invalid value of %s: %s", typ.Prim, data)
+}
+
+func raw(value *Node) (json.RawMessage, error) {
+	return json.Marshal(value)
+}
+
+// decodeEncoded - values of address-like types can be written as base58 string or in binary form
+func decodeEncoded(typ, value *Node, encoder func([]byte) (string, error)) (any, error) {
+	switch {
+	case value.String != nil:
+		return *value.String, nil
+	case value.Bytes != nil:
+		data, err := decodeHex(*value.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return encoder(data)
+	}
+	return nil, invalid(typ, value)
+}
+
+// decodePair - flattens nested pairs without annotations into the single object
+func decodePair(typ, value *Node, result *object) error {
+	leftType, rightType, ok := split("pair", typ.Args)
+	if !ok {
+		return invalid(typ, value)
+	}
+	if !value.IsSeq && value.Prim != "Pair" {
+		return invalid(typ, value)
+	}
+	leftValue, rightValue, ok := split("Pair", value.items())
+	if !ok {
+		return invalid(typ, value)
+	}
+
+	for _, item := range []struct{ typ, value *Node }{
+		{leftType, leftValue},
+		{rightType, rightValue},
+	} {
+		if item.typ.Prim == "pair" && item.typ.Annotation() == "" {
+			if err := decodePair(item.typ, item.value, result); err != nil {
+				return err
+			}
+			continue
+		}
+		decoded, err := decode(item.typ, item.value)
+		if err != nil {
+			return err
+		}
+		result.add(item.typ.name(), decoded)
+	}
+	return nil
+}
+
+// decodeOr - returns object with the single field named by the annotation of the chosen branch. Nested unions without annotations are flattened.
+func decodeOr(typ, value *Node) (any, error) {
+	for {
+		if len(typ.Args) != 2 || len(value.Args) != 1 {
+			return nil, invalid(typ, value)
+		}
+		var branch *Node
+		switch value.Prim {
+		case "Left":
+			branch = typ.Args[0]
+		case "Right":
+			branch = typ.Args[1]
+		default:
+			return nil, invalid(typ, value)
+		}
+		typ, value = branch, value.Args[0]
+
+		if typ.Prim != "or" || typ.Annotation() != "" {
+			decoded, err := decode(typ, value)
+			if err != nil {
+				return nil, err
+			}
+			return object{{typ.name(), decoded}}, nil
+		}
+	}
+}
+
+// decodeMap - map with keys of simple types is converted to object, other maps are converted to array of key-value objects.
+// Value of big map is its pointer.
+func decodeMap(typ, value *Node) (any, error) {
+	if value.Int != nil && typ.Prim == "big_map" {
+		return json.Number(*value.Int), nil
+	}
+	if !value.IsSeq || len(typ.Args) != 2 {
+		return nil, invalid(typ, value)
+	}
+
+	keyType, valueType := typ.Args[0], typ.Args[1]
+	simple := isSimple(keyType)
+	result := make(object, 0, len(value.Seq))
+	items := make([]any, 0, len(value.Seq))
+	for _, elt := range value.Seq {
+		if elt.Prim != "Elt" || len(elt.Args) != 2 {
+			return nil, invalid(typ, value)
+		}
+		key, err := decode(keyType, elt.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		val, err := decode(valueType, elt.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		if !simple {
+			items = append(items, object{{"key", key}, {"value", val}})
+			continue
+		}
+		switch typed := key.(type) {
+		case string:
+			result = append(result, field{typed, val})
+		case bool:
+			result = append(result, field{strconv.FormatBool(typed), val})
+		}
+	}
+	if simple {
+		return result, nil
+	}
+	return items, nil
+}
+
+func isSimple(typ *Node) bool {
+	switch typ.Prim {
+	case "int", "nat", "mutez", "string", "bytes", "bool", "timestamp", "address", "key_hash", "key", "signature", "chain_id":
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeTicket - ticket is converted to object with ticketer address, content and amount
+func decodeTicket(typ, value *Node) (any, error) {
+	if len(typ.Args) != 1 {
+		return nil, invalid(typ, value)
+	}
+
+	var ticketer, content, amount *Node
+	switch {
+	case value.Prim == "Ticket" && len(value.Args) == 4:
+		ticketer, content, amount = value.Args[0], value.Args[2], value.Args[3]
+	default:
+		items := value.items()
+		if len(items) == 2 && items[1].Prim == "Pair" {
+			items = append(items[:1:1], items[1].Args...)
+		}
+		if len(items) != 3 {
+			return nil, invalid(typ, value)
+		}
+		ticketer, content, amount = items[0], items[1], items[2]
+	}
+
+	address, err := decode(&Node{Prim: "address"}, ticketer)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decode(typ.Args[0], content)
+	if err != nil {
+		return nil, err
+	}
+	count, err := decode(&Node{Prim: "nat"}, amount)
+	if err != nil {
+		return nil, err
+	}
+	return object{{"address", address}, {"value", decoded}, {"amount", count}}, nil
+}
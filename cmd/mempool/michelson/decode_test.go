@@ -0,0 +1,161 @@
+package michelson
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// FA1.2 and FA2 entrypoints with default one, comb pair storage
+const testCode = `[
+	{"prim":"parameter","args":[{"prim":"or","args":[
+		{"prim":"or","args":[
+			{"prim":"pair","args":[{"prim":"address","annots":[":from"]},{"prim":"pair","args":[{"prim":"address","annots":[":to"]},{"prim":"nat","annots":[":value"]}]}],"annots":["%transfer"]},
+			{"prim":"list","args":[{"prim":"pair","args":[
+				{"prim":"address","annots":["%from_"]},
+				{"prim":"list","args":[{"prim":"pair","args":[{"prim":"address","annots":["%to_"]},{"prim":"nat","annots":["%token_id"]},{"prim":"nat","annots":["%amount"]}]}],"annots":["%txs"]}
+			]}],"annots":["%transfer_batch"]}
+		]},
+		{"prim":"or","args":[
+			{"prim":"unit","annots":["%default"]},
+			{"prim":"or","args":[
+				{"prim":"option","args":[{"prim":"key_hash"}],"annots":["%set_delegate"]},
+				{"prim":"pair","args":[{"prim":"nat"},{"prim":"nat"}],"annots":["%pair"]}
+			]}
+		]}
+	]}]},
+	{"prim":"storage","args":[{"prim":"pair","args":[
+		{"prim":"big_map","args":[{"prim":"address"},{"prim":"nat"}],"annots":["%ledger"]},
+		{"prim":"map","args":[{"prim":"string"},{"prim":"bytes"}],"annots":["%metadata"]},
+		{"prim":"map","args":[{"prim":"pair","args":[{"prim":"address"},{"prim":"nat"}]},{"prim":"unit"}],"annots":["%operators"]},
+		{"prim":"timestamp","annots":["%updated"]},
+		{"prim":"bool","annots":["%paused"]}
+	]}]},
+	{"prim":"code","args":[[]]}
+]`
+
+func TestScript_DecodeParameters(t *testing.T) {
+	script, err := ParseScript([]byte(testCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		entrypoint string
+		value      string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "type annotations",
+			entrypoint: "transfer",
+			value:      `{"prim":"Pair","args":[{"string":"tz1from"},{"prim":"Pair","args":[{"string":"tz1to"},{"int":"10"}]}]}`,
+			want:       `{"from":"tz1from","to":"tz1to","value":"10"}`,
+		}, {
+			name:       "list of comb pairs in both forms",
+			entrypoint: "transfer_batch",
+			value: `[{"prim":"Pair","args":[{"string":"tz1from"},[
+				{"prim":"Pair","args":[{"string":"tz1to"},{"int":"0"},{"int":"5"}]},
+				[{"string":"tz1other"},{"int":"1"},{"int":"7"}]
+			]]}]`,
+			want: `[{"from_":"tz1from","txs":[{"to_":"tz1to","token_id":"0","amount":"5"},{"to_":"tz1other","token_id":"1","amount":"7"}]}]`,
+		}, {
+			name:       "explicit default entrypoint",
+			entrypoint: "default",
+			value:      `{"prim":"Unit"}`,
+			want:       `{}`,
+		}, {
+			name:       "binary key hash",
+			entrypoint: "set_delegate",
+			value:      `{"prim":"Some","args":[{"bytes":"000000000000000000000000000000000000000000"}]}`,
+			want:       `"tz1Ke2h7sDdakHJQh8WX4Z372du1KChsksyU"`,
+		}, {
+			name:       "pair without annotations",
+			entrypoint: "pair",
+			value:      `{"prim":"Pair","args":[{"int":"1"},{"int":"2"}]}`,
+			want:       `{"nat":"1","nat_1":"2"}`,
+		}, {
+			name:       "unknown entrypoint",
+			entrypoint: "mint",
+			value:      `{"int":"1"}`,
+			wantErr:    true,
+		}, {
+			name:       "value doesn't match type",
+			entrypoint: "pair",
+			value:      `{"int":"1"}`,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := script.DecodeParameters(tt.entrypoint, []byte(tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeParameters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("DecodeParameters() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScript_DecodeStorage(t *testing.T) {
+	script, err := ParseScript([]byte(testCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := `{"prim":"Pair","args":[
+		{"int":"123"},
+		[{"prim":"Elt","args":[{"string":""},{"bytes":"74657a6f73"}]}],
+		[{"prim":"Elt","args":[{"prim":"Pair","args":[{"string":"tz1owner"},{"int":"0"}]},{"prim":"Unit"}]}],
+		{"int":"1700000000"},
+		{"prim":"False"}
+	]}`
+	want := `{"ledger":123,"metadata":{"":"74657a6f73"},"operators":[{"key":{"address":"tz1owner","nat":"0"},"value":{}}],"updated":"2023-11-14T22:13:20Z","paused":false}`
+
+	got, err := script.DecodeStorage([]byte(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("DecodeStorage() = %s, want %s", got, want)
+	}
+}
+
+func TestEncodeAddress(t *testing.T) {
+	for _, address := range []string{
+		"tz1Ke2h7sDdakHJQh8WX4Z372du1KChsksyU",
+		"tz2BFTyPeYRzxd5aiBchbXN3WCZhx7BqbMBq",
+		"KT1PWx2mnDueood7fEmfbBDKx1D9BAnnXitn",
+		"sr1Ghq66tYK9y3r8CC1Tf8i8m5nxh8nTvZEf",
+	} {
+		decoded := base58.Decode(address)
+		hash := hex.EncodeToString(decoded[3 : len(decoded)-4])
+
+		var binary string
+		switch address[:3] {
+		case "tz1":
+			binary = "0000" + hash
+		case "tz2":
+			binary = "0001" + hash
+		case "KT1":
+			binary = "01" + hash + "00"
+		case "sr1":
+			binary = "03" + hash + "00"
+		}
+		data, err := hex.DecodeString(binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := encodeAddress(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != address {
+			t.Errorf("encodeAddress() = %s, want %s", got, address)
+		}
+	}
+}
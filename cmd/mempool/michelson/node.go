@@ -0,0 +1,94 @@
+package michelson
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Node - Micheline expression: primitive application, literal or sequence
+type Node struct {
+	Prim   string   `json:"prim,omitempty"`
+	Args   []*Node  `json:"args,omitempty"`
+	Annots []string `json:"annots,omitempty"`
+	Int    *string  `json:"int,omitempty"`
+	String *string  `json:"string,omitempty"`
+	Bytes  *string  `json:"bytes,omitempty"`
+
+	Seq   []*Node `json:"-"`
+	IsSeq bool    `json:"-"`
+}
+
+type micheline Node
+
+// UnmarshalJSON -
+func (n *Node) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		n.IsSeq = true
+		return json.Unmarshal(data, &n.Seq)
+	}
+	return json.Unmarshal(data, (*micheline)(n))
+}
+
+// MarshalJSON -
+func (n *Node) MarshalJSON() ([]byte, error) {
+	if n.IsSeq {
+		if n.Seq == nil {
+			return []byte("[]"), nil
+		}
+		return json.Marshal(n.Seq)
+	}
+	return json.Marshal((*micheline)(n))
+}
+
+// Annotation - returns field annotation of the node or its type annotation if the field one is absent. Prefix of the annotation is trimmed.
+func (n *Node) Annotation() string {
+	var typeAnnot string
+	for _, annot := range n.Annots {
+		switch {
+		case len(annot) > 1 && annot[0] == '%':
+			return annot[1:]
+		case len(annot) > 1 && annot[0] == ':' && typeAnnot == "":
+			typeAnnot = annot[1:]
+		}
+	}
+	return typeAnnot
+}
+
+// fieldAnnotation - returns field annotation of the node without `%` prefix
+func (n *Node) fieldAnnotation() string {
+	for _, annot := range n.Annots {
+		if len(annot) > 1 && annot[0] == '%' {
+			return annot[1:]
+		}
+	}
+	return ""
+}
+
+// name - name of the field in decoded value: annotation or primitive name if the type isn't annotated
+func (n *Node) name() string {
+	if annot := n.Annotation(); annot != "" {
+		return annot
+	}
+	return n.Prim
+}
+
+// items - elements of the sequence or arguments of the primitive application. Comb pairs can be written in both forms.
+func (n *Node) items() []*Node {
+	if n.IsSeq {
+		return n.Seq
+	}
+	return n.Args
+}
+
+// split - splits comb of types or values into the first element and the rest ones
+func split(prim string, items []*Node) (*Node, *Node, bool) {
+	switch {
+	case len(items) < 2:
+		return nil, nil, false
+	case len(items) == 2:
+		return items[0], items[1], true
+	default:
+		return items[0], &Node{Prim: prim, Args: items[1:]}, true
+	}
+}
@@ -0,0 +1,85 @@
+package michelson
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// EntrypointDefault - entrypoint of the transactions without explicit one
+const EntrypointDefault = "default"
+
+// Script - types of parameter and storage of the contract
+type Script struct {
+	Parameter *Node
+	Storage   *Node
+}
+
+// ParseScript - receives Micheline code of the contract and finds its parameter and storage sections
+func ParseScript(code []byte) (*Script, error) {
+	var sections Node
+	if err := json.Unmarshal(code, &sections); err != nil {
+		return nil, errors.Wrap(err, "parse code")
+	}
+
+	var script Script
+	for _, section := range sections.Seq {
+		if len(section.Args) != 1 {
+			continue
+		}
+		switch section.Prim {
+		case "parameter":
+			script.Parameter = section.Args[0]
+		case "storage":
+			script.Storage = section.Args[0]
+		}
+	}
+	if script.Parameter == nil || script.Storage == nil {
+		return nil, errors.New("code without parameter or storage section")
+	}
+	return &script, nil
+}
+
+// Entrypoint - returns type of the entrypoint. `default` entrypoint is the whole parameter if the contract doesn't declare it explicitly.
+func (s *Script) Entrypoint(name string) (*Node, error) {
+	if name == "" {
+		name = EntrypointDefault
+	}
+	if typ := findEntrypoint(s.Parameter, name); typ != nil {
+		return typ, nil
+	}
+	if name == EntrypointDefault {
+		return s.Parameter, nil
+	}
+	return nil, errors.Errorf("unknown entrypoint: %s", name)
+}
+
+// findEntrypoint - entrypoints are field annotations of the union branches
+func findEntrypoint(typ *Node, name string) *Node {
+	if typ.fieldAnnotation() == name {
+		return typ
+	}
+	if typ.Prim != "or" {
+		return nil
+	}
+	for i := range typ.Args {
+		if found := findEntrypoint(typ.Args[i], name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// DecodeParameters - decodes value passed to the entrypoint
+func (s *Script) DecodeParameters(entrypoint string, value []byte) (json.RawMessage, error) {
+	typ, err := s.Entrypoint(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(typ, value)
+}
+
+// DecodeStorage - decodes storage value of the contract
+func (s *Script) DecodeStorage(value []byte) (json.RawMessage, error) {
+	return Decode(s.Storage, value)
+}
@@ -0,0 +1,114 @@
+package michelson
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/karlseguin/ccache"
+	"github.com/pkg/errors"
+)
+
+const (
+	scriptsCacheSize = 1000
+	scriptTTL        = time.Hour
+	failureTTL       = time.Minute
+)
+
+// ErrNotCached - script of the contract isn't received yet
+var ErrNotCached = errors.New("script is not cached")
+
+// ScriptSource - source of contract scripts, e.g. node RPC
+type ScriptSource interface {
+	ContractScript(ctx context.Context, blockID, contract string) (node.Script, error)
+}
+
+// Scripts - cache of parsed contract scripts. Failed requests are cached for a short time to not request missing contracts
+// on every operation. Concurrent requests of one contract share the single request to the source.
+type Scripts struct {
+	source ScriptSource
+	cache  *ccache.Cache
+
+	requests map[string]*scriptRequest
+	mx       sync.Mutex
+}
+
+// scriptRequest - request of the script to the source which concurrent callers wait for
+type scriptRequest struct {
+	done   chan struct{}
+	script *Script
+	err    error
+}
+
+// NewScripts -
+func NewScripts(source ScriptSource) *Scripts {
+	return &Scripts{
+		source:   source,
+		cache:    ccache.New(ccache.Configure().MaxSize(scriptsCacheSize)),
+		requests: make(map[string]*scriptRequest),
+	}
+}
+
+// Cached - returns script of the contract or the cached failure without requesting the source. Returns `ErrNotCached` if nothing is cached.
+func (s *Scripts) Cached(contract string) (*Script, error) {
+	if item := s.cache.Get(contract); item != nil && !item.Expired() {
+		switch typed := item.Value().(type) {
+		case *Script:
+			return typed, nil
+		case error:
+			return nil, typed
+		}
+	}
+	return nil, ErrNotCached
+}
+
+// Get - returns script of the contract from the cache or requests it from the source
+func (s *Scripts) Get(ctx context.Context, contract string) (*Script, error) {
+	if script, err := s.Cached(contract); !errors.Is(err, ErrNotCached) {
+		return script, err
+	}
+
+	s.mx.Lock()
+	request, ok := s.requests[contract]
+	if !ok {
+		request = &scriptRequest{done: make(chan struct{})}
+		s.requests[contract] = request
+	}
+	s.mx.Unlock()
+
+	if ok {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-request.done:
+			return request.script, request.err
+		}
+	}
+
+	request.script, request.err = s.receive(ctx, contract)
+	switch {
+	case request.err == nil:
+		s.cache.Set(contract, request.script, scriptTTL)
+	case ctx.Err() == nil:
+		s.cache.Set(contract, request.err, failureTTL)
+	}
+
+	s.mx.Lock()
+	delete(s.requests, contract)
+	s.mx.Unlock()
+	close(request.done)
+	return request.script, request.err
+}
+
+func (s *Scripts) receive(ctx context.Context, contract string) (*Script, error) {
+	raw, err := s.source.ContractScript(ctx, "head", contract)
+	if err != nil {
+		return nil, errors.Wrap(err, contract)
+	}
+	script, err := ParseScript(raw.Code)
+	if err != nil {
+		return nil, errors.Wrap(err, contract)
+	}
+	return script, nil
+}
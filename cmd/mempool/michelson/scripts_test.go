@@ -0,0 +1,83 @@
+package michelson
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/pkg/errors"
+)
+
+type blockingSource struct {
+	requests atomic.Int32
+	release  chan struct{}
+}
+
+func (s *blockingSource) ContractScript(ctx context.Context, blockID, contract string) (node.Script, error) {
+	s.requests.Add(1)
+	select {
+	case <-ctx.Done():
+		return node.Script{}, ctx.Err()
+	case <-s.release:
+	}
+	if contract == "KT1Missing" {
+		return node.Script{}, errors.New("not found")
+	}
+	return node.Script{Code: []byte(testCode)}, nil
+}
+
+func TestScripts_Get(t *testing.T) {
+	source := &blockingSource{release: make(chan struct{})}
+	scripts := NewScripts(source)
+
+	if _, err := scripts.Cached("KT1Contract"); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Cached before Get: %v", err)
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]*Script, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = scripts.Get(context.Background(), "KT1Contract")
+		}()
+	}
+	for source.requests.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(source.release)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("Get #%d: %v", i, errs[i])
+		}
+		if results[i] == nil {
+			t.Fatalf("Get #%d: nil script", i)
+		}
+	}
+	if got := source.requests.Load(); got != 1 {
+		t.Errorf("source requests = %d, want 1", got)
+	}
+
+	cached, err := scripts.Cached("KT1Contract")
+	if err != nil || cached != results[0] {
+		t.Errorf("Cached after Get = %v, %v", cached, err)
+	}
+
+	if _, err := scripts.Get(context.Background(), "KT1Missing"); err == nil {
+		t.Fatal("Get of missing contract: expected error")
+	}
+	if _, err := scripts.Cached("KT1Missing"); err == nil || errors.Is(err, ErrNotCached) {
+		t.Errorf("Cached of missing contract: expected cached failure, got %v", err)
+	}
+	if got := source.requests.Load(); got != 2 {
+		t.Errorf("source requests = %d, want 2", got)
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Block - block of the queue which is used to check branches of mempool operations. It's stored to restore the queue after restart.
+type Block struct {
+	bun.BaseModel `bun:"table:blocks" comment:"blocks - the last blocks known by the indexer. Branches of mempool operations are checked against them."`
+
+	Network     string    `bun:",pk"                                                             comment:"Identifies belonging network." json:"network"`
+	Hash        string    `bun:",pk"                                                             comment:"Hash of the block."            json:"hash"`
+	Predecessor string    `comment:"Hash of the previous block if the chain source provides it." json:"predecessor,omitempty"`
+	Level       uint64    `comment:"Level of the block."                                         json:"level"`
+	Timestamp   time.Time `comment:"Date of the block."                                          json:"timestamp"`
+}
+
+// SaveBlocks - stores blocks which don't exist yet
+func SaveBlocks(ctx context.Context, db bun.IDB, blocks ...Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	_, err := db.NewInsert().Model(&blocks).On("CONFLICT (network, hash) DO NOTHING").Exec(ctx)
+	return err
+}
+
+// DeleteBlocks -
+func DeleteBlocks(ctx context.Context, db bun.IDB, network string, hashes ...string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	_, err := db.NewDelete().Model((*Block)(nil)).
+		Where("network = ?", network).
+		Where("hash IN (?)", bun.In(hashes)).
+		Exec(ctx)
+	return err
+}
+
+// GetBlocks - returns stored blocks of the network sorted by level
+func GetBlocks(ctx context.Context, db bun.IDB, network string) ([]Block, error) {
+	var blocks []Block
+	err := db.NewSelect().Model(&blocks).
+		Where("network = ?", network).
+		Order("level").
+		Scan(ctx)
+	return blocks, err
+}
@@ -2,17 +2,29 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"reflect"
 	"time"
 
 	"github.com/dipdup-net/go-lib/config"
 	"github.com/dipdup-net/go-lib/database"
 	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 )
 
 // OpenDatabaseConnection -
 func OpenDatabaseConnection(ctx context.Context, cfg config.Database, kinds ...string) (db *database.Bun, err error) {
+	return openDatabaseConnection(ctx, cfg, false, kinds...)
+}
+
+// OpenArchiveDatabaseConnection - opens connection as `OpenDatabaseConnection` but operation tables are created partitioned by range of `created_at`
+func OpenArchiveDatabaseConnection(ctx context.Context, cfg config.Database, kinds ...string) (db *database.Bun, err error) {
+	return openDatabaseConnection(ctx, cfg, true, kinds...)
+}
+
+func openDatabaseConnection(ctx context.Context, cfg config.Database, partitioned bool, kinds ...string) (db *database.Bun, err error) {
 	db = database.NewBun()
 
 	if err := db.Connect(ctx, cfg); err != nil {
@@ -24,15 +36,21 @@ func OpenDatabaseConnection(ctx context.Context, cfg config.Database, kinds ...s
 	db.DB().AddQueryHook(new(logQueryHook))
 
 	data := GetModelsBy(kinds...)
-	data = append(data, &database.State{})
+	data = append(data, &Block{}, &database.State{})
 
 	for i := range data {
-		if _, err := db.DB().NewCreateTable().Model(data[i]).IfNotExists().Exec(ctx); err != nil {
+		if err := createTable(ctx, db.DB(), data[i], partitioned); err != nil {
 			if err := db.Close(); err != nil {
 				return nil, err
 			}
 			return nil, err
 		}
+		if err := addMissingColumns(ctx, db.DB(), data[i]); err != nil {
+			return nil, err
+		}
+		if err := createIndices(ctx, db.DB(), data[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	if err := database.MakeComments(ctx, db, data...); err != nil {
@@ -42,6 +60,124 @@ func OpenDatabaseConnection(ctx context.Context, cfg config.Database, kinds ...s
 	return db, nil
 }
 
+// createTable - creates table of the model if it doesn't exist. If `partitioned` is true operation tables are partitioned by range of `created_at`.
+// `created_at` is added to the primary key because PostgreSQL requires partition key to be a part of unique constraints.
+func createTable(ctx context.Context, db *bun.DB, model any, partitioned bool) error {
+	if !partitioned || !isOperation(model) {
+		_, err := db.NewCreateTable().Model(model).IfNotExists().Exec(ctx)
+		return err
+	}
+
+	table := db.Table(reflect.TypeOf(model))
+	var relkind string
+	err := db.NewSelect().
+		TableExpr("pg_class").
+		Column("relkind").
+		Where("relname = ?", table.Name).
+		Where("relnamespace = current_schema()::regnamespace").
+		Scan(ctx, &relkind)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return err
+	case relkind == "p":
+		return nil
+	default:
+		return errors.Errorf("table %s already exists and is not partitioned: archive mode requires a database created in archive mode", table.Name)
+	}
+
+	pks := make([]bun.Ident, 0, len(table.PKs)+1)
+	for _, pk := range table.PKs {
+		pks = append(pks, bun.Ident(pk.Name))
+	}
+	pks = append(pks, bun.Ident("created_at"))
+
+	_, err = db.NewCreateTable().
+		Model(model).
+		ColumnExpr("PRIMARY KEY (?)", bun.In(pks)).
+		PartitionBy("RANGE (created_at)").
+		Exec(ctx)
+	return err
+}
+
+// isOperation - returns true if the model embeds `MempoolOperation`
+func isOperation(model any) bool {
+	typ := reflect.TypeOf(model)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	field, ok := typ.FieldByName("MempoolOperation")
+	return ok && field.Anonymous
+}
+
+// addMissingColumns - adds columns which were introduced in the model after the table had been created
+func addMissingColumns(ctx context.Context, db *bun.DB, model any) error {
+	table := db.Table(reflect.TypeOf(model))
+
+	var existing []string
+	if err := db.NewSelect().
+		TableExpr("information_schema.columns").
+		Column("column_name").
+		Where("table_schema = current_schema()").
+		Where("table_name = ?", table.Name).
+		Scan(ctx, &existing); err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	columns := make(map[string]struct{}, len(existing))
+	for i := range existing {
+		columns[existing[i]] = struct{}{}
+	}
+
+	for _, field := range table.Fields {
+		if _, ok := columns[field.Name]; ok {
+			continue
+		}
+		if _, err := db.NewAddColumn().
+			Model(model).
+			ColumnExpr("? ?", bun.Ident(field.Name), bun.Safe(field.CreateTableSQLType)).
+			IfNotExists().
+			Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndices - creates indices declared by `index` tags of the model. Fields with the same index name form a composite index
+// in the order of declaration.
+func createIndices(ctx context.Context, db *bun.DB, model any) error {
+	table := db.Table(reflect.TypeOf(model))
+
+	names := make([]string, 0)
+	columns := make(map[string][]bun.Ident)
+	for _, field := range table.Fields {
+		name, ok := field.StructField.Tag.Lookup("index")
+		if !ok || name == "" {
+			continue
+		}
+		if _, ok := columns[name]; !ok {
+			names = append(names, name)
+		}
+		columns[name] = append(columns[name], bun.Ident(field.Name))
+	}
+
+	for _, name := range names {
+		if _, err := db.NewCreateIndex().
+			Model(model).
+			Index(name).
+			IfNotExists().
+			ColumnExpr("?", bun.In(columns[name])).
+			Exec(ctx); err != nil {
+			return errors.Wrap(err, name)
+		}
+	}
+	return nil
+}
+
 type logQueryHook struct{}
 
 // BeforeQuery -
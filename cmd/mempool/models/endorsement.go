@@ -26,3 +26,13 @@ func EndorsementsWithoutBaker(ctx context.Context, db bun.IDB, network string, l
 		Scan(ctx)
 	return
 }
+
+// SetEndorsementBaker -
+func SetEndorsementBaker(ctx context.Context, db bun.IDB, endorsement *Endorsement) error {
+	_, err := db.NewUpdate().
+		Model(endorsement).
+		WherePK().
+		Set("baker = ?", endorsement.Baker).
+		Exec(ctx)
+	return err
+}
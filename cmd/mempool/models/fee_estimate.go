@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FeeEstimate -
+type FeeEstimate struct {
+	bun.BaseModel `bun:"table:fee_estimates" comment:"fee_estimates - fee needed to include manager operation within the number of blocks with the confidence."`
+
+	Network         string  `bun:",pk"                                                                 comment:"Identifies belonging network."                                                                     json:"network"`
+	Blocks          uint64  `bun:",pk"                                                                 comment:"Count of blocks within which the operation should be included."                                    json:"blocks"`
+	Confidence      uint64  `bun:",pk"                                                                 comment:"Share of the operations with the price which were included within the count of blocks in percent." json:"confidence"`
+	MutezPerGasUnit float64 `comment:"Price of gas unit in micro tez."                                 json:"mutez_per_gas_unit"`
+	MutezPerByte    uint64  `comment:"Price of byte of the operation in micro tez."                    json:"mutez_per_byte"`
+	BaseFee         uint64  `comment:"Minimal fee of the operation in micro tez."                      json:"base_fee"`
+	Samples         uint64  `comment:"Count of operations which were used for estimation."             json:"samples"`
+	Level           uint64  `comment:"Level of the block at which the estimation has been calculated." json:"level"`
+	UpdatedAt       int64   `comment:"Date of last update in seconds since UNIX epoch."                json:"updated_at"`
+}
+
+// SaveFeeEstimates - replaces estimates of the network
+func SaveFeeEstimates(ctx context.Context, db bun.IDB, estimates ...FeeEstimate) error {
+	if len(estimates) == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+	for i := range estimates {
+		estimates[i].UpdatedAt = now
+	}
+
+	_, err := db.NewInsert().Model(&estimates).
+		On("CONFLICT (network, blocks, confidence) DO UPDATE").
+		Set("mutez_per_gas_unit = excluded.mutez_per_gas_unit").
+		Set("mutez_per_byte = excluded.mutez_per_byte").
+		Set("base_fee = excluded.base_fee").
+		Set("samples = excluded.samples").
+		Set("level = excluded.level").
+		Set("updated_at = excluded.updated_at").
+		Exec(ctx)
+	return err
+}
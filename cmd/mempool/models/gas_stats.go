@@ -18,6 +18,7 @@ type GasStats struct {
 	UpdatedAt      int64  `comment:"Date of last update in seconds since UNIX epoch."                           json:"updated_at"`
 	LevelInMempool uint64 `comment:"Level of the block at which the statistics has been calculated in mempool." json:"level_in_mempool"`
 	LevelInChain   uint64 `comment:"Level of the block at which the statistics has been calculated in chain."   json:"level_in_chain"`
+	Size           uint64 `comment:"Size of the operation group in bytes."                                      json:"size"`
 }
 
 // BeforeInsert -
@@ -54,13 +55,62 @@ func (s *GasStats) Save(ctx context.Context, db bun.IDB) error {
 	if s.LevelInMempool > 0 {
 		query.Set("level_in_mempool = case gas_stats.level_in_mempool when 0 then excluded.level_in_mempool else gas_stats.level_in_mempool end")
 	}
+	if s.Size > 0 {
+		query.Set("size = excluded.size")
+	}
 
 	_, err := query.Exec(ctx)
 	return err
 }
 
-// DeleteOldGasStats -
-func DeleteOldGasStats(ctx context.Context, db bun.IDB, timeout uint64) error {
-	_, err := db.NewDelete().Model((*GasStats)(nil)).Where("updated_at < ?", time.Now().Unix()-int64(timeout)).Exec(ctx)
+// SaveMempoolGasStats - stores statistics of the operations seen in mempool by one statement. Statistics of the same operation are merged.
+func SaveMempoolGasStats(ctx context.Context, db bun.IDB, stats ...GasStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	// ON CONFLICT DO UPDATE can't affect the same row twice in one statement
+	now := time.Now().Unix()
+	rows := make([]GasStats, 0, len(stats))
+	indices := make(map[string]int, len(stats))
+	for i := range stats {
+		key := stats[i].Network + "/" + stats[i].Hash
+		if j, ok := indices[key]; ok {
+			if stats[i].Size > 0 {
+				rows[j].Size = stats[i].Size
+			}
+			continue
+		}
+		indices[key] = len(rows)
+		stats[i].UpdatedAt = now
+		rows = append(rows, stats[i])
+	}
+
+	_, err := db.NewInsert().Model(&rows).
+		On("CONFLICT (network, hash) DO UPDATE").
+		Set("level_in_mempool = case gas_stats.level_in_mempool when 0 then excluded.level_in_mempool else gas_stats.level_in_mempool end").
+		Set("size = case excluded.size when 0 then gas_stats.size else excluded.size end").
+		Exec(ctx)
 	return err
 }
+
+// DeleteOldGasStats - deletes statistics which were not updated during `timeout` seconds. If `limit` is positive
+// it deletes not more than `limit` rows. Returns count of deleted rows.
+func DeleteOldGasStats(ctx context.Context, db bun.IDB, timeout uint64, limit int) (int, error) {
+	ts := time.Now().Unix() - int64(timeout)
+	return deleteChunk(ctx, db, (*GasStats)(nil), limit, func(q bun.QueryBuilder) bun.QueryBuilder {
+		return q.Where("updated_at < ?", ts)
+	})
+}
+
+// IncludedGasStats - returns statistics of the operations which were seen in mempool and included in chain
+func IncludedGasStats(ctx context.Context, db bun.IDB, network string) ([]GasStats, error) {
+	var stats []GasStats
+	err := db.NewSelect().Model(&stats).
+		Where("network = ?", network).
+		Where("level_in_mempool > 0").
+		Where("level_in_chain > 0").
+		Where("total_gas_used > 0").
+		Scan(ctx)
+	return stats, err
+}
@@ -39,17 +39,21 @@ type ChangableMempoolOperation interface {
 type MempoolOperation struct {
 	CreatedAt       int64   `comment:"Date of creation in seconds since UNIX epoch."                                                 json:"-"`
 	UpdatedAt       int64   `comment:"Date of last update in seconds since UNIX epoch."                                              json:"-"`
-	Network         string  `bun:",pk"                                                                                               comment:"Identifies belonging network."                json:"network"`
-	Hash            string  `bun:",pk"                                                                                               comment:"Hash of the operation."                       json:"hash"`
+	Network         string  `bun:",pk"                                                                                               comment:"Identifies belonging network."                                                                    json:"network"`
+	Hash            string  `bun:",pk"                                                                                               comment:"Hash of the operation."                                                                           json:"hash"`
 	Branch          string  `comment:"Hash of the block, in which the operation was included."                                       json:"branch"`
 	Status          string  `comment:"Status of the operation."                                                                      json:"status"`
 	Kind            string  `comment:"Type of the operation."                                                                        json:"kind"`
 	Signature       string  `comment:"Signature of the operation."                                                                   json:"signature"`
 	Protocol        string  `comment:"Hash of the protocol, in which the operation was included in mempool."                         json:"protocol"`
 	Level           uint64  `comment:"The height of the block from the genesis block, in which the operation was included."          json:"level"`
-	Errors          JSONB   `bun:",type:jsonb"                                                                                       comment:"Errors with the operation processing if any." json:"errors,omitempty"`
+	Errors          JSONB   `bun:",type:jsonb"                                                                                       comment:"Errors with the operation processing if any."                                                     json:"errors,omitempty"`
 	ExpirationLevel *uint64 `comment:"Datetime of block expiration in which the operation was included in seconds since UNIX epoch." json:"expiration_level"`
-	Raw             JSONB   `bun:",type:jsonb"                                                                                       comment:"Raw JSON object of the operation."            json:"raw,omitempty"`
+	Raw             JSONB   `bun:",type:jsonb"                                                                                       comment:"Raw JSON object of the operation."                                                                json:"raw,omitempty"`
+	Node            string  `comment:"URL of the node which was the first to report the operation."                                  json:"node"`
+	FirstSeenAt     int64   `bun:",nullzero"                                                                                         comment:"Date when the operation was seen in mempool for the first time in milliseconds since UNIX epoch." json:"first_seen_at,omitempty"`
+	AppliedAt       int64   `bun:",nullzero"                                                                                         comment:"Date when the operation was applied in mempool in milliseconds since UNIX epoch."                 json:"applied_at,omitempty"`
+	IncludedAt      int64   `bun:",nullzero"                                                                                         comment:"Timestamp of the block in which the operation was included in milliseconds since UNIX epoch."     json:"included_at,omitempty"`
 }
 
 var _ bun.BeforeAppendModelHook = (*MempoolOperation)(nil)
@@ -64,137 +68,321 @@ func (mo *MempoolOperation) BeforeAppendModel(ctx context.Context, query bun.Que
 	return nil
 }
 
-// SetInChain -
-func SetInChain(ctx context.Context, db bun.IDB, network, hash, kind string, level uint64) error {
-	model, err := getModelByKind(kind)
+// StatusChange - new status of the operation changed by bulk update
+type StatusChange struct {
+	Hash   string
+	Status string
+}
+
+// SetInChain - returns true if the operation was found. `includedAt` is the block timestamp in milliseconds, zero if it's unknown yet.
+func SetInChain(ctx context.Context, db bun.IDB, network, hash, kind string, level uint64, includedAt int64) (bool, error) {
+	model, err := ModelByKind(kind)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if _, err := db.NewUpdate().Model(model).
+	query := db.NewUpdate().Model(model).
 		Where("hash = ?", hash).
 		Where("network = ?", network).
 		Set("status = ?", StatusInChain).
 		Set("level = ?", level).
-		Set("errors = NULL").
-		Exec(ctx); err != nil {
+		Set("errors = NULL")
+	if includedAt > 0 {
+		query.Set("included_at = ?", includedAt)
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil
+			return false, nil
+		}
+		return false, err
+	}
+	return isAffected(result), nil
+}
+
+// SetStatus - sets status reported by mempool to the operation which was not included yet. Returns true if the operation was found.
+// `timestamp` is the time when the status was received in milliseconds.
+func SetStatus(ctx context.Context, db bun.IDB, network, hash, status string, errs JSONB, timestamp int64, kinds ...string) (bool, error) {
+	var found bool
+	for _, kind := range kinds {
+		model, err := ModelByKind(kind)
+		if err != nil {
+			return false, err
+		}
+
+		query := db.NewUpdate().Model(model).
+			Where("hash = ?", hash).
+			Where("network = ?", network).
+			Where("status NOT IN (?)", bun.In([]string{StatusInChain, StatusExpired})).
+			Set("status = ?", status).
+			Set("errors = ?", errs)
+		if status == StatusApplied {
+			query.Set("applied_at = COALESCE(applied_at, ?)", timestamp)
+		}
+
+		result, err := query.Exec(ctx)
+		if err != nil {
+			return false, err
+		}
+		found = found || isAffected(result)
+	}
+	return found, nil
+}
+
+// StatusUpdate - mempool status of the operation which is set by bulk update. `Timestamp` is the time when the status was received in milliseconds.
+type StatusUpdate struct {
+	Hash      string `bun:"hash,type:varchar"`
+	Status    string `bun:"status,type:varchar"`
+	Errors    JSONB  `bun:"errors,type:jsonb"`
+	Timestamp int64  `bun:"timestamp,type:bigint"`
+}
+
+// SetStatuses - sets statuses reported by mempool to the operations which were not included yet by one statement per kind.
+// If the operation is updated several times the last update wins. Returns flags whether the operation of each update was found.
+func SetStatuses(ctx context.Context, db bun.IDB, network string, updates []StatusUpdate, kinds ...string) ([]bool, error) {
+	found := make([]bool, len(updates))
+	if len(updates) == 0 {
+		return found, nil
+	}
+
+	last := make(map[string]int, len(updates))
+	for i := range updates {
+		last[updates[i].Hash] = i
+	}
+	values := make([]StatusUpdate, 0, len(last))
+	for i := range updates {
+		if last[updates[i].Hash] == i {
+			values = append(values, updates[i])
+		}
+	}
+
+	updated := make(map[string]struct{}, len(values))
+	for _, kind := range kinds {
+		model, err := ModelByKind(kind)
+		if err != nil {
+			return nil, err
+		}
+
+		var hashes []string
+		if _, err := db.NewUpdate().
+			With("_data", db.NewValues(&values)).
+			Model(model).
+			TableExpr("_data").
+			Set("status = _data.status").
+			Set("errors = _data.errors").
+			Set("applied_at = CASE WHEN _data.status = ? THEN COALESCE(?TableAlias.applied_at, _data.timestamp) ELSE ?TableAlias.applied_at END", StatusApplied).
+			Where("?TableAlias.hash = _data.hash").
+			Where("?TableAlias.network = ?", network).
+			Where("?TableAlias.status NOT IN (?)", bun.In([]string{StatusInChain, StatusExpired})).
+			Returning("?TableAlias.hash").
+			Exec(ctx, &hashes); err != nil {
+			return nil, err
+		}
+		for i := range hashes {
+			updated[hashes[i]] = struct{}{}
+		}
+	}
+
+	for i := range updates {
+		_, found[i] = updated[updates[i].Hash]
+	}
+	return found, nil
+}
+
+// SetIncludedAt - sets block timestamp in milliseconds to the operations included at the level if it was unknown
+func SetIncludedAt(ctx context.Context, db bun.IDB, network string, level uint64, includedAt int64, kinds ...string) error {
+	for _, kind := range kinds {
+		model, err := ModelByKind(kind)
+		if err != nil {
+			return err
+		}
+
+		if _, err := db.NewUpdate().Model(model).
+			Set("included_at = ?", includedAt).
+			Where("network = ?", network).
+			Where("level = ?", level).
+			Where("status = ?", StatusInChain).
+			Where("included_at IS NULL").
+			Exec(ctx); err != nil {
+			return err
 		}
-		return err
 	}
 	return nil
 }
 
-// SetExpired -
-func SetExpired(ctx context.Context, db bun.IDB, network, branch string, kinds ...string) error {
+// SetExpired - returns hashes of expired operations
+func SetExpired(ctx context.Context, db bun.IDB, network, branch string, kinds ...string) ([]string, error) {
 	if len(kinds) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	hashes := make([]string, 0)
 	for _, kind := range kinds {
-		model, err := getModelByKind(kind)
+		model, err := ModelByKind(kind)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
+		var expired []string
 		if _, err := db.NewUpdate().
 			Model(model).
 			Set("status = ?", StatusExpired).
 			Where("network = ?", network).
 			Where("branch = ?", branch).
 			Where("status = ?", StatusApplied).
-			Exec(ctx); err != nil {
-			return err
+			Returning("hash").
+			Exec(ctx, &expired); err != nil {
+			return nil, err
 		}
+		hashes = append(hashes, expired...)
 	}
-	return nil
+	return unique(hashes), nil
 }
 
-// Rollback -
-func Rollback(ctx context.Context, db bun.IDB, network, branch string, level uint64, kinds ...string) error {
+// Rollback - returns changed statuses of the operations. Applied operations with the orphaned branch are refused,
+// operations included into the orphaned block are returned to mempool whatever their branch is.
+func Rollback(ctx context.Context, db bun.IDB, network, branch string, level uint64, kinds ...string) ([]StatusChange, error) {
 	if len(kinds) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	var refusedHashes, appliedHashes []string
 	for _, kind := range kinds {
-		model, err := getModelByKind(kind)
+		model, err := ModelByKind(kind)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		query := db.NewUpdate().Model(model).
+		var refused []string
+		if _, err := db.NewUpdate().Model(model).
+			Set("status = ?", StatusBranchRefused).
 			Where("network = ?", network).
 			Where("branch = ?", branch).
-			Set("status = ?", StatusBranchRefused).
-			WhereGroup(" AND ", func(q *bun.UpdateQuery) *bun.UpdateQuery {
-				return q.Where("status = ?", StatusApplied).WhereGroup(" OR ", func(q1 *bun.UpdateQuery) *bun.UpdateQuery {
-					return q1.Where("status = ?", StatusInChain).Where("level = ?", level)
-				})
-			})
-
-		if _, err := query.Exec(ctx); err != nil {
-			return err
+			Where("status = ?", StatusApplied).
+			Returning("hash").
+			Exec(ctx, &refused); err != nil {
+			return nil, err
 		}
 
+		var applied []string
 		if _, err := db.NewUpdate().Model(model).
 			Set("status = ?", StatusApplied).
+			Set("level = 0").
+			Set("included_at = NULL").
 			Where("network = ?", network).
-			Where("branch = ?", branch).
 			Where("status = ?", StatusInChain).
-			Where("level < ?", level).
-			Exec(ctx); err != nil {
-			return err
+			Where("level = ?", level).
+			Returning("hash").
+			Exec(ctx, &applied); err != nil {
+			return nil, err
 		}
+
+		refusedHashes = append(refusedHashes, refused...)
+		appliedHashes = append(appliedHashes, applied...)
 	}
 
-	return nil
+	changes := make([]StatusChange, 0, len(refusedHashes)+len(appliedHashes))
+	for _, hash := range unique(refusedHashes) {
+		changes = append(changes, StatusChange{Hash: hash, Status: StatusBranchRefused})
+	}
+	for _, hash := range unique(appliedHashes) {
+		changes = append(changes, StatusChange{Hash: hash, Status: StatusApplied})
+	}
+	return changes, nil
 }
 
-// DeleteOldOperations -
-func DeleteOldOperations(ctx context.Context, db bun.IDB, timeout uint64, status string, kinds ...string) error {
-	if len(kinds) == 0 {
-		return nil
+func isAffected(result sql.Result) bool {
+	if result == nil {
+		return false
 	}
+	count, err := result.RowsAffected()
+	return err == nil && count > 0
+}
 
+func unique(hashes []string) []string {
+	set := make(map[string]struct{}, len(hashes))
+	result := make([]string, 0, len(hashes))
+	for i := range hashes {
+		if _, ok := set[hashes[i]]; ok {
+			continue
+		}
+		set[hashes[i]] = struct{}{}
+		result = append(result, hashes[i])
+	}
+	return result
+}
+
+// DeleteOldOperations - deletes operations which were not updated during `timeout` seconds. If `limit` is positive
+// it deletes not more than `limit` rows of each kind. Returns count of deleted rows.
+func DeleteOldOperations(ctx context.Context, db bun.IDB, timeout uint64, status string, limit int, kinds ...string) (int, error) {
+	var deleted int
 	for _, kind := range kinds {
-		model, err := getModelByKind(kind)
+		model, err := ModelByKind(kind)
 		if err != nil {
-			return err
+			return deleted, err
 		}
 		ts := time.Now().Unix() - int64(timeout)
-		query := db.NewDelete().Model(model).Where("updated_at < ?", ts)
-
-		if status != "" {
-			query.Where("status = ?", status)
+		filter := func(q bun.QueryBuilder) bun.QueryBuilder {
+			q = q.Where("updated_at < ?", ts)
+			if status != "" {
+				q = q.Where("status = ?", status)
+			}
+			return q
 		}
 
-		if _, err := query.Exec(ctx); err != nil {
-			return err
+		count, err := deleteChunk(ctx, db, model, limit, filter)
+		if err != nil {
+			return deleted, err
 		}
+		deleted += count
 	}
-	return nil
+	return deleted, nil
+}
+
+// deleteChunk - deletes rows of the model matched by the filter. If `limit` is positive not more than `limit` rows are deleted.
+func deleteChunk(ctx context.Context, db bun.IDB, model any, limit int, filter func(q bun.QueryBuilder) bun.QueryBuilder) (int, error) {
+	query := db.NewDelete().Model(model)
+	if limit > 0 {
+		chunk := db.NewSelect().Model(model).ColumnExpr("ctid").ApplyQueryBuilder(filter).Limit(limit)
+		query = query.Where("ctid IN (?)", chunk)
+	} else {
+		query = query.ApplyQueryBuilder(filter)
+	}
+
+	result, err := query.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
 }
 
 // GetModelsBy -
 func GetModelsBy(kinds ...string) []interface{} {
-	var hasManager bool
+	var hasManager, hasTransactions bool
 	data := make([]interface{}, 0, len(kinds))
 	for i := range kinds {
 		hasManager = hasManager || node.IsManager(kinds[i])
-		model, err := getModelByKind(kinds[i])
+		hasTransactions = hasTransactions || kinds[i] == node.KindTransaction
+		model, err := ModelByKind(kinds[i])
 		if err == nil {
 			data = append(data, model)
 		}
 	}
 
 	if hasManager {
-		data = append(data, &GasStats{})
+		data = append(data, &GasStats{}, &FeeEstimate{}, &Simulation{})
+	}
+	if hasTransactions {
+		data = append(data, &MevSuspect{})
 	}
+	data = append(data, &StatusHistory{})
 	return data
 }
 
-func getModelByKind(kind string) (interface{}, error) {
+// ModelByKind - returns empty model of the operation kind
+func ModelByKind(kind string) (interface{}, error) {
 	switch kind {
 	case node.KindActivation:
 		return &ActivateAccount{}, nil
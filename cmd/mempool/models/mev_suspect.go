@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MevSuspect -
+type MevSuspect struct {
+	bun.BaseModel `bun:"table:mev_suspects" comment:"mev_suspects - pending transactions which fee ordering suggests front-running or sandwiching."`
+
+	Network     string `bun:",pk"                                                                             comment:"Identifies belonging network."                                                   json:"network"`
+	Kind        string `bun:",pk"                                                                             comment:"Kind of the suspect: front_running or sandwich."                                 json:"kind"`
+	Victim      string `bun:",pk"                                                                             comment:"Hash of the transaction which is front-run."                                     json:"victim"`
+	Front       string `bun:",pk"                                                                             comment:"Hash of the transaction which pays higher fee to be included before the victim." json:"front"`
+	Back        string `comment:"Hash of the back transaction of the sandwich. It's empty for front-running." json:"back,omitempty"`
+	Attacker    string `comment:"Address of the account who has sent the front and back transactions."        index:"mev_suspect_attacker_idx"                                                          json:"attacker"`
+	Destination string `comment:"Address of the contract which is called by the transactions."                index:"mev_suspect_destination_idx"                                                       json:"destination"`
+	Entrypoint  string `comment:"Entrypoint which is called by the victim."                                   json:"entrypoint"`
+	VictimFee   int64  `comment:"Fee of the victim transaction (micro tez)."                                  json:"victim_fee,string"`
+	FrontFee    int64  `comment:"Fee of the front transaction (micro tez)."                                   json:"front_fee,string"`
+	BackFee     int64  `comment:"Fee of the back transaction (micro tez)."                                    json:"back_fee,string"`
+	Level       uint64 `comment:"Level of the indexer state at which the suspect was detected."               json:"level"`
+	DetectedAt  int64  `comment:"Date of detection in seconds since UNIX epoch."                              json:"detected_at"`
+}
+
+// SaveMevSuspects - stores suspects which don't exist yet
+func SaveMevSuspects(ctx context.Context, db bun.IDB, suspects ...MevSuspect) error {
+	if len(suspects) == 0 {
+		return nil
+	}
+	_, err := db.NewInsert().Model(&suspects).
+		On("CONFLICT (network, kind, victim, front) DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+// DeleteOldMevSuspects - deletes suspects which were detected more than `timeout` seconds ago
+func DeleteOldMevSuspects(ctx context.Context, db bun.IDB, timeout uint64, limit int) (int, error) {
+	ts := time.Now().Unix() - int64(timeout)
+	return deleteChunk(ctx, db, (*MevSuspect)(nil), limit, func(q bun.QueryBuilder) bun.QueryBuilder {
+		return q.Where("detected_at < ?", ts)
+	})
+}
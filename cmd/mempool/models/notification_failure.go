@@ -0,0 +1,29 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// NotificationFailure - webhook notification which was not delivered after all retries
+type NotificationFailure struct {
+	bun.BaseModel `bun:"table:notification_failures" comment:"notification_failures - dead letters of webhook notifications."`
+
+	ID        uint64    `bun:",pk,autoincrement"                                     comment:"Internal identifier."      json:"-"`
+	URL       string    `comment:"URL of the webhook."                               json:"url"`
+	Network   string    `comment:"Identifies belonging network."                     json:"network"`
+	Hash      string    `comment:"Hash of the operation."                            json:"hash"`
+	Status    string    `comment:"Status of the operation which was notified about." json:"status"`
+	Payload   JSONB     `bun:",type:jsonb"                                           comment:"Body of the notification." json:"payload"`
+	Error     string    `comment:"Error of the last delivery attempt."               json:"error"`
+	Attempts  uint64    `comment:"Count of delivery attempts."                       json:"attempts"`
+	CreatedAt time.Time `comment:"Date when the notification was given up."          json:"created_at"`
+}
+
+// SaveNotificationFailure -
+func SaveNotificationFailure(ctx context.Context, db bun.IDB, failure *NotificationFailure) error {
+	_, err := db.NewInsert().Model(failure).Exec(ctx)
+	return err
+}
@@ -18,10 +18,12 @@ type Origination struct {
 	Delegate     string `comment:"Address of the baker (delegate), which was marked as a delegate in the operation."  json:",omitempty"`
 	Source       string `comment:"Address of the account who has sent the operation."                                 index:"origination_source_idx"                                        json:"source,omitempty"`
 	Script       struct {
+		Code    json.RawMessage `json:"code"`
 		Storage json.RawMessage `json:"storage"`
 	} `json:"script" bun:"-"`
 
-	Storage JSONB `bun:",type:jsonb" comment:"Initial contract storage value converted to human-readable JSON." json:"-"`
+	Storage        JSONB `bun:",type:jsonb" comment:"Initial contract storage value converted to human-readable JSON."          json:"-"`
+	StorageDecoded JSONB `bun:",type:jsonb" comment:"Initial contract storage value decoded by the storage type of the script." json:"storage_decoded,omitempty"`
 }
 
 // Fill -
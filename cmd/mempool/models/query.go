@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/uptrace/bun"
+)
+
+// PendingFilters - filters of pending operations
+type PendingFilters struct {
+	Kinds       []string
+	Source      string
+	Destination string
+	Limit       int
+	Offset      int
+}
+
+// OperationsCount - count of operations of the kind with the status
+type OperationsCount struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  uint64 `json:"count"`
+}
+
+// GetByHash - returns contents of the operation group with the hash of the kinds
+func GetByHash(ctx context.Context, db bun.IDB, network, hash string, kinds ...string) ([]any, error) {
+	result := make([]any, 0)
+	for _, model := range modelsByKinds(kinds) {
+		slice := newModelSlice(model)
+		if err := db.NewSelect().Model(slice.Interface()).
+			Where("network = ?", network).
+			Where("hash = ?", hash).
+			Scan(ctx); err != nil {
+			return nil, err
+		}
+		result = appendSlice(result, slice)
+	}
+	return result, nil
+}
+
+// GetPending - returns operations of the kinds which are waiting for inclusion sorted by creation time from newest to oldest
+func GetPending(ctx context.Context, db bun.IDB, network string, filters PendingFilters) ([]any, error) {
+	type item struct {
+		createdAt int64
+		hash      string
+		value     any
+	}
+
+	items := make([]item, 0)
+	for _, model := range modelsByKinds(filters.Kinds) {
+		table := db.Dialect().Tables().Get(reflect.TypeOf(model))
+		if filters.Source != "" && !table.HasField("source") {
+			continue
+		}
+		if filters.Destination != "" && !table.HasField("destination") {
+			continue
+		}
+
+		slice := newModelSlice(model)
+		query := db.NewSelect().Model(slice.Interface()).
+			Where("network = ?", network).
+			Where("status IN (?)", bun.In([]string{StatusApplied, StatusBranchDelayed})).
+			Order("created_at DESC", "hash").
+			Limit(filters.Offset + filters.Limit)
+		if filters.Source != "" {
+			query.Where("source = ?", filters.Source)
+		}
+		if filters.Destination != "" {
+			query.Where("destination = ?", filters.Destination)
+		}
+		if err := query.Scan(ctx); err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < slice.Elem().Len(); i++ {
+			value := slice.Elem().Index(i)
+			operation := value.FieldByName("MempoolOperation").Interface().(MempoolOperation)
+			items = append(items, item{
+				createdAt: operation.CreatedAt,
+				hash:      operation.Hash,
+				value:     value.Addr().Interface(),
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].createdAt != items[j].createdAt {
+			return items[i].createdAt > items[j].createdAt
+		}
+		return items[i].hash < items[j].hash
+	})
+
+	result := make([]any, 0, filters.Limit)
+	for i := filters.Offset; i < len(items) && len(result) < filters.Limit; i++ {
+		result = append(result, items[i].value)
+	}
+	return result, nil
+}
+
+// CountByStatus - returns count of stored operations of the kinds grouped by status
+func CountByStatus(ctx context.Context, db bun.IDB, network string, kinds ...string) ([]OperationsCount, error) {
+	result := make([]OperationsCount, 0)
+	for _, model := range modelsByKinds(kinds) {
+		var counts []OperationsCount
+		if err := db.NewSelect().Model(model).
+			ColumnExpr("kind").
+			ColumnExpr("status").
+			ColumnExpr("count(*) AS count").
+			Where("network = ?", network).
+			Group("kind", "status").
+			Order("kind", "status").
+			Scan(ctx, &counts); err != nil {
+			return nil, err
+		}
+		result = append(result, counts...)
+	}
+	return result, nil
+}
+
+// GetFeeEstimates -
+func GetFeeEstimates(ctx context.Context, db bun.IDB, network string) ([]FeeEstimate, error) {
+	var estimates []FeeEstimate
+	err := db.NewSelect().Model(&estimates).
+		Where("network = ?", network).
+		Order("blocks", "confidence").
+		Scan(ctx)
+	return estimates, err
+}
+
+// modelsByKinds - returns models of the kinds skipping unknown kinds and kinds which share the table
+func modelsByKinds(kinds []string) []any {
+	result := make([]any, 0, len(kinds))
+	seen := make(map[reflect.Type]struct{}, len(kinds))
+	for _, kind := range kinds {
+		model, err := ModelByKind(kind)
+		if err != nil {
+			continue
+		}
+		typ := reflect.TypeOf(model)
+		if _, ok := seen[typ]; ok {
+			continue
+		}
+		seen[typ] = struct{}{}
+		result = append(result, model)
+	}
+	return result
+}
+
+func newModelSlice(model any) reflect.Value {
+	typ := reflect.TypeOf(model).Elem()
+	return reflect.New(reflect.SliceOf(typ))
+}
+
+func appendSlice(result []any, slice reflect.Value) []any {
+	for i := 0; i < slice.Elem().Len(); i++ {
+		result = append(result, slice.Elem().Index(i).Addr().Interface())
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Simulation -
+type Simulation struct {
+	bun.BaseModel `bun:"table:simulations" comment:"simulations - predicted results of mempool operations simulated on the head block."`
+
+	Network            string `bun:",pk"                                                                               comment:"Identifies belonging network."                                                          json:"network"`
+	Hash               string `bun:",pk"                                                                               comment:"Hash of the operation."                                                                 json:"hash"`
+	Level              uint64 `comment:"Level of the head block on which the operation was simulated."                 json:"level"`
+	Status             string `comment:"Predicted status of the operation: applied, failed, backtracked or skipped."   json:"status"`
+	ConsumedMilligas   uint64 `comment:"Predicted amount of gas consumed by all contents of the operation (milligas)." json:"consumed_milligas,string"`
+	BalanceUpdates     JSONB  `bun:",type:jsonb"                                                                       comment:"Predicted balance updates including fees and internal operations."                      json:"balance_updates,omitempty"`
+	StorageDiff        JSONB  `bun:",type:jsonb"                                                                       comment:"Predicted storage and lazy storage diffs of the called contracts by the content index." json:"storage_diff,omitempty"`
+	InternalOperations JSONB  `bun:",type:jsonb"                                                                       comment:"Predicted results of internal operations."                                              json:"internal_operations,omitempty"`
+	Errors             JSONB  `bun:",type:jsonb"                                                                       comment:"Errors of the simulation."                                                              json:"errors,omitempty"`
+	UpdatedAt          int64  `comment:"Date of last update in seconds since UNIX epoch."                              json:"updated_at"`
+}
+
+// SaveSimulations - stores simulations replacing previous results of the same operations
+func SaveSimulations(ctx context.Context, db bun.IDB, simulations ...Simulation) error {
+	if len(simulations) == 0 {
+		return nil
+	}
+	now := time.Now().Unix()
+	for i := range simulations {
+		simulations[i].UpdatedAt = now
+	}
+
+	_, err := db.NewInsert().Model(&simulations).
+		On("CONFLICT (network, hash) DO UPDATE").
+		Set("level = excluded.level").
+		Set("status = excluded.status").
+		Set("consumed_milligas = excluded.consumed_milligas").
+		Set("balance_updates = excluded.balance_updates").
+		Set("storage_diff = excluded.storage_diff").
+		Set("internal_operations = excluded.internal_operations").
+		Set("errors = excluded.errors").
+		Set("updated_at = excluded.updated_at").
+		Exec(ctx)
+	return err
+}
+
+// DeleteOldSimulations - deletes simulations which were not updated during `timeout` seconds
+func DeleteOldSimulations(ctx context.Context, db bun.IDB, timeout uint64, limit int) (int, error) {
+	ts := time.Now().Unix() - int64(timeout)
+	return deleteChunk(ctx, db, (*Simulation)(nil), limit, func(q bun.QueryBuilder) bun.QueryBuilder {
+		return q.Where("updated_at < ?", ts)
+	})
+}
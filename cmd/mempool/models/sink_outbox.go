@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// SinkMessage - message which is waiting to be published to the message broker
+type SinkMessage struct {
+	bun.BaseModel `bun:"table:sink_outbox" comment:"sink_outbox - messages which are waiting to be published to the message broker."`
+
+	ID        uint64    `bun:",pk,autoincrement"                      comment:"Internal identifier." json:"id"`
+	Subject   string    `comment:"Subject (topic) of the message."    json:"subject"`
+	Payload   JSONB     `bun:",type:jsonb"                            comment:"Body of the message." json:"payload"`
+	CreatedAt time.Time `comment:"Date when the message was created." json:"created_at"`
+}
+
+// SaveSinkMessages -
+func SaveSinkMessages(ctx context.Context, db bun.IDB, messages ...SinkMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	_, err := db.NewInsert().Model(&messages).Exec(ctx)
+	return err
+}
+
+// GetSinkMessages - returns the oldest messages
+func GetSinkMessages(ctx context.Context, db bun.IDB, limit int) ([]SinkMessage, error) {
+	var messages []SinkMessage
+	err := db.NewSelect().Model(&messages).Order("id").Limit(limit).Scan(ctx)
+	return messages, err
+}
+
+// DeleteSinkMessages - removes published messages
+func DeleteSinkMessages(ctx context.Context, db bun.IDB, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := db.NewDelete().Model((*SinkMessage)(nil)).Where("id IN (?)", bun.In(ids)).Exec(ctx)
+	return err
+}
@@ -11,7 +11,7 @@ type SmartRollupAddMessage struct {
 	Counter      int64    `bun:",pk"                                                                                    comment:"An account nonce which is used to prevent operation replay." json:"counter,string"`
 	GasLimit     int64    `comment:"A cap on the amount of gas a given operation can consume."                          json:"gas_limit,string"`
 	StorageLimit int64    `comment:"A cap on the amount of storage a given operation can consume."                      json:"storage_limit,string"`
-	Source       string   `comment:"Address of the account who has sent the operation."                                 index:"set_deposits_limit_source_idx"                                 json:"source,omitempty"`
+	Source       string   `comment:"Address of the account who has sent the operation."                                 index:"sr_add_message_source_idx"                                     json:"source,omitempty"`
 	Message      []string `comment:"Messages added to the smart rollup inbox (Array of hex strings)."                   json:"message"`
 }
 
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// StatusHistory -
+type StatusHistory struct {
+	bun.BaseModel `bun:"table:status_history" comment:"status_history - every status transition of mempool operations."`
+
+	ID        uint64    `bun:",pk,autoincrement"                                                    comment:"Internal identifier."                        json:"-"`
+	Network   string    `comment:"Identifies belonging network."                                    index:"status_history_hash_idx"                       json:"network"`
+	Hash      string    `comment:"Hash of the operation."                                           index:"status_history_hash_idx"                       json:"hash"`
+	Status    string    `comment:"Status of the operation after the transition."                    json:"status"`
+	Level     uint64    `comment:"Level of the indexer state at which the transition was observed." json:"level"`
+	Node      string    `comment:"URL of the node which reported the transition if any."            json:"node,omitempty"`
+	Protocol  string    `comment:"Hash of the protocol which was active during the transition."     json:"protocol,omitempty"`
+	Errors    JSONB     `bun:",type:jsonb"                                                          comment:"Errors reported with the transition if any." json:"errors,omitempty"`
+	Timestamp time.Time `comment:"Date of the transition."                                          index:"status_history_timestamp_idx"                  json:"timestamp"`
+}
+
+// SaveStatusHistory -
+func SaveStatusHistory(ctx context.Context, db bun.IDB, history ...StatusHistory) error {
+	if len(history) == 0 {
+		return nil
+	}
+	_, err := db.NewInsert().Model(&history).Exec(ctx)
+	return err
+}
+
+// GetStatusHistory - returns status transitions of the operation in chronological order
+func GetStatusHistory(ctx context.Context, db bun.IDB, network, hash string) ([]StatusHistory, error) {
+	var history []StatusHistory
+	err := db.NewSelect().Model(&history).
+		Where("network = ?", network).
+		Where("hash = ?", hash).
+		Order("id").
+		Scan(ctx)
+	return history, err
+}
+
+// DeleteOldStatusHistory - deletes transitions which were observed more than `timeout` seconds ago
+func DeleteOldStatusHistory(ctx context.Context, db bun.IDB, timeout uint64, limit int) (int, error) {
+	ts := time.Now().UTC().Add(-time.Duration(timeout) * time.Second)
+	return deleteChunk(ctx, db, (*StatusHistory)(nil), limit, func(q bun.QueryBuilder) bun.QueryBuilder {
+		return q.Where("timestamp < ?", ts)
+	})
+}
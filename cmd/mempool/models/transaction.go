@@ -1,18 +1,54 @@
 package models
 
-import "github.com/uptrace/bun"
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/uptrace/bun"
+)
 
 // Transaction -
 type Transaction struct {
 	bun.BaseModel `bun:"table:transactions"`
 
 	MempoolOperation
-	Source       string `comment:"Address of the account who has sent the operation."                                 index:"transaction_source_idx"                                                                   json:"source"`
-	Fee          int64  `comment:"Fee to the baker, produced block, in which the operation was included (micro tez)." json:"fee,string"`
-	Counter      int64  `bun:",pk"                                                                                    comment:"An account nonce which is used to prevent operation replay."                            json:"counter,string"`
-	GasLimit     int64  `comment:"A cap on the amount of gas a given operation can consume."                          json:"gas_limit,string"`
-	StorageLimit int64  `comment:"A cap on the amount of storage a given operation can consume."                      json:"storage_limit,string"`
-	Amount       string `comment:"The transaction amount (mutez)."                                                    json:"amount"`
-	Destination  string `comment:"Address of the target of the transaction."                                          index:"transaction_destination_idx"                                                              json:"destination"`
-	Parameters   JSONB  `bun:",type:jsonb"                                                                            comment:"Transaction parameter, including called entrypoint and value passed to the entrypoint." json:"parameters,omitempty"`
+	Source            string `comment:"Address of the account who has sent the operation."                                 index:"transaction_source_idx"                                                                   json:"source"`
+	Fee               int64  `comment:"Fee to the baker, produced block, in which the operation was included (micro tez)." json:"fee,string"`
+	Counter           int64  `bun:",pk"                                                                                    comment:"An account nonce which is used to prevent operation replay."                            json:"counter,string"`
+	GasLimit          int64  `comment:"A cap on the amount of gas a given operation can consume."                          json:"gas_limit,string"`
+	StorageLimit      int64  `comment:"A cap on the amount of storage a given operation can consume."                      json:"storage_limit,string"`
+	Amount            string `comment:"The transaction amount (mutez)."                                                    json:"amount"`
+	Destination       string `comment:"Address of the target of the transaction."                                          index:"transaction_destination_idx"                                                              json:"destination"`
+	Entrypoint        string `comment:"Called entrypoint. It's empty if the transaction has no parameters."                index:"transaction_entrypoint_idx"                                                               json:"-"`
+	Parameters        JSONB  `bun:",type:jsonb"                                                                            comment:"Transaction parameter, including called entrypoint and value passed to the entrypoint." json:"parameters,omitempty"`
+	ParametersDecoded JSONB  `bun:",type:jsonb"                                                                            comment:"Value of the transaction parameter decoded by the entrypoint type."                     json:"parameters_decoded,omitempty"`
+}
+
+// parameters - parameters of the transaction as they're sent to the node
+type parameters struct {
+	Entrypoint string          `json:"entrypoint"`
+	Value      json.RawMessage `json:"value"`
+}
+
+// Fill - sets entrypoint from parameters and returns Micheline value of the parameters
+func (t *Transaction) Fill() (json.RawMessage, error) {
+	if len(t.Parameters) == 0 {
+		return nil, nil
+	}
+	var p parameters
+	if err := json.Unmarshal(t.Parameters, &p); err != nil {
+		return nil, err
+	}
+	t.Entrypoint = p.Entrypoint
+	return p.Value, nil
+}
+
+// SetParametersDecoded - sets decoded parameters to the stored transaction which is found by primary key
+func SetParametersDecoded(ctx context.Context, db bun.IDB, transaction *Transaction) error {
+	_, err := db.NewUpdate().
+		Model(transaction).
+		WherePK().
+		Set("parameters_decoded = ?", transaction.ParametersDecoded).
+		Exec(ctx)
+	return err
 }
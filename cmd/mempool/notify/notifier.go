@@ -0,0 +1,315 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const subscriptionSize = 10_000
+
+// Payload - body of the webhook request
+type Payload struct {
+	Type       stream.EventType `json:"type"`
+	Network    string           `json:"network"`
+	Hash       string           `json:"hash"`
+	Status     string           `json:"status"`
+	Level      uint64           `json:"level,omitempty"`
+	Accounts   []string         `json:"accounts"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Operations []any            `json:"operations"`
+}
+
+// errors which are written to dead letters instead of delivery errors
+var (
+	errQueueFull = errors.New("delivery queue of the webhook is full")
+	errDropped   = errors.New("event was dropped because the notifier is too slow")
+)
+
+// Notifier - sends webhook notifications about operations involving watched accounts. Events are read from one subscription,
+// the operations of the event are requested once and deliveries are passed to bounded queues of the hooks.
+// Events which are skipped by the hub or don't fit into the queue are written to `notification_failures` table.
+type Notifier struct {
+	db           *database.Bun
+	hub          *stream.Hub
+	networks     map[string][]string
+	hooks        []*webhook
+	subscription *stream.Subscription
+	g            workerpool.Group
+
+	dropped   []stream.Event
+	droppedMx sync.Mutex
+	// hasDropped - is signalled when events are added to `dropped`
+	hasDropped chan struct{}
+}
+
+// New - creates notifier. `networks` maps indexed network to its operation kinds.
+func New(cfgs []*config.Notification, db *database.Bun, hub *stream.Hub, networks map[string][]string) (*Notifier, error) {
+	if db == nil {
+		return nil, errors.New("nil database connection")
+	}
+	if hub == nil {
+		return nil, errors.New("nil event hub")
+	}
+
+	n := &Notifier{
+		db:         db,
+		hub:        hub,
+		networks:   networks,
+		hooks:      make([]*webhook, 0, len(cfgs)),
+		g:          workerpool.NewGroup(),
+		hasDropped: make(chan struct{}, 1),
+	}
+	for i := range cfgs {
+		n.hooks = append(n.hooks, newWebhook(cfgs[i]))
+	}
+	return n, nil
+}
+
+// Start -
+func (n *Notifier) Start(ctx context.Context) error {
+	if _, err := n.db.DB().NewCreateTable().Model((*models.NotificationFailure)(nil)).IfNotExists().Exec(ctx); err != nil {
+		return errors.Wrap(err, "create notification_failures table")
+	}
+	if err := database.MakeComments(ctx, n.db, &models.NotificationFailure{}); err != nil {
+		return errors.Wrap(err, "notification_failures comments")
+	}
+
+	for _, hook := range n.hooks {
+		for i := range hook.queues {
+			queue := hook.queues[i]
+			n.g.GoCtx(ctx, func(ctx context.Context) {
+				n.deliver(ctx, hook, queue)
+			})
+		}
+	}
+
+	n.subscription = n.hub.SubscribeWithOverflow(stream.Filter{}, subscriptionSize, n.drop)
+	n.g.GoCtx(ctx, n.listen)
+	n.g.GoCtx(ctx, n.saveDropped)
+	return nil
+}
+
+// Close -
+func (n *Notifier) Close() error {
+	n.g.Wait()
+	if n.subscription != nil {
+		n.hub.Unsubscribe(n.subscription)
+	}
+	return nil
+}
+
+func (n *Notifier) listen(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-n.subscription.Events():
+			if !ok {
+				return
+			}
+			if err := n.dispatch(ctx, event); err != nil {
+				log.Err(err).Str("hash", event.Hash).Msg("webhook notification")
+			}
+		}
+	}
+}
+
+// dispatch - passes notifications about the event to the queues of the hooks. If the queue is full, the notification is written to dead letters.
+func (n *Notifier) dispatch(ctx context.Context, event stream.Event) error {
+	deliveries, err := n.deliveries(ctx, event)
+	if err != nil {
+		return err
+	}
+	for hook, d := range deliveries {
+		if hook.enqueue(d) {
+			continue
+		}
+		if err := n.saveFailure(ctx, hook, d, errQueueFull, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliveries - returns notifications about the event for the hooks which watch the event and the accounts of its operations.
+// Status events don't carry the operation so it's read from the database once for all hooks.
+func (n *Notifier) deliveries(ctx context.Context, event stream.Event) (map[*webhook]delivery, error) {
+	hooks := make([]*webhook, 0, len(n.hooks))
+	for _, hook := range n.hooks {
+		if hook.filter.Match(event) {
+			hooks = append(hooks, hook)
+		}
+	}
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	operations := []any{event.Data}
+	if event.Data == nil {
+		stored, err := models.GetByHash(ctx, n.db.DB(), event.Network, event.Hash, n.networks[event.Network]...)
+		if err != nil {
+			return nil, err
+		}
+		operations = stored
+	}
+
+	candidates := []string{event.Source, event.Destination}
+	for i := range operations {
+		candidates = append(candidates, accounts(operations[i])...)
+	}
+
+	deliveries := make(map[*webhook]delivery, len(hooks))
+	for _, hook := range hooks {
+		payload, ok := newPayload(hook, event, operations, candidates)
+		if !ok {
+			continue
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		deliveries[hook] = delivery{event: event, body: body}
+	}
+	return deliveries, nil
+}
+
+// deliver - sends notifications from the queue of the hook
+func (n *Notifier) deliver(ctx context.Context, hook *webhook, queue <-chan delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-queue:
+			attempts, err := hook.deliver(ctx, d.body)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+			if err := n.saveFailure(ctx, hook, d, err, attempts); err != nil {
+				log.Err(err).Str("url", hook.url).Str("hash", d.event.Hash).Msg("save notification failure")
+			}
+		}
+	}
+}
+
+// drop - keeps the event skipped by the hub to write it to dead letters. It's called by the publisher, so it doesn't block.
+func (n *Notifier) drop(event stream.Event) {
+	n.droppedMx.Lock()
+	n.dropped = append(n.dropped, event)
+	n.droppedMx.Unlock()
+
+	select {
+	case n.hasDropped <- struct{}{}:
+	default:
+	}
+}
+
+// saveDropped - writes notifications about the events skipped by the hub to dead letters
+func (n *Notifier) saveDropped(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.hasDropped:
+			n.droppedMx.Lock()
+			events := n.dropped
+			n.dropped = nil
+			n.droppedMx.Unlock()
+
+			for i := range events {
+				deliveries, err := n.deliveries(ctx, events[i])
+				if err != nil {
+					log.Err(err).Str("hash", events[i].Hash).Msg("dropped webhook notification")
+					continue
+				}
+				for hook, d := range deliveries {
+					if err := n.saveFailure(ctx, hook, d, errDropped, 0); err != nil {
+						log.Err(err).Str("url", hook.url).Str("hash", d.event.Hash).Msg("save notification failure")
+					}
+				}
+			}
+		}
+	}
+}
+
+func (n *Notifier) saveFailure(ctx context.Context, hook *webhook, d delivery, err error, attempts uint64) error {
+	return models.SaveNotificationFailure(ctx, n.db.DB(), &models.NotificationFailure{
+		URL:       hook.url,
+		Network:   d.event.Network,
+		Hash:      d.event.Hash,
+		Status:    d.event.Status,
+		Payload:   d.body,
+		Error:     err.Error(),
+		Attempts:  attempts,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// newPayload - builds request body. Returns false if the operation doesn't involve any of watched accounts.
+func newPayload(hook *webhook, event stream.Event, operations []any, candidates []string) (Payload, bool) {
+	payload := Payload{
+		Type:       event.Type,
+		Network:    event.Network,
+		Hash:       event.Hash,
+		Status:     event.Status,
+		Level:      event.Level,
+		Timestamp:  event.Timestamp,
+		Operations: operations,
+	}
+	for _, account := range candidates {
+		if _, ok := hook.accounts[account]; ok && !slices.Contains(payload.Accounts, account) {
+			payload.Accounts = append(payload.Accounts, account)
+		}
+	}
+	return payload, len(payload.Accounts) > 0
+}
+
+// involved - fields of operations which may contain watched account
+type involved struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Delegate    string `json:"delegate"`
+	Pkh         string `json:"pkh"`
+}
+
+func accounts(operation any) []string {
+	data, err := json.Marshal(operation)
+	if err != nil {
+		return nil
+	}
+	var fields involved
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+
+	result := make([]string, 0, 4)
+	for _, account := range []string{fields.Source, fields.Destination, fields.Delegate, fields.Pkh} {
+		if account != "" {
+			result = append(result, account)
+		}
+	}
+	return result
+}
+
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for i := range values {
+		set[values[i]] = struct{}{}
+	}
+	return set
+}
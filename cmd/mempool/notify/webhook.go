@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	"github.com/pkg/errors"
+)
+
+// SignatureHeader - header with hex-encoded HMAC-SHA256 of the request body signed by webhook secret
+const SignatureHeader = "X-Mempool-Signature"
+
+// default delivery settings
+const (
+	defaultMaxRetries = 5
+	defaultTimeout    = 10 * time.Second
+	defaultBackoff    = time.Second
+	maxBackoff        = time.Minute
+	defaultWorkers    = 4
+	defaultQueueSize  = 1000
+)
+
+// default statuses which are notified about: operation entered mempool, was refused, expired or was included
+var defaultStatuses = []string{
+	models.StatusApplied,
+	models.StatusRefused,
+	models.StatusBranchRefused,
+	models.StatusExpired,
+	models.StatusInChain,
+}
+
+type webhook struct {
+	url        string
+	secret     string
+	accounts   map[string]struct{}
+	filter     stream.Filter
+	maxRetries uint64
+	backoff    time.Duration
+	client     *http.Client
+	// queues - deliveries of the hook sharded by operation hash, so notifications about one operation are sent in order.
+	// Every queue is served by its own worker.
+	queues []chan delivery
+}
+
+// delivery - body of the notification about the event
+type delivery struct {
+	event stream.Event
+	body  []byte
+}
+
+func newWebhook(cfg *config.Notification) *webhook {
+	hook := &webhook{
+		url:        cfg.URL,
+		secret:     cfg.Secret,
+		accounts:   make(map[string]struct{}, len(cfg.Accounts)),
+		maxRetries: cfg.MaxRetries,
+		backoff:    defaultBackoff,
+		client: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+	for _, address := range cfg.Addresses() {
+		hook.accounts[address] = struct{}{}
+	}
+	statuses := cfg.Statuses
+	if len(statuses) == 0 {
+		statuses = defaultStatuses
+	}
+	hook.filter = stream.Filter{
+		Networks: toSet(cfg.Networks),
+		Statuses: toSet(statuses),
+	}
+	if hook.maxRetries == 0 {
+		hook.maxRetries = defaultMaxRetries
+	}
+	if cfg.Timeout > 0 {
+		hook.client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	workers, size := cfg.Workers, cfg.QueueSize
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	hook.queues = make([]chan delivery, workers)
+	for i := range hook.queues {
+		hook.queues[i] = make(chan delivery, size/workers+1)
+	}
+	return hook
+}
+
+// enqueue - adds the delivery to the queue of its operation. Returns false if the queue is full.
+func (w *webhook) enqueue(d delivery) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(d.event.Hash))
+	select {
+	case w.queues[int(h.Sum32()%uint32(len(w.queues)))] <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliver - sends the body retrying with exponential backoff. Returns count of attempts and the last error.
+func (w *webhook) deliver(ctx context.Context, body []byte) (uint64, error) {
+	backoff := w.backoff
+
+	var (
+		attempt uint64
+		err     error
+	)
+	for attempt = 1; ; attempt++ {
+		if err = w.send(ctx, body); err == nil {
+			return attempt, nil
+		}
+		if attempt > w.maxRetries {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (w *webhook) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign - returns signature of the body which is sent in `X-Mempool-Signature` header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+)
+
+func TestWebhookDeliver(t *testing.T) {
+	body := []byte(`{"hash":"ooQ"}`)
+
+	tests := []struct {
+		name         string
+		failures     int32
+		maxRetries   uint64
+		wantAttempts uint64
+		wantErr      bool
+	}{
+		{
+			name:         "delivered at first attempt",
+			maxRetries:   2,
+			wantAttempts: 1,
+		}, {
+			name:         "delivered after retries",
+			failures:     2,
+			maxRetries:   2,
+			wantAttempts: 3,
+		}, {
+			name:         "retries exhausted",
+			failures:     10,
+			maxRetries:   2,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				if got, want := r.Header.Get(SignatureHeader), Sign("secret", received); got != want {
+					t.Errorf("signature = %s, want %s", got, want)
+				}
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			hook := newWebhook(&config.Notification{
+				URL:        server.URL,
+				Secret:     "secret",
+				MaxRetries: tt.maxRetries,
+			})
+			hook.backoff = time.Millisecond
+
+			attempts, err := hook.deliver(context.Background(), body)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("deliver() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWebhookEnqueue(t *testing.T) {
+	hook := newWebhook(&config.Notification{
+		URL:       "http://localhost",
+		Workers:   2,
+		QueueSize: 2,
+	})
+	if len(hook.queues) != 2 {
+		t.Fatalf("queues = %d, want 2", len(hook.queues))
+	}
+
+	d := delivery{event: stream.Event{Hash: "oo1"}}
+	for i := 0; i < cap(hook.queues[0]); i++ {
+		if !hook.enqueue(d) {
+			t.Fatalf("delivery %d is rejected by not full queue", i)
+		}
+	}
+	if hook.enqueue(d) {
+		t.Error("delivery is accepted by full queue")
+	}
+
+	var queued int
+	for i := range hook.queues {
+		if len(hook.queues[i]) > 0 {
+			queued++
+		}
+	}
+	if queued != 1 {
+		t.Errorf("deliveries of one operation are spread over %d queues", queued)
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	hook := newWebhook(&config.Notification{
+		URL:      "http://localhost",
+		Networks: []string{"mainnet"},
+	})
+	hook.accounts["tz1watched"] = struct{}{}
+
+	event := stream.Event{Network: "mainnet", Hash: "oo1", Status: "applied"}
+	if !hook.filter.Match(event) {
+		t.Error("event of the watched network and default status doesn't match")
+	}
+	if hook.filter.Match(stream.Event{Network: "ghostnet", Status: "applied"}) {
+		t.Error("event of other network matches")
+	}
+
+	payload, ok := newPayload(hook, event, nil, []string{"tz1other", "tz1watched", "tz1watched"})
+	if !ok || len(payload.Accounts) != 1 || payload.Accounts[0] != "tz1watched" {
+		t.Errorf("payload accounts = %v, want [tz1watched]", payload.Accounts)
+	}
+	if _, ok := newPayload(hook, event, nil, []string{"tz1other"}); ok {
+		t.Error("payload is built for operation which doesn't involve watched accounts")
+	}
+}
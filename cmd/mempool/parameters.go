@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/michelson"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	parametersWorkers   = 4
+	parametersQueueSize = 1024 * 8
+)
+
+// parametersTask - stored transaction which parameters are decoded after the script of its destination is received
+type parametersTask struct {
+	hash        string
+	counter     int64
+	destination string
+	entrypoint  string
+	value       []byte
+}
+
+// decodeCachedParameters - decodes parameters by the cached script of the destination. Returns false if the script isn't received yet:
+// requesting the node by the worker would block all operations of its shard, so such transactions are decoded after they're stored.
+func (w *worker) decodeCachedParameters(destination, entrypoint string, value []byte) (models.JSONB, bool) {
+	script, err := w.scripts.Cached(destination)
+	switch {
+	case errors.Is(err, michelson.ErrNotCached):
+		return nil, false
+	case err != nil:
+		return nil, true
+	}
+	return w.decode(script, destination, entrypoint, value), true
+}
+
+// decode - decodes parameters by the script. Parameters are stored undecoded if the value doesn't match the entrypoint type.
+func (indexer *Indexer) decode(script *michelson.Script, destination, entrypoint string, value []byte) models.JSONB {
+	decoded, err := script.DecodeParameters(entrypoint, value)
+	if err != nil {
+		indexer.warn().Err(err).Str("destination", destination).Str("entrypoint", entrypoint).Msg("decode parameters")
+		return nil
+	}
+	return models.JSONB(decoded)
+}
+
+// enqueueParameters - queues stored transactions for decoding. The task is skipped if the queue is full,
+// so the transaction is kept with undecoded parameters.
+func (w *worker) enqueueParameters(tasks ...parametersTask) {
+	for i := range tasks {
+		select {
+		case w.parameters <- tasks[i]:
+		default:
+			w.warn().Str("hash", tasks[i].hash).Msg("parameters queue is full")
+		}
+	}
+}
+
+// decodeParameters - receives scripts of destinations of queued transactions and writes decoded parameters to the stored transactions
+func (indexer *Indexer) decodeParameters(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-indexer.parameters:
+			script, err := indexer.scripts.Get(ctx, task.destination)
+			if err != nil {
+				indexer.warn().Err(err).Str("destination", task.destination).Msg("receive contract script")
+				continue
+			}
+			decoded := indexer.decode(script, task.destination, task.entrypoint, task.value)
+			if decoded == nil {
+				continue
+			}
+
+			transaction := models.Transaction{
+				MempoolOperation:  models.MempoolOperation{Network: indexer.network, Hash: task.hash},
+				Counter:           task.counter,
+				ParametersDecoded: decoded,
+			}
+			if err := indexer.db.SetParametersDecoded(ctx, &transaction); err != nil {
+				indexer.error(err).Str("hash", task.hash).Msg("set decoded parameters")
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+)
+
+const (
+	defaultPendingLimit    = 10000
+	pendingReleaseInterval = time.Second
+)
+
+type pendingOperation struct {
+	msg      receiver.Message
+	applied  node.Applied
+	received time.Time
+}
+
+// pendingOperations - applied operations which branch isn't known by the block queue yet. Mempool of the node can be ahead of the chain source,
+// so such operations are kept until the block of the branch is received or the timeout is expired.
+// Operations are kept in the receiving order in `queue` and indexed by hash in `items`.
+type pendingOperations struct {
+	items   map[string]*list.Element
+	queue   *list.List
+	timeout time.Duration
+	limit   int
+	mx      sync.Mutex
+}
+
+func newPendingOperations(timeout time.Duration, limit int) *pendingOperations {
+	return &pendingOperations{
+		items:   make(map[string]*list.Element),
+		queue:   list.New(),
+		timeout: timeout,
+		limit:   limit,
+	}
+}
+
+// Add - buffers the operation. The operation buffered with the same hash is replaced. The oldest operation is dropped if the buffer is full.
+func (p *pendingOperations) Add(msg receiver.Message, applied node.Applied, received time.Time) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.remove(applied.Hash)
+	if p.queue.Len() >= p.limit {
+		p.delete(p.queue.Front())
+	}
+	p.items[applied.Hash] = p.queue.PushBack(&pendingOperation{msg, applied, received})
+}
+
+// Remove - drops buffered operations with the hashes. It's called when a newer status of the operation is received,
+// so the stale applied status isn't written after it. Returns count of the dropped operations.
+func (p *pendingOperations) Remove(hashes ...string) int {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	var count int
+	for i := range hashes {
+		count += p.remove(hashes[i])
+	}
+	return count
+}
+
+func (p *pendingOperations) remove(hash string) int {
+	element, ok := p.items[hash]
+	if !ok {
+		return 0
+	}
+	p.delete(element)
+	return 1
+}
+
+func (p *pendingOperations) delete(element *list.Element) {
+	op := p.queue.Remove(element).(*pendingOperation)
+	delete(p.items, op.applied.Hash)
+}
+
+// Release - returns operations which branch is known in the receiving order and removes them and expired ones from the buffer.
+// The second value is count of the expired operations.
+func (p *pendingOperations) Release(known func(branch string) bool, now time.Time) ([]pendingOperation, int) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	var (
+		released []pendingOperation
+		expired  int
+	)
+	for element := p.queue.Front(); element != nil; {
+		next := element.Next()
+		op := element.Value.(*pendingOperation)
+		switch {
+		case known(op.applied.Branch):
+			released = append(released, *op)
+			p.delete(element)
+		case now.Sub(op.received) > p.timeout:
+			expired++
+			p.delete(element)
+		}
+		element = next
+	}
+	return released, expired
+}
+
+// Len -
+func (p *pendingOperations) Len() int {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	return p.queue.Len()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+)
+
+func TestPendingOperations_Release(t *testing.T) {
+	now := time.Now()
+	pending := newPendingOperations(time.Minute, 3)
+	for _, op := range []struct {
+		hash, branch string
+		received     time.Time
+	}{
+		{"oo0", "BL0", now},
+		{"oo1", "BL1", now.Add(-2 * time.Minute)},
+		{"oo2", "BL2", now},
+		{"oo3", "BL1", now},
+		{"oo4", "BL2", now},
+	} {
+		pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: op.hash, Branch: op.branch}, op.received)
+	}
+
+	released, expired := pending.Release(func(branch string) bool { return branch == "BL2" }, now)
+	if len(released) != 2 || released[0].applied.Hash != "oo2" || released[1].applied.Hash != "oo4" {
+		t.Errorf("released = %v, want oo2 and oo4 in the receiving order", released)
+	}
+	if expired != 0 {
+		t.Errorf("expired = %d, want 0: the oldest operations are dropped by limit", expired)
+	}
+	if pending.Len() != 1 {
+		t.Errorf("len = %d, want 1", pending.Len())
+	}
+
+	released, expired = pending.Release(func(branch string) bool { return false }, now.Add(2*time.Minute))
+	if len(released) != 0 || expired != 1 || pending.Len() != 0 {
+		t.Errorf("released = %d, expired = %d, len = %d", len(released), expired, pending.Len())
+	}
+}
+
+func TestPendingOperations_Remove(t *testing.T) {
+	now := time.Now()
+	pending := newPendingOperations(time.Minute, 10)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied, Node: "a"}, node.Applied{Hash: "oo1", Branch: "BL1"}, now)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: "oo2", Branch: "BL1"}, now)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied, Node: "b"}, node.Applied{Hash: "oo1", Branch: "BL1"}, now)
+	if pending.Len() != 2 {
+		t.Fatalf("len = %d, want 2: operation with the same hash must be replaced", pending.Len())
+	}
+
+	if removed := pending.Remove("oo2", "oo3"); removed != 1 {
+		t.Errorf("removed = %d, want 1", removed)
+	}
+	released, _ := pending.Release(func(branch string) bool { return true }, now)
+	if len(released) != 1 || released[0].applied.Hash != "oo1" || released[0].msg.Node != "b" {
+		t.Errorf("released = %v, want the last received oo1", released)
+	}
+}
+
+func TestPendingOperations_Evict(t *testing.T) {
+	now := time.Now()
+	pending := newPendingOperations(time.Minute, 2)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: "oo1", Branch: "BL1"}, now)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: "oo2", Branch: "BL1"}, now)
+	// the replaced operation becomes the newest one, so oo2 is evicted
+	pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: "oo1", Branch: "BL1"}, now)
+	pending.Add(receiver.Message{Status: receiver.StatusApplied}, node.Applied{Hash: "oo3", Branch: "BL1"}, now)
+
+	if removed := pending.Remove("oo2"); removed != 0 {
+		t.Errorf("evicted operation is still buffered")
+	}
+	released, _ := pending.Release(func(branch string) bool { return true }, now)
+	if len(released) != 2 || released[0].applied.Hash != "oo1" || released[1].applied.Hash != "oo3" {
+		t.Errorf("released = %v, want oo1 and oo3", released)
+	}
+	if pending.Len() != 0 {
+		t.Errorf("len = %d, want 0", pending.Len())
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+)
+
+const defaultWorkersCount = 4
+
+// task - decoded and filtered mempool operation which is persisted by worker
+type task struct {
+	msg      receiver.Message
+	hash     string
+	applied  *node.Applied
+	failed   *node.FailedMonitor
+	isUpdate bool
+
+	// done - if it's set the worker flushes its batch and closes the channel. Other fields are ignored.
+	done chan struct{}
+}
+
+// worker - persists mempool operations. Every worker has its own batch so operations with the same hash
+// are always processed by the same worker in the receiving order.
+type worker struct {
+	*Indexer
+
+	tasks  chan task
+	batch  *operationBatch
+	events []stream.Event
+
+	// flushAttempts - count of failed writes of the current batch. The next write is postponed until retryAt.
+	flushAttempts int
+	retryAt       time.Time
+}
+
+func newWorker(indexer *Indexer, batchSize int) *worker {
+	return &worker{
+		Indexer: indexer,
+		tasks:   make(chan task, batchSize),
+		batch:   newOperationBatch(batchSize),
+	}
+}
+
+func (w *worker) run(ctx context.Context) {
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.flushOnStop()
+			return
+		case <-ticker.C:
+			if err := w.flushDue(ctx); err != nil {
+				w.error(err).Msg("flush")
+			}
+		case t := <-w.tasks:
+			w.handleTask(ctx, t)
+		}
+	}
+}
+
+func (w *worker) handleTask(ctx context.Context, t task) {
+	switch {
+	case t.done != nil:
+		if err := w.flush(ctx); err != nil {
+			w.error(err).Msg("flush")
+		}
+		close(t.done)
+	case t.applied != nil && t.isUpdate:
+		if err := w.handleStatusUpdate(ctx, t.hash, t.applied.Contents, nil, t.msg); err != nil {
+			w.error(err).Msg("handleStatusUpdate")
+		}
+	case t.applied != nil:
+		if err := w.handleAppliedOperation(ctx, *t.applied, t.msg); err != nil {
+			w.error(err).Msg("handleAppliedOperation")
+		}
+	case t.failed != nil && t.isUpdate:
+		if err := w.handleStatusUpdate(ctx, t.hash, t.failed.Contents, models.JSONB(t.failed.Error), t.msg); err != nil {
+			w.error(err).Msg("handleStatusUpdate")
+		}
+	case t.failed != nil:
+		if err := w.handleFailedOperation(ctx, *t.failed, t.msg); err != nil {
+			w.error(err).Msg("handleFailedOperation")
+		}
+	}
+}
+
+// shard - returns index of the worker which processes operations with the hash
+func shard(hash string, count int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hash))
+	return int(h.Sum32() % uint32(count))
+}
+
+// dispatch - sends the task to the worker which is responsible for its hash
+func (indexer *Indexer) dispatch(ctx context.Context, t task) {
+	select {
+	case <-ctx.Done():
+	case indexer.workers[shard(t.hash, len(indexer.workers))].tasks <- t:
+	}
+}
+
+// barrier - waits until all workers wrote the tasks which were dispatched before the call.
+// It's used before chain updates to keep the order of writes.
+func (indexer *Indexer) barrier(ctx context.Context) error {
+	done := make([]chan struct{}, len(indexer.workers))
+	for i := range indexer.workers {
+		done[i] = make(chan struct{})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case indexer.workers[i].tasks <- task{done: done[i]}:
+		}
+	}
+	for i := range done {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done[i]:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+)
+
+func TestShard(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "one worker", count: 1},
+		{name: "four workers", count: 4},
+		{name: "odd count", count: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				hash := fmt.Sprintf("oo%d", i)
+				got := shard(hash, tt.count)
+				if got < 0 || got >= tt.count {
+					t.Fatalf("shard(%s) = %d, out of range [0, %d)", hash, got, tt.count)
+				}
+				if again := shard(hash, tt.count); again != got {
+					t.Fatalf("shard(%s) is not stable: %d != %d", hash, got, again)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexer_Pipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := storage.NewMemory()
+	indexer := newTestWorker(db, 100).Indexer
+	indexer.flushInterval = time.Hour
+	indexer.pipeline = workerpool.NewGroup()
+	indexer.workers = make([]*worker, 4)
+	for i := range indexer.workers {
+		indexer.workers[i] = newWorker(indexer, 100)
+		indexer.pipeline.GoCtx(ctx, indexer.workers[i].run)
+	}
+
+	statuses := []receiver.Status{receiver.StatusApplied, receiver.StatusRefused, receiver.StatusBranchDelayed}
+	for i := 0; i < 20; i++ {
+		applied := newAppliedTransaction(fmt.Sprintf("oo%d", i), "1")
+		for j, status := range statuses {
+			indexer.dispatch(ctx, task{
+				msg:      receiver.Message{Status: status, ReceivedAt: time.Now()},
+				hash:     applied.Hash,
+				applied:  &applied,
+				isUpdate: j > 0,
+			})
+		}
+	}
+	if err := indexer.barrier(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := db.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 20 {
+		t.Fatalf("stored operations = %d, want 20", len(stored))
+	}
+	for i := range stored {
+		tx := stored[i].(*models.Transaction)
+		if tx.Status != models.StatusBranchDelayed {
+			t.Errorf("status of %s = %s, want %s", tx.Hash, tx.Status, models.StatusBranchDelayed)
+		}
+	}
+
+	cancel()
+	indexer.pipeline.Wait()
+}
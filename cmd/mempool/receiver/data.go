@@ -1,10 +1,14 @@
 package receiver
 
+import "time"
+
 // Message -
 type Message struct {
-	Status   Status
-	Protocol string
-	Body     interface{}
+	Status     Status
+	Protocol   string
+	Node       string
+	ReceivedAt time.Time
+	Body       interface{}
 }
 
 // Status
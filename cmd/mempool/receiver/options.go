@@ -2,6 +2,7 @@ package receiver
 
 import (
 	"github.com/dipdup-net/go-lib/prometheus"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
 )
 
 // ReceiverOption -
@@ -20,3 +21,30 @@ func WithBlockTime(blockTime int64) ReceiverOption {
 		m.blockTime = blockTime
 	}
 }
+
+// WithActiveNodes - sets how many nodes stream the mempool at once. Zero means all healthy nodes.
+func WithActiveNodes(count int) ReceiverOption {
+	return func(m *Receiver) {
+		m.activeNodes = count
+	}
+}
+
+// WithFailover - sets thresholds after which node is considered unhealthy: count of consecutive RPC errors
+// and count of block times without any applied operation.
+func WithFailover(maxErrors, stalledBlocks uint64) ReceiverOption {
+	return func(m *Receiver) {
+		if maxErrors > 0 {
+			m.maxErrors = maxErrors
+		}
+		if stalledBlocks > 0 {
+			m.stalledBlocks = stalledBlocks
+		}
+	}
+}
+
+// WithRecorder - writes every payload received from node monitors to the recording
+func WithRecorder(recorder *record.Recorder) ReceiverOption {
+	return func(m *Receiver) {
+		m.recorder = recorder
+	}
+}
@@ -0,0 +1,125 @@
+package receiver
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/node"
+)
+
+// nodeState - health of the RPC node and its monitor if the node is active
+type nodeState struct {
+	url      string
+	priority int
+	rpc      node.API
+
+	level        uint64
+	errors       uint64
+	stalledUntil time.Time
+	lastApplied  atomic.Int64
+	delivered    atomic.Int64
+
+	monitor *node.Monitor
+	cancel  context.CancelFunc
+	g       workerpool.Group
+}
+
+func newNodeState(url string, priority int) *nodeState {
+	return &nodeState{
+		url:      url,
+		priority: priority,
+		rpc:      node.NewMainRPC(url),
+	}
+}
+
+func (ns *nodeState) isActive() bool {
+	return ns.monitor != nil
+}
+
+// touch - restarts the stall timer of the node. It's called on activation, so the node has `timeout` to deliver operations.
+func (ns *nodeState) touch() {
+	ns.lastApplied.Store(time.Now().UnixNano())
+}
+
+// deliver - marks that the node delivered applied operations right now
+func (ns *nodeState) deliver() {
+	now := time.Now().UnixNano()
+	ns.lastApplied.Store(now)
+	ns.delivered.Store(now)
+}
+
+// isStalled - returns true if active node has not delivered any applied operation for `timeout` while another node did.
+// Silence of all nodes means the quiet mempool, not the stalled node. `others` is the last delivery time of other nodes.
+func (ns *nodeState) isStalled(now time.Time, timeout time.Duration, others time.Time) bool {
+	if !ns.isActive() || timeout == 0 {
+		return false
+	}
+	last := time.Unix(0, ns.lastApplied.Load())
+	return now.Sub(last) > timeout && now.Sub(others) <= timeout
+}
+
+// lastDelivery - returns the last time when any node except `except` delivered applied operations
+func lastDelivery(nodes []*nodeState, except *nodeState) time.Time {
+	var last int64
+	for _, ns := range nodes {
+		if ns == except {
+			continue
+		}
+		last = max(last, ns.delivered.Load())
+	}
+	return time.Unix(0, last)
+}
+
+// lag - returns how many levels the node is behind the indexer
+func (ns *nodeState) lag(indexerLevel uint64) uint64 {
+	if indexerLevel == 0 || ns.level+1 >= indexerLevel {
+		return 0
+	}
+	return indexerLevel - ns.level - 1
+}
+
+// score - health penalty of the node. Zero means that node is healthy. The greater score is the worse node is.
+func (ns *nodeState) score(indexerLevel uint64, maxErrors uint64, now time.Time) uint64 {
+	var score uint64
+	if lag := ns.lag(indexerLevel); lag > 0 {
+		score += 10 * lag
+	}
+	if maxErrors > 0 && ns.errors >= maxErrors {
+		score += 100 * ns.errors
+	}
+	if now.Before(ns.stalledUntil) {
+		score += 1000
+	}
+	return score
+}
+
+// choose - returns the nodes which should stream the mempool: up to `count` healthy nodes in order of preference.
+// If there is no healthy node the least unhealthy one is returned.
+func choose(nodes []*nodeState, count int, indexerLevel, maxErrors uint64, now time.Time) map[string]struct{} {
+	if count <= 0 || count > len(nodes) {
+		count = len(nodes)
+	}
+
+	ordered := make([]*nodeState, len(nodes))
+	copy(ordered, nodes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		si := ordered[i].score(indexerLevel, maxErrors, now)
+		sj := ordered[j].score(indexerLevel, maxErrors, now)
+		if si != sj {
+			return si < sj
+		}
+		return ordered[i].priority < ordered[j].priority
+	})
+
+	result := make(map[string]struct{}, count)
+	for i := 0; i < count; i++ {
+		if i > 0 && ordered[i].score(indexerLevel, maxErrors, now) > 0 {
+			break
+		}
+		result[ordered[i].url] = struct{}{}
+	}
+	return result
+}
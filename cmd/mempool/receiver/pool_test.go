@@ -0,0 +1,151 @@
+package receiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+)
+
+func TestChoose(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		nodes []*nodeState
+		count int
+		level uint64
+		want  []string
+	}{
+		{
+			name: "all healthy, fan-in",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 100},
+				{url: "b", priority: 1, level: 100},
+			},
+			level: 100,
+			want:  []string{"a", "b"},
+		}, {
+			name: "preferred node is healthy",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 100},
+				{url: "b", priority: 1, level: 100},
+			},
+			count: 1,
+			level: 100,
+			want:  []string{"a"},
+		}, {
+			name: "preferred node is stuck",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 90},
+				{url: "b", priority: 1, level: 100},
+			},
+			count: 1,
+			level: 100,
+			want:  []string{"b"},
+		}, {
+			name: "preferred node returns errors",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 100, errors: 3},
+				{url: "b", priority: 1, level: 100},
+				{url: "c", priority: 2, level: 100},
+			},
+			level: 100,
+			want:  []string{"b", "c"},
+		}, {
+			name: "preferred node is stalled",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 100, stalledUntil: now.Add(time.Minute)},
+				{url: "b", priority: 1, level: 100},
+			},
+			count: 1,
+			level: 100,
+			want:  []string{"b"},
+		}, {
+			name: "all nodes are unhealthy",
+			nodes: []*nodeState{
+				{url: "a", priority: 0, level: 80},
+				{url: "b", priority: 1, level: 95},
+			},
+			level: 100,
+			want:  []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := choose(tt.nodes, tt.count, tt.level, 3, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("choose() = %v, want %v", got, tt.want)
+			}
+			for _, url := range tt.want {
+				if _, ok := got[url]; !ok {
+					t.Errorf("choose() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeState_IsStalled(t *testing.T) {
+	now := time.Now()
+	timeout := time.Minute
+
+	newActive := func(lastApplied time.Time) *nodeState {
+		ns := &nodeState{url: "a", monitor: new(node.Monitor)}
+		ns.lastApplied.Store(lastApplied.UnixNano())
+		return ns
+	}
+
+	tests := []struct {
+		name        string
+		lastApplied time.Time
+		others      time.Time
+		want        bool
+	}{
+		{
+			name:        "node delivers operations",
+			lastApplied: now.Add(-time.Second),
+			others:      now,
+		}, {
+			name:        "quiet mempool: nobody delivers operations",
+			lastApplied: now.Add(-2 * timeout),
+			others:      now.Add(-2 * timeout),
+		}, {
+			name:        "no other node",
+			lastApplied: now.Add(-2 * timeout),
+		}, {
+			name:        "another node delivers operations",
+			lastApplied: now.Add(-2 * timeout),
+			others:      now.Add(-time.Second),
+			want:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newActive(tt.lastApplied).isStalled(now, timeout, tt.others); got != tt.want {
+				t.Errorf("isStalled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastDelivery(t *testing.T) {
+	a, b, c := &nodeState{url: "a"}, &nodeState{url: "b"}, &nodeState{url: "c"}
+	a.deliver()
+	time.Sleep(time.Millisecond)
+	b.deliver()
+
+	nodes := []*nodeState{a, b, c}
+	if got := lastDelivery(nodes, b); got.UnixNano() != a.delivered.Load() {
+		t.Errorf("lastDelivery() except b = %v, want delivery of a", got)
+	}
+	if got := lastDelivery(nodes, a); got.UnixNano() != b.delivered.Load() {
+		t.Errorf("lastDelivery() except a = %v, want delivery of b", got)
+	}
+
+	// activation doesn't mean delivery
+	c.touch()
+	if got := lastDelivery([]*nodeState{a, c}, a); got.UnixNano() != 0 {
+		t.Errorf("lastDelivery() = %v, want zero", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/dipdup-io/workerpool"
@@ -11,44 +12,70 @@ import (
 	"github.com/dipdup-net/go-lib/node"
 	"github.com/dipdup-net/go-lib/prometheus"
 	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
+// metric names
+const (
+	rpcErrorsCountName       = "mempool_rpc_errors_count"
+	nodeFirstSeenCountName   = "mempool_node_first_seen_count"
+	nodeDuplicatesCountName  = "mempool_node_duplicates_count"
+	nodeActiveGaugeName      = "mempool_node_active"
+	nodeSwitchesCountName    = "mempool_node_switches_count"
+	defaultDeduplicationSize = 100_000
+)
+
 // Receiver -
 type Receiver struct {
-	url       string
-	monitor   *node.Monitor
-	db        *database.Bun
+	nodes     []*nodeState
+	db        database.StateRepository
 	prom      *prometheus.Service
 	state     *database.State
+	seen      *transitions
+	recorder  *record.Recorder
 	indexName string
 	protocol  string
 	network   string
 
-	blockTime int64
+	blockTime     int64
+	activeNodes   int
+	maxErrors     uint64
+	stalledBlocks uint64
 
+	mx         sync.RWMutex
 	g          workerpool.Group
 	operations chan Message
 }
 
 // New -
-func New(url string, network string, db *database.Bun, opts ...ReceiverOption) (*Receiver, error) {
-	if url == "" {
-		return nil, errors.Errorf("empty url: %s", network)
+func New(urls []string, network string, db database.StateRepository, opts ...ReceiverOption) (*Receiver, error) {
+	if len(urls) == 0 {
+		return nil, errors.Errorf("empty url list: %s", network)
 	}
 	if db == nil {
 		return nil, errors.Errorf("nil database connection: %s", network)
 	}
 
 	indexer := Receiver{
-		url:        url,
-		db:         db,
-		operations: make(chan Message, 1024),
-		indexName:  models.MempoolIndexName(network),
-		network:    network,
-		monitor:    node.NewMonitor(url),
-		g:          workerpool.NewGroup(),
+		db:            db,
+		operations:    make(chan Message, 1024),
+		indexName:     models.MempoolIndexName(network),
+		network:       network,
+		nodes:         make([]*nodeState, 0, len(urls)),
+		seen:          newTransitions(),
+		state:         new(database.State),
+		maxErrors:     3,
+		stalledBlocks: 10,
+		g:             workerpool.NewGroup(),
+	}
+
+	for i := range urls {
+		if urls[i] == "" {
+			return nil, errors.Errorf("empty url: %s", network)
+		}
+		indexer.nodes = append(indexer.nodes, newNodeState(urls[i], i))
 	}
 
 	for i := range opts {
@@ -64,29 +91,20 @@ func New(url string, network string, db *database.Bun, opts ...ReceiverOption) (
 
 // Start -
 func (indexer *Receiver) Start(ctx context.Context) {
-	indexer.g.GoCtx(ctx, func(ctx context.Context) {
-		indexer.updateState(ctx, indexer.url)
-	})
-
-	indexer.g.GoCtx(ctx, func(ctx context.Context) {
-		indexer.run(ctx, indexer.monitor)
-	})
-
-	indexer.monitor.SubscribeOnMempoolApplied(ctx)
-	indexer.monitor.SubscribeOnMempoolBranchDelayed(ctx)
-	indexer.monitor.SubscribeOnMempoolBranchRefused(ctx)
-	indexer.monitor.SubscribeOnMempoolRefused(ctx)
-	indexer.monitor.SubscribeOnMempoolOutdated(ctx)
+	indexer.g.GoCtx(ctx, indexer.updateState)
 }
 
 // Close -
 func (indexer *Receiver) Close() error {
 	indexer.g.Wait()
 
-	if err := indexer.monitor.Close(); err != nil {
-		log.Err(err).Str("network", indexer.network).Msg("closing monitor")
+	for _, ns := range indexer.nodes {
+		if ns.isActive() {
+			indexer.deactivate(ns)
+		}
 	}
 
+	indexer.seen.close()
 	close(indexer.operations)
 	return nil
 }
@@ -96,73 +114,161 @@ func (indexer *Receiver) Operations() <-chan Message {
 	return indexer.operations
 }
 
-func (indexer *Receiver) run(ctx context.Context, monitor *node.Monitor) {
+// activate - subscribes on the node mempool
+func (indexer *Receiver) activate(ctx context.Context, ns *nodeState) {
+	monitorCtx, cancel := context.WithCancel(ctx)
+	ns.monitor = node.NewMonitor(ns.url)
+	ns.cancel = cancel
+	ns.g = workerpool.NewGroup()
+	ns.touch()
+
+	monitor := ns.monitor
+	ns.g.GoCtx(monitorCtx, func(ctx context.Context) {
+		indexer.run(ctx, ns, monitor)
+	})
+
+	monitor.SubscribeOnMempoolApplied(monitorCtx)
+	monitor.SubscribeOnMempoolBranchDelayed(monitorCtx)
+	monitor.SubscribeOnMempoolBranchRefused(monitorCtx)
+	monitor.SubscribeOnMempoolRefused(monitorCtx)
+	monitor.SubscribeOnMempoolOutdated(monitorCtx)
+
+	indexer.setNodeActiveMetric(ns.url, 1)
+}
+
+// deactivate - unsubscribes from the node mempool
+func (indexer *Receiver) deactivate(ns *nodeState) {
+	ns.cancel()
+	ns.g.Wait()
+	if err := ns.monitor.Close(); err != nil {
+		log.Err(err).Str("network", indexer.network).Str("node", ns.url).Msg("closing monitor")
+	}
+	ns.monitor = nil
+	ns.cancel = nil
+
+	indexer.setNodeActiveMetric(ns.url, 0)
+}
+
+// rebalance - switches mempool subscriptions to the healthiest nodes
+func (indexer *Receiver) rebalance(ctx context.Context) {
+	now := time.Now()
+	stallTimeout := time.Duration(indexer.blockTime) * time.Duration(indexer.stalledBlocks) * time.Second
+
+	for _, ns := range indexer.nodes {
+		if ns.isStalled(now, stallTimeout, lastDelivery(indexer.nodes, ns)) {
+			log.Warn().Str("network", indexer.network).Str("node", ns.url).Msg("node stopped delivering applied operations")
+			ns.stalledUntil = now.Add(stallTimeout)
+		}
+	}
+
+	desired := choose(indexer.nodes, indexer.activeNodes, indexer.state.Level, indexer.maxErrors, now)
+	for _, ns := range indexer.nodes {
+		_, want := desired[ns.url]
+		switch {
+		case want && !ns.isActive():
+			log.Info().Str("network", indexer.network).Str("node", ns.url).Msg("subscribing on node mempool")
+			indexer.activate(ctx, ns)
+			indexer.incrementNodeMetric(nodeSwitchesCountName, ns.url)
+		case !want && ns.isActive():
+			log.Warn().Str("network", indexer.network).Str("node", ns.url).Uint64("score", ns.score(indexer.state.Level, indexer.maxErrors, now)).Msg("node is unhealthy, unsubscribing from node mempool")
+			indexer.deactivate(ns)
+		}
+	}
+}
+
+func (indexer *Receiver) run(ctx context.Context, ns *nodeState, monitor *node.Monitor) {
+	url := ns.url
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
 		case applied := <-monitor.Applied():
+			if len(applied) > 0 {
+				ns.deliver()
+			}
 			for i := range applied {
-				indexer.operations <- Message{
-					Status:   StatusApplied,
-					Body:     *applied[i],
-					Protocol: indexer.protocol,
-				}
+				indexer.send(ctx, url, StatusApplied, applied[i].Hash, *applied[i])
 			}
 		case branchDelayed := <-monitor.BranchDelayed():
 			for i := range branchDelayed {
-				indexer.operations <- Message{
-					Status:   StatusBranchDelayed,
-					Body:     *branchDelayed[i],
-					Protocol: indexer.protocol,
-				}
+				indexer.send(ctx, url, StatusBranchDelayed, branchDelayed[i].Hash, *branchDelayed[i])
 			}
 		case branchRefused := <-monitor.BranchRefused():
 			for i := range branchRefused {
-				indexer.operations <- Message{
-					Status:   StatusBranchRefused,
-					Body:     *branchRefused[i],
-					Protocol: indexer.protocol,
-				}
+				indexer.send(ctx, url, StatusBranchRefused, branchRefused[i].Hash, *branchRefused[i])
 			}
 		case refused := <-monitor.Refused():
 			for i := range refused {
-				indexer.operations <- Message{
-					Status:   StatusRefused,
-					Body:     *refused[i],
-					Protocol: indexer.protocol,
-				}
+				indexer.send(ctx, url, StatusRefused, refused[i].Hash, *refused[i])
 			}
 		case outdated := <-monitor.Outdated():
 			for i := range outdated {
-				indexer.operations <- Message{
-					Status:   StatusOutdated,
-					Body:     *outdated[i],
-					Protocol: indexer.protocol,
-				}
+				indexer.send(ctx, url, StatusOutdated, outdated[i].Hash, *outdated[i])
 			}
 		}
 	}
 }
 
-func (indexer *Receiver) checkHead(ctx context.Context, rpc node.API) error {
-	head, err := rpc.Header(ctx, "head")
+// send - pushes the message to the output channel if the operation has not been reported with the same status yet
+func (indexer *Receiver) send(ctx context.Context, url string, status Status, hash string, body any) {
+	indexer.record(url, status, body)
+
+	if !indexer.isTransition(url, status, hash) {
+		indexer.incrementNodeMetric(nodeDuplicatesCountName, url)
+		return
+	}
+	indexer.incrementNodeMetric(nodeFirstSeenCountName, url)
+
+	select {
+	case <-ctx.Done():
+	case indexer.operations <- Message{
+		Status:     status,
+		Body:       body,
+		Protocol:   indexer.getProtocol(),
+		Node:       url,
+		ReceivedAt: time.Now(),
+	}:
+	}
+}
+
+// isTransition - returns true if the node moved the operation to a status differing from its last reported status
+func (indexer *Receiver) isTransition(url string, status Status, hash string) bool {
+	return indexer.seen.isTransition(url, status, hash, indexer.blockTime)
+}
+
+func (indexer *Receiver) getProtocol() string {
+	indexer.mx.RLock()
+	defer indexer.mx.RUnlock()
+	return indexer.protocol
+}
+
+func (indexer *Receiver) setProtocol(protocol string) {
+	indexer.mx.Lock()
+	indexer.protocol = protocol
+	indexer.mx.Unlock()
+}
+
+func (indexer *Receiver) checkHead(ctx context.Context, ns *nodeState) error {
+	head, err := ns.rpc.Header(ctx, "head")
 	if err != nil {
-		indexer.incrementMetric(rpc.URL(), indexer.network, err)
+		ns.errors++
+		indexer.incrementMetric(ns.url, indexer.network, err)
 		return err
 	}
+	ns.errors = 0
+	ns.level = head.Level
 
 	// If node is behind indexer more than one block throw error
-	if head.Level < indexer.state.Level-1 && indexer.state.Level > 0 {
-		return errors.Errorf("Node is stucked url=%s node_level=%d indexer_level=%d", rpc.URL(), head.Level, indexer.state.Level)
+	if ns.lag(indexer.state.Level) > 0 {
+		return errors.Errorf("Node is stucked url=%s node_level=%d indexer_level=%d", ns.url, head.Level, indexer.state.Level)
 	}
 
-	indexer.protocol = head.Protocol
+	indexer.setProtocol(head.Protocol)
 	return nil
 }
 
-func (indexer *Receiver) updateState(ctx context.Context, url string) {
+func (indexer *Receiver) updateState(ctx context.Context) {
 	ticker := time.NewTicker(time.Second * time.Duration(indexer.blockTime))
 	defer ticker.Stop()
 
@@ -170,21 +276,16 @@ func (indexer *Receiver) updateState(ctx context.Context, url string) {
 	if err := indexer.setState(ctx); err != nil {
 		log.Err(err).Msg("set state")
 	}
-
-	rpc := node.NewMainRPC(url)
-	if err := indexer.checkHead(ctx, rpc); err != nil {
-		log.Err(err).Msg("check head")
-	}
+	indexer.checkHeads(ctx)
+	indexer.rebalance(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := indexer.checkHead(ctx, rpc); err != nil {
-				log.Err(err).Msg("check head")
-				continue
-			}
+			indexer.checkHeads(ctx)
+			indexer.rebalance(ctx)
 			if err := indexer.setState(ctx); err != nil {
 				log.Err(err).Msg("set state")
 				continue
@@ -193,6 +294,14 @@ func (indexer *Receiver) updateState(ctx context.Context, url string) {
 	}
 }
 
+func (indexer *Receiver) checkHeads(ctx context.Context) {
+	for _, ns := range indexer.nodes {
+		if err := indexer.checkHead(ctx, ns); err != nil {
+			log.Err(err).Str("network", indexer.network).Msg("check head")
+		}
+	}
+}
+
 func (indexer *Receiver) setState(ctx context.Context) error {
 	state, err := indexer.db.State(ctx, indexer.indexName)
 	if err != nil {
@@ -215,9 +324,29 @@ func (indexer *Receiver) incrementMetric(url, network string, err error) {
 	if !ok {
 		return
 	}
-	indexer.prom.IncrementCounter("mempool_rpc_errors_count", map[string]string{
+	indexer.prom.IncrementCounter(rpcErrorsCountName, map[string]string{
 		"network": network,
 		"node":    url,
 		"code":    fmt.Sprintf("%d", reqErr.Code),
 	})
 }
+
+func (indexer *Receiver) setNodeActiveMetric(url string, value float64) {
+	if indexer.prom == nil {
+		return
+	}
+	indexer.prom.SetGaugeValue(nodeActiveGaugeName, map[string]string{
+		"network": indexer.network,
+		"node":    url,
+	}, value)
+}
+
+func (indexer *Receiver) incrementNodeMetric(name, url string) {
+	if indexer.prom == nil {
+		return
+	}
+	indexer.prom.IncrementCounter(name, map[string]string{
+		"network": indexer.network,
+		"node":    url,
+	})
+}
@@ -0,0 +1,122 @@
+package receiver
+
+import (
+	"context"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// record - writes raw monitor payload to the recording if it's enabled
+func (indexer *Receiver) record(url string, status Status, body any) {
+	if indexer.recorder == nil {
+		return
+	}
+
+	var payload any = body
+	switch typed := body.(type) {
+	case node.Applied:
+		if len(typed.Raw) > 0 {
+			payload = []byte(typed.Raw)
+		}
+	case node.FailedMonitor:
+		if len(typed.Raw) > 0 {
+			payload = []byte(typed.Raw)
+		}
+	}
+
+	if err := indexer.recorder.Record(record.Entry{
+		Source:   record.SourceMempool,
+		Kind:     string(status),
+		Node:     url,
+		Protocol: indexer.getProtocol(),
+	}, payload); err != nil {
+		log.Err(err).Str("network", indexer.network).Msg("record mempool operation")
+	}
+}
+
+// Replay - mempool source which feeds operations from the recording instead of node monitors.
+// Operations are deduplicated in the same way as operations received from nodes.
+type Replay struct {
+	seen       *transitions
+	blockTime  int64
+	operations chan Message
+}
+
+// NewReplay -
+func NewReplay(blockTime int64) *Replay {
+	if blockTime == 0 {
+		blockTime = 15
+	}
+	return &Replay{
+		seen:       newTransitions(),
+		blockTime:  blockTime,
+		operations: make(chan Message, 1024),
+	}
+}
+
+// Start -
+func (r *Replay) Start(ctx context.Context) {}
+
+// Close -
+func (r *Replay) Close() error {
+	r.seen.close()
+	close(r.operations)
+	return nil
+}
+
+// Operations -
+func (r *Replay) Operations() <-chan Message {
+	return r.operations
+}
+
+// Push - decodes the recorded monitor payload and sends it to the output channel. Entries of other sources are ignored.
+func (r *Replay) Push(ctx context.Context, entry record.Entry) error {
+	if entry.Source != record.SourceMempool {
+		return nil
+	}
+
+	var (
+		status = Status(entry.Kind)
+		hash   string
+		body   any
+	)
+	switch status {
+	case StatusApplied:
+		var applied node.Applied
+		if err := json.Unmarshal(entry.Payload, &applied); err != nil {
+			return errors.Wrap(err, "decode applied operation")
+		}
+		hash, body = applied.Hash, applied
+	case StatusBranchDelayed, StatusBranchRefused, StatusRefused, StatusOutdated:
+		var failed node.FailedMonitor
+		if err := json.Unmarshal(entry.Payload, &failed); err != nil {
+			return errors.Wrapf(err, "decode %s operation", status)
+		}
+		hash, body = failed.Hash, failed
+	default:
+		return errors.Errorf("unknown recorded mempool status: %s", entry.Kind)
+	}
+
+	if !r.seen.isTransition(entry.Node, status, hash, r.blockTime) {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.operations <- Message{
+		Status:     status,
+		Body:       body,
+		Protocol:   entry.Protocol,
+		Node:       entry.Node,
+		ReceivedAt: entry.Time,
+	}:
+	}
+	return nil
+}
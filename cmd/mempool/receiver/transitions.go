@@ -0,0 +1,64 @@
+package receiver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache"
+)
+
+// transitions - remembers the last status reported for operations to drop duplicates received from several nodes.
+// The last status is kept per operation and per (node, operation): the first report of a node disagreeing with the others is emitted,
+// but nodes repeating their own statuses or first reporting an already emitted one don't flip the operation back and forth.
+type transitions struct {
+	cache *ccache.Cache
+	mx    sync.Mutex
+}
+
+func newTransitions() *transitions {
+	return &transitions{
+		cache: ccache.New(ccache.Configure().MaxSize(defaultDeduplicationSize)),
+	}
+}
+
+// isTransition - returns true if the status differs from the last status of the operation and the node moved the operation to it.
+// The first report of a node is a transition unless the status was already emitted for the operation.
+func (t *transitions) isTransition(node string, status Status, hash string, blockTime int64) bool {
+	ttl := time.Duration(blockTime) * time.Second * 120
+	nodeKey := node + "|" + hash
+	emittedKey := hash + "#" + string(status)
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	last, hasLast := t.get(hash)
+	nodeLast, hasNodeLast := t.get(nodeKey)
+	_, emitted := t.get(emittedKey)
+	t.cache.Set(nodeKey, status, ttl)
+
+	switch {
+	case !hasLast:
+	case last == status:
+		return false
+	case hasNodeLast && nodeLast == status:
+		return false
+	case !hasNodeLast && emitted:
+		return false
+	}
+	t.cache.Set(hash, status, ttl)
+	t.cache.Set(emittedKey, status, ttl)
+	return true
+}
+
+func (t *transitions) get(key string) (Status, bool) {
+	item := t.cache.Get(key)
+	if item == nil || item.Expired() {
+		return "", false
+	}
+	status, ok := item.Value().(Status)
+	return status, ok
+}
+
+func (t *transitions) close() {
+	t.cache.Stop()
+}
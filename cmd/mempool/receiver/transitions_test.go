@@ -0,0 +1,62 @@
+package receiver
+
+import "testing"
+
+func TestTransitions(t *testing.T) {
+	type report struct {
+		node   string
+		status Status
+		want   bool
+	}
+
+	tests := []struct {
+		name    string
+		reports []report
+	}{
+		{
+			name: "duplicate from another node",
+			reports: []report{
+				{"a", StatusApplied, true},
+				{"b", StatusApplied, false},
+			},
+		}, {
+			name: "nodes disagree",
+			reports: []report{
+				{"a", StatusApplied, true},
+				{"b", StatusBranchDelayed, true},
+				{"a", StatusApplied, false},
+				{"b", StatusBranchDelayed, false},
+			},
+		}, {
+			name: "first report of a node is refused",
+			reports: []report{
+				{"a", StatusApplied, true},
+				{"b", StatusRefused, true},
+				{"a", StatusApplied, false},
+				{"c", StatusApplied, false},
+				{"b", StatusRefused, false},
+			},
+		}, {
+			name: "node moves operation forward",
+			reports: []report{
+				{"a", StatusApplied, true},
+				{"b", StatusApplied, false},
+				{"a", StatusBranchDelayed, true},
+				{"b", StatusBranchDelayed, false},
+				{"b", StatusApplied, true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := newTransitions()
+			defer seen.close()
+
+			for i, r := range tt.reports {
+				if got := seen.isTransition(r.node, r.status, "oo", 30); got != r.want {
+					t.Errorf("report %d (%s %s): isTransition() = %v, want %v", i, r.node, r.status, got, r.want)
+				}
+			}
+		})
+	}
+}
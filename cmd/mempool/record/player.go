@@ -0,0 +1,62 @@
+package record
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Handler - receives entries of the recording during playback
+type Handler func(ctx context.Context, entry Entry) error
+
+// Play - passes entries of the recording to the handler in recorded order. Intervals between entries are divided by `speed`,
+// so 1 replays in real time and 10 replays 10 times faster. Zero or negative speed replays without delays.
+func Play(ctx context.Context, path string, speed float64, handler Handler) error {
+	reader, err := NewReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var (
+		first   time.Time
+		started = time.Now()
+		timer   = time.NewTimer(0)
+	)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		entry, err := reader.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return errors.Wrap(err, "read recording")
+		}
+
+		if speed > 0 {
+			if first.IsZero() {
+				first = entry.Time
+			}
+			offset := time.Duration(float64(entry.Time.Sub(first)) / speed)
+			if wait := time.Until(started.Add(offset)); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handler(ctx, entry); err != nil {
+			return err
+		}
+	}
+}
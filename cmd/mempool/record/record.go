@@ -0,0 +1,226 @@
+package record
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// sources of recorded entries
+const (
+	SourceNode    = "node"
+	SourceMempool = "mempool"
+	SourceTzKT    = "tzkt"
+	// SourceNodeChain - blocks and operations of the chain source based on node RPC. They have the same format as TzKT ones.
+	SourceNodeChain = "node_chain"
+)
+
+// kinds of recorded entries. Mempool entries have kind of the operation status.
+const (
+	KindHead       = "head"
+	KindBlock      = "block"
+	KindOperations = "operations"
+)
+
+const flushInterval = time.Second
+
+// Entry - recorded message of data source
+type Entry struct {
+	Time     time.Time           `json:"time"`
+	Source   string              `json:"source"`
+	Kind     string              `json:"kind"`
+	Node     string              `json:"node,omitempty"`
+	Protocol string              `json:"protocol,omitempty"`
+	Payload  jsoniter.RawMessage `json:"payload"`
+}
+
+// Head - chain parameters at the moment when recording was started. They replace node RPC requests during replay.
+type Head struct {
+	ChainID        string    `json:"chain_id"`
+	Hash           string    `json:"hash"`
+	Level          uint64    `json:"level"`
+	Timestamp      time.Time `json:"timestamp"`
+	BlockTime      int64     `json:"block_time"`
+	BlocksPerCycle uint64    `json:"blocks_per_cycle"`
+}
+
+// FileName - returns path of the recording of the network in the directory
+func FileName(dir, network string) string {
+	return filepath.Join(dir, network+".jsonl.gz")
+}
+
+// Recorder - writes entries to gzip-compressed JSONL file. It's safe for concurrent use.
+type Recorder struct {
+	file      *os.File
+	gz        *gzip.Writer
+	buf       *bufio.Writer
+	encoder   *jsoniter.Encoder
+	lastFlush time.Time
+	mx        sync.Mutex
+}
+
+// ErrRecordingExists - the recording file exists and overwriting isn't allowed
+var ErrRecordingExists = errors.New("recording already exists")
+
+// NewRecorder - creates the file at `path`. Existing file is truncated if `overwrite` is true, otherwise `ErrRecordingExists` is returned.
+func NewRecorder(path string, overwrite bool) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errors.Wrap(err, "create recording directory")
+	}
+	flags := os.O_RDWR | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o666)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, errors.Wrap(ErrRecordingExists, path)
+		}
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	buf := bufio.NewWriter(gz)
+	return &Recorder{
+		file:      file,
+		gz:        gz,
+		buf:       buf,
+		encoder:   json.NewEncoder(buf),
+		lastFlush: time.Now(),
+	}, nil
+}
+
+// Record - writes the entry with the payload. `payload` is written as is if it's raw JSON and is marshaled otherwise.
+// Entry time is set to the current time if it's empty.
+func (r *Recorder) Record(entry Entry, payload any) error {
+	switch typed := payload.(type) {
+	case jsoniter.RawMessage:
+		entry.Payload = typed
+	case []byte:
+		entry.Payload = typed
+	default:
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "marshal payload")
+		}
+		entry.Payload = data
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if err := r.encoder.Encode(entry); err != nil {
+		return err
+	}
+	// flushing periodically keeps the file readable up to the last second if the process is killed
+	if time.Since(r.lastFlush) < flushInterval {
+		return nil
+	}
+	r.lastFlush = time.Now()
+	return r.flush()
+}
+
+func (r *Recorder) flush() error {
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	return r.gz.Flush()
+}
+
+// Close -
+func (r *Recorder) Close() error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+	if err := r.gz.Close(); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
+
+// Reader - reads entries of the recording one by one
+type Reader struct {
+	file   *os.File
+	gz     *gzip.Reader
+	reader *bufio.Reader
+}
+
+// NewReader -
+func NewReader(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+	return &Reader{
+		file:   file,
+		gz:     gz,
+		reader: bufio.NewReader(gz),
+	}, nil
+}
+
+// Next - returns the next entry. It returns io.EOF when the recording is over.
+// Recording which was cut off by the process termination ends with io.EOF as well: incomplete last line is skipped.
+func (r *Reader) Next() (Entry, error) {
+	var entry Entry
+	line, err := r.reader.ReadBytes('\n')
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return entry, io.EOF
+	case err != nil:
+		return entry, err
+	}
+	err = json.Unmarshal(line, &entry)
+	return entry, err
+}
+
+// Close -
+func (r *Reader) Close() error {
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// ReadHead - returns chain parameters stored in the recording
+func ReadHead(path string) (Head, error) {
+	var head Head
+
+	reader, err := NewReader(path)
+	if err != nil {
+		return head, err
+	}
+	defer reader.Close()
+
+	for {
+		entry, err := reader.Next()
+		switch {
+		case errors.Is(err, io.EOF):
+			return head, errors.Errorf("recording doesn't contain head: %s", path)
+		case err != nil:
+			return head, err
+		case entry.Source == SourceNode && entry.Kind == KindHead:
+			err := json.Unmarshal(entry.Payload, &head)
+			return head, err
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func writeRecording(t *testing.T, path string, entries []Entry) {
+	t.Helper()
+
+	recorder, err := NewRecorder(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range entries {
+		if err := recorder.Record(entries[i], []byte(entries[i].Payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	path := FileName(t.TempDir(), "mainnet")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	recorder, err := NewRecorder(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Record(Entry{Source: SourceNode, Kind: KindHead, Time: start}, Head{ChainID: "NetXdQprcVkpaWU", Level: 100, BlockTime: 8}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Record(Entry{Source: SourceMempool, Kind: "applied", Node: "http://node", Time: start.Add(time.Second)}, []byte(`{"hash":"oo1","branch":"BL1"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Record(Entry{Source: SourceTzKT, Kind: KindBlock}, map[string]any{"hash": "BL2", "level": 101}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := ReadHead(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.ChainID != "NetXdQprcVkpaWU" || head.Level != 100 || head.BlockTime != 8 {
+		t.Errorf("unexpected head: %+v", head)
+	}
+
+	reader, err := NewReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var entries []Entry
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(entries))
+	}
+	if got := string(entries[1].Payload); got != `{"hash":"oo1","branch":"BL1"}` {
+		t.Errorf("raw payload = %s", got)
+	}
+	if entries[1].Node != "http://node" || !entries[1].Time.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected mempool entry: %+v", entries[1])
+	}
+	if entries[2].Time.IsZero() {
+		t.Error("entry time is not set")
+	}
+}
+
+func TestReader_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truncated.jsonl.gz")
+	writeRecording(t, path, []Entry{
+		{Source: SourceMempool, Kind: "applied", Payload: []byte(`{"hash":"oo1"}`)},
+		{Source: SourceMempool, Kind: "applied", Payload: []byte(`{"hash":"oo2"}`)},
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-8], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = Play(context.Background(), path, 0, func(ctx context.Context, entry Entry) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("played entries = %d, want 2", count)
+	}
+}
+
+func TestNewRecorder_Exists(t *testing.T) {
+	path := FileName(t.TempDir(), "mainnet")
+	writeRecording(t, path, []Entry{
+		{Source: SourceMempool, Kind: "applied", Payload: []byte(`{"hash":"oo1"}`)},
+	})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewRecorder(path, false); !errors.Is(err, ErrRecordingExists) {
+		t.Fatalf("NewRecorder of existing recording: err = %v, want %v", err, ErrRecordingExists)
+	}
+	kept, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kept, data) {
+		t.Fatal("existing recording is changed")
+	}
+
+	recorder, err := NewRecorder(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := Play(context.Background(), path, 0, func(ctx context.Context, entry Entry) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("entries of overwritten recording = %d, want 0", count)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	start := time.Now().UTC()
+	entries := []Entry{
+		{Source: SourceTzKT, Kind: KindBlock, Time: start, Payload: []byte(`{}`)},
+		{Source: SourceTzKT, Kind: KindBlock, Time: start.Add(200 * time.Millisecond), Payload: []byte(`{}`)},
+		{Source: SourceTzKT, Kind: KindBlock, Time: start.Add(400 * time.Millisecond), Payload: []byte(`{}`)},
+	}
+	tests := []struct {
+		name  string
+		speed float64
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "real time", speed: 1, min: 400 * time.Millisecond, max: time.Second},
+		{name: "accelerated", speed: 4, min: 100 * time.Millisecond, max: 350 * time.Millisecond},
+		{name: "instant", speed: 0, min: 0, max: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "play.jsonl.gz")
+			writeRecording(t, path, entries)
+
+			var played []time.Time
+			began := time.Now()
+			if err := Play(context.Background(), path, tt.speed, func(ctx context.Context, entry Entry) error {
+				played = append(played, entry.Time)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			elapsed := time.Since(began)
+
+			if len(played) != len(entries) {
+				t.Fatalf("played entries = %d, want %d", len(played), len(entries))
+			}
+			for i := range played {
+				if !played[i].Equal(entries[i].Time) {
+					t.Errorf("entry %d is played out of order", i)
+				}
+			}
+			if elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("playback took %s, want between %s and %s", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
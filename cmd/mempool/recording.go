@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+)
+
+// fetchHead - requests chain parameters from the node
+func fetchHead(ctx context.Context, info ChainInfo) (record.Head, error) {
+	var head record.Head
+
+	constants, err := info.Constants(ctx, "head")
+	if err != nil {
+		return head, err
+	}
+	head.BlockTime = constants.MinimalBlockDelay
+	if head.BlockTime == 0 {
+		if len(constants.TimeBetweenBlocks) == 0 {
+			return head, errors.New("Empty time_between_blocks in node response")
+		}
+		head.BlockTime = constants.TimeBetweenBlocks[0]
+	}
+	head.BlocksPerCycle = constants.BlocksPerCycle
+
+	header, err := info.Header(ctx, "head")
+	if err != nil {
+		return head, err
+	}
+	head.ChainID = header.ChainID
+	head.Hash = header.Hash
+	head.Level = header.Level
+	head.Timestamp = header.Timestamp
+	return head, nil
+}
+
+// recordedChainInfo - chain parameters stored in the recording. It replaces the node in replay mode.
+type recordedChainInfo struct {
+	head record.Head
+}
+
+// Constants -
+func (info recordedChainInfo) Constants(ctx context.Context, blockID string) (node.Constants, error) {
+	return node.Constants{
+		MinimalBlockDelay: info.head.BlockTime,
+		BlocksPerCycle:    info.head.BlocksPerCycle,
+	}, nil
+}
+
+// Header -
+func (info recordedChainInfo) Header(ctx context.Context, blockID string) (node.Header, error) {
+	return node.Header{
+		ChainID:   info.head.ChainID,
+		Hash:      info.head.Hash,
+		Level:     info.head.Level,
+		Timestamp: info.head.Timestamp,
+	}, nil
+}
+
+// Metadata -
+func (info recordedChainInfo) Metadata(ctx context.Context, blockID string) (node.BlockMetadata, error) {
+	return node.BlockMetadata{}, errors.New("block metadata isn't recorded, set expired_after_blocks in replay mode")
+}
+
+// newRecorder - creates the recording and writes chain parameters to it, so the recording can be replayed without the node
+func newRecorder(path string, overwrite bool, head record.Head) (*record.Recorder, error) {
+	recorder, err := record.NewRecorder(path, overwrite)
+	if err != nil {
+		return nil, errors.Wrap(err, "create recorder")
+	}
+	if err := recorder.Record(record.Entry{
+		Source: record.SourceNode,
+		Kind:   record.KindHead,
+	}, head); err != nil {
+		recorder.Close()
+		return nil, errors.Wrap(err, "record head")
+	}
+	return recorder, nil
+}
+
+// newReplaySources - creates sources which are fed from the recording of the network
+func newReplaySources(network string, cfg config.Replay, blockTime int64) (OperationSource, ChainSource) {
+	speed := cfg.Speed
+	switch {
+	case cfg.Instant:
+		speed = 0
+	case speed == 0:
+		speed = 1
+	}
+
+	mempool := receiver.NewReplay(blockTime)
+	chain := &replayChain{
+		Replay:  tzkt.NewReplay(),
+		mempool: mempool,
+		path:    record.FileName(cfg.Dir, network),
+		network: network,
+		speed:   speed,
+		g:       workerpool.NewGroup(),
+	}
+	return mempool, chain
+}
+
+// replayChain - chain source of replay mode. It starts playback of the recording to both replay sources on connection.
+type replayChain struct {
+	*tzkt.Replay
+	mempool *receiver.Replay
+	path    string
+	network string
+	speed   float64
+	g       workerpool.Group
+}
+
+// Connect -
+func (r *replayChain) Connect(ctx context.Context) error {
+	r.g.GoCtx(ctx, r.play)
+	return nil
+}
+
+// Close - waits until playback is stopped, so nothing is pushed to closed channels of replay sources
+func (r *replayChain) Close() error {
+	r.g.Wait()
+	return r.Replay.Close()
+}
+
+func (r *replayChain) play(ctx context.Context) {
+	log.Info().Str("network", r.network).Str("file", r.path).Float64("speed", r.speed).Msg("replaying...")
+
+	err := record.Play(ctx, r.path, r.speed, func(ctx context.Context, entry record.Entry) error {
+		switch entry.Source {
+		case record.SourceMempool:
+			return r.mempool.Push(ctx, entry)
+		case record.SourceTzKT:
+			return r.Replay.Push(ctx, entry)
+		default:
+			return nil
+		}
+	})
+	switch {
+	case err == nil:
+		log.Info().Str("network", r.network).Msg("replay is finished")
+	case errors.Is(err, context.Canceled):
+	default:
+		log.Err(err).Str("network", r.network).Msg("replay")
+	}
+}
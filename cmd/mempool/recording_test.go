@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+)
+
+func TestReplaySources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	recorder, err := newRecorder(record.FileName(dir, "mainnet"), false, record.Head{ChainID: "NetXdQprcVkpaWU", Level: 100, BlockTime: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []struct {
+		entry   record.Entry
+		payload string
+	}{
+		{
+			entry:   record.Entry{Source: record.SourceMempool, Kind: string(receiver.StatusApplied), Node: "http://node1", Time: start},
+			payload: `{"hash":"oo1","branch":"BL1","contents":[{"kind":"transaction","counter":"1"}]}`,
+		}, {
+			entry:   record.Entry{Source: record.SourceMempool, Kind: string(receiver.StatusApplied), Node: "http://node2", Time: start.Add(time.Millisecond)},
+			payload: `{"hash":"oo1","branch":"BL1","contents":[{"kind":"transaction","counter":"1"}]}`,
+		}, {
+			entry:   record.Entry{Source: record.SourceTzKT, Kind: record.KindBlock, Time: start.Add(2 * time.Millisecond)},
+			payload: `{"hash":"BL2","level":101,"type":1}`,
+		}, {
+			entry:   record.Entry{Source: record.SourceMempool, Kind: string(receiver.StatusRefused), Node: "http://node2", Time: start.Add(3 * time.Millisecond)},
+			payload: `{"hash":"oo1","branch":"BL1","contents":[{"kind":"transaction","counter":"1"}],"error":[]}`,
+		},
+	}
+	for _, e := range entries {
+		if err := recorder.Record(e.entry, []byte(e.payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := record.ReadHead(record.FileName(dir, "mainnet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.ChainID != "NetXdQprcVkpaWU" || head.BlockTime != 8 {
+		t.Errorf("unexpected head: %+v", head)
+	}
+
+	mempool, chain := newReplaySources("mainnet", config.Replay{Dir: dir, Instant: true}, head.BlockTime)
+	if err := chain.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	chain.(*replayChain).g.Wait()
+
+	applied := <-mempool.Operations()
+	if applied.Status != receiver.StatusApplied || applied.Node != "http://node1" || !applied.ReceivedAt.Equal(start) {
+		t.Errorf("unexpected applied message: %+v", applied)
+	}
+	body, ok := applied.Body.(node.Applied)
+	if !ok || body.Hash != "oo1" || len(body.Contents) != 1 || len(body.Contents[0].Body) == 0 {
+		t.Errorf("applied operation is not decoded: %+v", applied.Body)
+	}
+
+	refused := <-mempool.Operations()
+	if refused.Status != receiver.StatusRefused {
+		t.Errorf("duplicate of applied operation is not dropped: got %s", refused.Status)
+	}
+	if _, ok := refused.Body.(node.FailedMonitor); !ok {
+		t.Errorf("refused operation is not decoded: %T", refused.Body)
+	}
+
+	block := <-chain.Blocks()
+	if block.Hash != "BL2" || block.Level != 101 {
+		t.Errorf("unexpected block: %+v", block)
+	}
+
+	if err := chain.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mempool.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
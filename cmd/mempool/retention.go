@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+)
+
+// default retention settings
+const (
+	defaultRetentionInterval  = time.Minute
+	defaultRetentionBatchSize = 10000
+)
+
+// retention - periodically wipes operations, status history, gas statistics, simulations and MEV suspects which are out of the storage period.
+// Rows are deleted by chunks, so the cleanup doesn't hold long locks and doesn't block indexing.
+func (indexer *Indexer) retention(ctx context.Context) {
+	ticker := time.NewTicker(indexer.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := indexer.purge(ctx); err != nil {
+				indexer.error(err).Msg("retention")
+			}
+		}
+	}
+}
+
+// purge - deletes all old rows chunk by chunk. Operations are kept forever in archive mode.
+func (indexer *Indexer) purge(ctx context.Context) error {
+	if !indexer.archive {
+		if err := indexer.purgeOperations(ctx); err != nil {
+			return err
+		}
+	}
+
+	if indexer.hasManager {
+		if err := indexer.purgeChunks(ctx, "gas_stats", func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldGasStats(ctx, indexer.gasStatsLifetime, limit)
+		}); err != nil {
+			return errors.Wrap(err, "DeleteOldGasStats")
+		}
+	}
+
+	if indexer.mev != nil && !indexer.archive {
+		if err := indexer.purgeChunks(ctx, "mev_suspects", func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldMevSuspects(ctx, indexer.keepOperations, limit)
+		}); err != nil {
+			return errors.Wrap(err, "DeleteOldMevSuspects")
+		}
+	}
+
+	if !indexer.archive {
+		if err := indexer.purgeChunks(ctx, "status_history", func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldStatusHistory(ctx, indexer.keepOperations, limit)
+		}); err != nil {
+			return errors.Wrap(err, "DeleteOldStatusHistory")
+		}
+	}
+
+	if indexer.simulator != nil {
+		if err := indexer.purgeChunks(ctx, "simulations", func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldSimulations(ctx, indexer.keepOperations, limit)
+		}); err != nil {
+			return errors.Wrap(err, "DeleteOldSimulations")
+		}
+	}
+	return nil
+}
+
+func (indexer *Indexer) purgeOperations(ctx context.Context) error {
+	for _, kind := range indexer.filters.Kinds {
+		if err := indexer.purgeChunks(ctx, kind, func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldOperations(ctx, indexer.keepInChain, models.StatusInChain, limit, kind)
+		}); err != nil {
+			return errors.Wrapf(err, "DeleteOldOperations in_chain %s", kind)
+		}
+		if err := indexer.purgeChunks(ctx, kind, func(ctx context.Context, limit int) (int, error) {
+			return indexer.db.DeleteOldOperations(ctx, indexer.keepOperations, "", limit, kind)
+		}); err != nil {
+			return errors.Wrapf(err, "DeleteOldOperations %s", kind)
+		}
+	}
+	return nil
+}
+
+// purgeChunks - calls `deleteChunk` until it deletes less rows than the batch size
+func (indexer *Indexer) purgeChunks(ctx context.Context, table string, deleteChunk func(ctx context.Context, limit int) (int, error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		deleted, err := deleteChunk(ctx, indexer.retentionBatchSize)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 && indexer.prom != nil {
+			if counter := indexer.prom.Counter(retentionPurgedRowsCountName); counter != nil {
+				counter.With(map[string]string{
+					"table":   table,
+					"network": indexer.network,
+				}).Add(float64(deleted))
+			}
+		}
+		if deleted < indexer.retentionBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/storage"
+)
+
+func TestIndexer_Purge(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		batchSize int
+	}{
+		{name: "one chunk", batchSize: 100},
+		{name: "several chunks", batchSize: 2},
+		{name: "chunk is equal to rows count", batchSize: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := storage.NewMemory()
+			indexer := newTestWorker(db, 10).Indexer
+			indexer.keepInChain = 60
+			indexer.keepOperations = 3600
+			indexer.retentionBatchSize = tt.batchSize
+
+			now := time.Now().Unix()
+			for i := 0; i < 7; i++ {
+				tx := &models.Transaction{
+					MempoolOperation: models.MempoolOperation{
+						Network:   "mainnet",
+						Hash:      fmt.Sprintf("oo%d", i),
+						Kind:      node.KindTransaction,
+						Status:    models.StatusInChain,
+						UpdatedAt: now - 120,
+					},
+				}
+				if i >= 5 {
+					// applied operations are kept for longer period
+					tx.Status = models.StatusApplied
+				}
+				if _, err := db.SaveOperation(ctx, tx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := indexer.purge(ctx); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := db.Operations("mainnet", node.KindTransaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 2 {
+				t.Fatalf("stored operations = %d, want 2", len(stored))
+			}
+			for i := range stored {
+				if status := stored[i].(*models.Transaction).Status; status != models.StatusApplied {
+					t.Errorf("status of the rest operation = %s, want %s", status, models.StatusApplied)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexer_PurgeStatusHistory(t *testing.T) {
+	ctx := context.Background()
+	db := storage.NewMemory()
+	indexer := newTestWorker(db, 10).Indexer
+	indexer.keepInChain = 60
+	indexer.keepOperations = 3600
+	indexer.retentionBatchSize = 2
+
+	now := time.Now().UTC()
+	history := make([]models.StatusHistory, 0)
+	for i := 0; i < 5; i++ {
+		history = append(history, models.StatusHistory{
+			Network:   "mainnet",
+			Hash:      fmt.Sprintf("oo%d", i),
+			Status:    models.StatusApplied,
+			Timestamp: now.Add(-2 * time.Hour),
+		})
+	}
+	history = append(history, models.StatusHistory{
+		Network:   "mainnet",
+		Hash:      "oo5",
+		Status:    models.StatusApplied,
+		Timestamp: now,
+	})
+	if err := db.SaveStatusHistory(ctx, history...); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := indexer.purge(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rest := db.StatusHistory("mainnet")
+	if len(rest) != 1 || rest[0].Hash != "oo5" {
+		t.Errorf("rest of history = %v, want the row of oo5 only", rest)
+	}
+}
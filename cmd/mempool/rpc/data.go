@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"strconv"
+
+	"github.com/dipdup-net/go-lib/tzkt/data"
+)
+
+// operationGroup - operation of the block as it's returned by `/chains/main/blocks/<block>/operations`
+type operationGroup struct {
+	Hash     string    `json:"hash"`
+	Contents []content `json:"contents"`
+}
+
+type content struct {
+	Kind        string           `json:"kind"`
+	Source      string           `json:"source"`
+	Destination string           `json:"destination"`
+	Delegate    string           `json:"delegate"`
+	Fee         string           `json:"fee"`
+	Parameters  *data.Parameters `json:"parameters"`
+	Metadata    contentMetadata  `json:"metadata"`
+}
+
+type contentMetadata struct {
+	Delegate                 string           `json:"delegate"`
+	OperationResult          *operationResult `json:"operation_result"`
+	InternalOperationResults []internalResult `json:"internal_operation_results"`
+}
+
+type internalResult struct {
+	Result operationResult `json:"result"`
+}
+
+type operationResult struct {
+	ConsumedMilligas string `json:"consumed_milligas"`
+}
+
+// accounts - returns addresses which the content is related to
+func (c content) accounts() []string {
+	return []string{c.Source, c.Destination, c.Delegate, c.Metadata.Delegate}
+}
+
+// milligas - returns gas consumed by the content and its internal operations
+func (c content) milligas() uint64 {
+	var milligas uint64
+	if c.Metadata.OperationResult != nil {
+		milligas += parseUint(c.Metadata.OperationResult.ConsumedMilligas)
+	}
+	for i := range c.Metadata.InternalOperationResults {
+		milligas += parseUint(c.Metadata.InternalOperationResults[i].Result.ConsumedMilligas)
+	}
+	return milligas
+}
+
+func parseUint(value string) uint64 {
+	if value == "" {
+		return 0
+	}
+	result, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return result
+}
+
+// rights - consensus rights of the level as they're returned by `/helpers/attestation_rights` and `/helpers/endorsing_rights`
+type rights struct {
+	Level     uint64           `json:"level"`
+	Delegates []delegateRights `json:"delegates"`
+}
+
+type delegateRights struct {
+	Delegate         string `json:"delegate"`
+	AttestationPower uint64 `json:"attestation_power"`
+	EndorsingPower   uint64 `json:"endorsing_power"`
+}
+
+func (r delegateRights) power() uint64 {
+	if r.AttestationPower > 0 {
+		return r.AttestationPower
+	}
+	return r.EndorsingPower
+}
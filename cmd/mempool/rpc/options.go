@@ -0,0 +1,13 @@
+package rpc
+
+import "github.com/dipdup-net/mempool/cmd/mempool/record"
+
+// ChainOption -
+type ChainOption func(*Chain)
+
+// WithRecorder - writes every block and operation message to the recording
+func WithRecorder(recorder *record.Recorder) ChainOption {
+	return func(c *Chain) {
+		c.recorder = recorder
+	}
+}
@@ -0,0 +1,492 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	// historySize - count of last block hashes which are kept to find the common ancestor on reorg
+	historySize = 128
+
+	reconnectDelay = 5 * time.Second
+)
+
+// Chain - chain source which receives blocks from the node head monitor and requests their operations, rights and delegates from the node RPC.
+// It's used instead of TzKT when the indexer has to depend on the node only.
+type Chain struct {
+	url      string
+	rpc      *node.RPC
+	client   *http.Client
+	monitor  *http.Client
+	kinds    map[string]struct{}
+	accounts map[string]struct{}
+	recorder *record.Recorder
+
+	state     uint64
+	connected uint64
+	hashes    map[uint64]string
+	mx        sync.Mutex
+
+	operations chan tzkt.OperationMessage
+	blocks     chan tzkt.BlockMessage
+	g          workerpool.Group
+}
+
+// NewChain - Chain constructor
+func NewChain(url string, accounts []string, kinds []string, opts ...ChainOption) *Chain {
+	url = strings.TrimSuffix(url, "/")
+	c := &Chain{
+		url:        url,
+		rpc:        node.NewMainRPC(url),
+		client:     &http.Client{Timeout: time.Minute},
+		monitor:    &http.Client{},
+		kinds:      make(map[string]struct{}),
+		accounts:   make(map[string]struct{}),
+		hashes:     make(map[uint64]string),
+		operations: make(chan tzkt.OperationMessage, 1024),
+		blocks:     make(chan tzkt.BlockMessage, 1024),
+		g:          workerpool.NewGroup(),
+	}
+	for i := range kinds {
+		c.kinds[kinds[i]] = struct{}{}
+	}
+	for i := range accounts {
+		c.accounts[accounts[i]] = struct{}{}
+	}
+	for i := range opts {
+		opts[i](c)
+	}
+	return c
+}
+
+// Connect - requests the head of the node, sends it as the state message and starts the head monitor
+func (c *Chain) Connect(ctx context.Context) error {
+	head, err := c.rpc.Header(ctx, "head")
+	if err != nil {
+		return errors.Wrap(err, "get node head")
+	}
+
+	c.mx.Lock()
+	c.state = head.Level
+	c.connected = head.Level
+	c.hashes[head.Level] = head.Hash
+	c.mx.Unlock()
+
+	if err := c.sendBlock(ctx, tzkt.BlockMessage{
+		Type:      events.MessageTypeState,
+		Hash:      head.Hash,
+		Level:     head.Level,
+		Timestamp: head.Timestamp.UTC(),
+	}); err != nil {
+		return err
+	}
+
+	c.g.GoCtx(ctx, c.listen)
+	return nil
+}
+
+// Subscribe - head monitor receives all blocks, so there is nothing to subscribe to
+func (c *Chain) Subscribe() error {
+	return nil
+}
+
+// Close -
+func (c *Chain) Close() error {
+	c.g.Wait()
+
+	close(c.operations)
+	close(c.blocks)
+	return nil
+}
+
+// Operations -
+func (c *Chain) Operations() <-chan tzkt.OperationMessage {
+	return c.operations
+}
+
+// Blocks -
+func (c *Chain) Blocks() <-chan tzkt.BlockMessage {
+	return c.blocks
+}
+
+func (c *Chain) listen(ctx context.Context) {
+	for {
+		if err := c.monitorHeads(ctx); err != nil {
+			log.Err(err).Str("node", c.url).Msg("monitor heads")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (c *Chain) monitorHeads(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/monitor/heads/main", c.url), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.monitor.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("monitor heads: %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var head node.Header
+		if err := decoder.Decode(&head); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := c.handleHead(ctx, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleHead - sends the head and all blocks which are missed before it. If the head isn't a descendant of the last sent block,
+// blocks are requested by predecessor hash until the common ancestor and reorg message to its level is sent before them.
+func (c *Chain) handleHead(ctx context.Context, head node.Header) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if hash, ok := c.hashes[head.Level]; ok && hash == head.Hash {
+		return nil
+	}
+
+	pending := []node.Header{head}
+	for current := head; current.Level > 1; {
+		parent := current.Level - 1
+		if parent <= c.state {
+			if hash, ok := c.hashes[parent]; !ok || hash == current.Predecessor {
+				break
+			}
+		}
+		header, err := c.rpc.Header(ctx, current.Predecessor)
+		if err != nil {
+			return errors.Wrapf(err, "get header %s", current.Predecessor)
+		}
+		pending = append(pending, header)
+		current = header
+	}
+
+	if ancestor := pending[len(pending)-1].Level - 1; ancestor < c.state {
+		log.Warn().Str("node", c.url).Uint64("from", c.state).Uint64("to", ancestor).Msg("chain reorganization")
+		if err := c.sendBlock(ctx, tzkt.BlockMessage{
+			Type:  events.MessageTypeReorg,
+			Hash:  c.hashes[ancestor],
+			Level: ancestor,
+		}); err != nil {
+			return err
+		}
+		for level := range c.hashes {
+			if level > ancestor {
+				delete(c.hashes, level)
+			}
+		}
+		c.state = ancestor
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		if err := c.processBlock(ctx, pending[i]); err != nil {
+			return err
+		}
+		c.state = pending[i].Level
+	}
+	return nil
+}
+
+// processBlock - sends the block and its operations matching filters
+func (c *Chain) processBlock(ctx context.Context, header node.Header) error {
+	msg, err := c.blockOperations(ctx, header)
+	if err != nil {
+		return err
+	}
+
+	if err := c.sendBlock(ctx, tzkt.BlockMessage{
+		Type:        events.MessageTypeData,
+		Hash:        header.Hash,
+		Predecessor: header.Predecessor,
+		Level:       header.Level,
+		Timestamp:   header.Timestamp.UTC(),
+	}); err != nil {
+		return err
+	}
+
+	empty := true
+	msg.Hash.Range(func(_, _ any) bool {
+		empty = false
+		return false
+	})
+	if !empty {
+		tzkt.Record(c.recorder, record.SourceNodeChain, msg)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case c.operations <- msg:
+		}
+	}
+
+	c.hashes[header.Level] = header.Hash
+	if header.Level > historySize {
+		delete(c.hashes, header.Level-historySize)
+	}
+	return nil
+}
+
+func (c *Chain) sendBlock(ctx context.Context, block tzkt.BlockMessage) error {
+	tzkt.Record(c.recorder, record.SourceNodeChain, block)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case c.blocks <- block:
+		return nil
+	}
+}
+
+// blockOperations - requests operations of the block and converts the ones matching filters to the TzKT format.
+// Gas and fees of all contents of the operation group are summed up as TzKT source does.
+func (c *Chain) blockOperations(ctx context.Context, header node.Header) (tzkt.OperationMessage, error) {
+	msg := tzkt.OperationMessage{
+		Level:     header.Level,
+		Block:     header.Hash,
+		Timestamp: header.Timestamp.UTC(),
+		Hash:      new(sync.Map),
+	}
+
+	var passes [][]operationGroup
+	if err := c.get(ctx, fmt.Sprintf("chains/main/blocks/%s/operations", header.Hash), &passes); err != nil {
+		return msg, errors.Wrapf(err, "get operations of %s", header.Hash)
+	}
+
+	var id uint64
+	for _, groups := range passes {
+		for _, group := range groups {
+			operation, ok := c.operation(group)
+			if !ok {
+				continue
+			}
+			id++
+			operation.ID = id
+			operation.Level = header.Level
+			operation.Block = header.Hash
+			msg.Hash.Store(operation.Hash, operation)
+		}
+	}
+	return msg, nil
+}
+
+func (c *Chain) operation(group operationGroup) (data.Operation, bool) {
+	var (
+		operation data.Operation
+		matched   bool
+		milligas  uint64
+		fee       uint64
+	)
+	for _, content := range group.Contents {
+		milligas += content.milligas()
+		fee += parseUint(content.Fee)
+
+		if matched || !c.match(content) {
+			continue
+		}
+		matched = true
+		operation.Hash = group.Hash
+		operation.Type = content.Kind
+		operation.Parameters = content.Parameters
+	}
+	if !matched {
+		return operation, false
+	}
+	if milligas > 0 {
+		gasUsed := (milligas + 999) / 1000
+		operation.GasUsed = &gasUsed
+	}
+	if fee > 0 {
+		operation.BakerFee = &fee
+	}
+	return operation, true
+}
+
+func (c *Chain) match(content content) bool {
+	if _, ok := c.kinds[content.Kind]; !ok {
+		return false
+	}
+	if len(c.accounts) == 0 {
+		return true
+	}
+	for _, address := range content.accounts() {
+		if _, ok := c.accounts[address]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync - sends blocks after `indexerLevel` up to the head of the node at the moment of connection. Later blocks are sent by the head monitor.
+func (c *Chain) Sync(ctx context.Context, indexerLevel uint64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	log.Info().Msgf("current node level is %d. Current mempool indexer level is %d", c.connected, indexerLevel)
+	if indexerLevel == 0 {
+		return
+	}
+
+	for level := indexerLevel + 1; level <= c.connected; level++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		header, err := c.rpc.Header(ctx, strconv.FormatUint(level, 10))
+		if err != nil {
+			log.Err(err).Msg("rpc.Sync")
+			return
+		}
+		if err := c.processBlock(ctx, header); err != nil {
+			log.Err(err).Msg("rpc.Sync")
+			return
+		}
+	}
+	log.Info().Msg("synced")
+}
+
+// GetBlocks - returns `limit` blocks not higher than `state` in descending order
+func (c *Chain) GetBlocks(ctx context.Context, limit, state uint64) ([]tzkt.BlockMessage, error) {
+	blocks := make([]tzkt.BlockMessage, 0, limit)
+	for level := state; level > 0 && uint64(len(blocks)) < limit; level-- {
+		header, err := c.rpc.Header(ctx, strconv.FormatUint(level, 10))
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, tzkt.BlockMessage{
+			Type:        events.MessageTypeData,
+			Hash:        header.Hash,
+			Predecessor: header.Predecessor,
+			Level:       header.Level,
+			Timestamp:   header.Timestamp.UTC(),
+		})
+	}
+	return blocks, nil
+}
+
+// GetBlock - returns the block by hash
+func (c *Chain) GetBlock(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error) {
+	header, err := c.rpc.Header(ctx, hash)
+	if err != nil {
+		return tzkt.BlockMessage{}, err
+	}
+	return tzkt.BlockMessage{
+		Type:        events.MessageTypeData,
+		Hash:        header.Hash,
+		Predecessor: header.Predecessor,
+		Level:       header.Level,
+		Timestamp:   header.Timestamp.UTC(),
+	}, nil
+}
+
+// Delegates - returns active delegates with revealed keys. Delegates are sorted by address to make pagination stable.
+func (c *Chain) Delegates(ctx context.Context, limit, offset int64) ([]data.Delegate, error) {
+	var addresses []string
+	if err := c.get(ctx, "chains/main/blocks/head/context/delegates?active=true", &addresses); err != nil {
+		return nil, errors.Wrap(err, "get delegates")
+	}
+	sort.Strings(addresses)
+
+	if offset >= int64(len(addresses)) {
+		return nil, nil
+	}
+	addresses = addresses[offset:]
+	if limit < int64(len(addresses)) {
+		addresses = addresses[:limit]
+	}
+
+	delegates := make([]data.Delegate, 0, len(addresses))
+	for i := range addresses {
+		var publicKey *string
+		if err := c.get(ctx, fmt.Sprintf("chains/main/blocks/head/context/contracts/%s/manager_key", addresses[i]), &publicKey); err != nil {
+			return nil, errors.Wrapf(err, "get key of %s", addresses[i])
+		}
+		if publicKey == nil {
+			continue
+		}
+		delegates = append(delegates, data.Delegate{
+			Address:   addresses[i],
+			PublicKey: *publicKey,
+		})
+	}
+	return delegates, nil
+}
+
+// Rights - returns attestation rights of the level. Endorsing rights are requested from nodes of protocols before attestations.
+func (c *Chain) Rights(ctx context.Context, level uint64) ([]data.Right, error) {
+	var response []rights
+	if err := c.get(ctx, fmt.Sprintf("chains/main/blocks/head/helpers/attestation_rights?level=%d", level), &response); err != nil {
+		if err := c.get(ctx, fmt.Sprintf("chains/main/blocks/head/helpers/endorsing_rights?level=%d", level), &response); err != nil {
+			return nil, errors.Wrapf(err, "get rights of %d", level)
+		}
+	}
+
+	result := make([]data.Right, 0)
+	for i := range response {
+		for _, delegate := range response[i].Delegates {
+			result = append(result, data.Right{
+				Type:  "endorsing",
+				Level: response[i].Level,
+				Slots: delegate.power(),
+				Baker: data.Address{Address: delegate.Delegate},
+			})
+		}
+	}
+	return result, nil
+}
+
+func (c *Chain) get(ctx context.Context, uri string, response any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", c.url, uri), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,296 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+)
+
+// fakeNode - serves headers, operations, rights and delegate keys from its fields
+type fakeNode struct {
+	headers    map[string]node.Header
+	head       string
+	operations map[string]string
+	rights     string
+	delegates  map[string]string
+}
+
+func newFakeNode(t *testing.T, n *fakeNode) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/chains/main/blocks/")
+		switch {
+		case strings.HasSuffix(path, "/header"):
+			id := strings.TrimSuffix(path, "/header")
+			if id == "head" {
+				id = n.head
+			}
+			for _, header := range n.headers {
+				if header.Hash == id || fmt.Sprintf("%d", header.Level) == id {
+					_ = json.NewEncoder(w).Encode(header)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case strings.HasSuffix(path, "/operations"):
+			body, ok := n.operations[strings.TrimSuffix(path, "/operations")]
+			if !ok {
+				body = "[[],[],[],[]]"
+			}
+			fmt.Fprint(w, body)
+		case strings.HasSuffix(path, "/helpers/attestation_rights"):
+			http.Error(w, "not found", http.StatusNotFound)
+		case strings.HasSuffix(path, "/helpers/endorsing_rights"):
+			fmt.Fprint(w, n.rights)
+		case strings.HasSuffix(path, "/context/delegates"):
+			addresses := make([]string, 0, len(n.delegates))
+			for address := range n.delegates {
+				addresses = append(addresses, address)
+			}
+			_ = json.NewEncoder(w).Encode(addresses)
+		case strings.HasSuffix(path, "/manager_key"):
+			address := strings.TrimSuffix(strings.TrimPrefix(path, "head/context/contracts/"), "/manager_key")
+			if key := n.delegates[address]; key != "" {
+				_ = json.NewEncoder(w).Encode(key)
+			} else {
+				fmt.Fprint(w, "null")
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newHeader(level uint64, hash, predecessor string) node.Header {
+	return node.Header{
+		Level:       level,
+		Hash:        hash,
+		Predecessor: predecessor,
+		Timestamp:   time.Date(2024, 1, 1, 0, 0, int(level), 0, time.UTC),
+	}
+}
+
+func TestChain_handleHead(t *testing.T) {
+	n := &fakeNode{
+		head: "BL100",
+		headers: map[string]node.Header{
+			"BL99":   newHeader(99, "BL99", "BL98"),
+			"BL100":  newHeader(100, "BL100", "BL99"),
+			"BL101":  newHeader(101, "BL101", "BL100"),
+			"BL102":  newHeader(102, "BL102", "BL101"),
+			"BL101b": newHeader(101, "BL101b", "BL100"),
+			"BL102b": newHeader(102, "BL102b", "BL101b"),
+			"BL103b": newHeader(103, "BL103b", "BL102b"),
+		},
+	}
+	server := newFakeNode(t, n)
+
+	ctx := context.Background()
+	chain := NewChain(server.URL, nil, []string{node.KindTransaction})
+
+	head, err := chain.rpc.Header(ctx, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.state = head.Level
+	chain.hashes[head.Level] = head.Hash
+
+	type block struct {
+		typ   events.MessageType
+		level uint64
+		hash  string
+	}
+	tests := []struct {
+		name string
+		head string
+		want []block
+	}{
+		{
+			name: "missed block",
+			head: "BL102",
+			want: []block{
+				{events.MessageTypeData, 101, "BL101"},
+				{events.MessageTypeData, 102, "BL102"},
+			},
+		}, {
+			name: "same head",
+			head: "BL102",
+		}, {
+			name: "reorg",
+			head: "BL103b",
+			want: []block{
+				{events.MessageTypeReorg, 100, "BL100"},
+				{events.MessageTypeData, 101, "BL101b"},
+				{events.MessageTypeData, 102, "BL102b"},
+				{events.MessageTypeData, 103, "BL103b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := chain.handleHead(ctx, n.headers[tt.head]); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]block, 0)
+			for len(chain.blocks) > 0 {
+				msg := <-chain.blocks
+				got = append(got, block{msg.Type, msg.Level, msg.Hash})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("blocks = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("block %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+			if want := n.headers[tt.head].Level; chain.state != want {
+				t.Errorf("state = %d, want %d", chain.state, want)
+			}
+		})
+	}
+}
+
+func TestChain_blockOperations(t *testing.T) {
+	n := &fakeNode{
+		operations: map[string]string{
+			"BL1": `[
+				[{"hash":"ooEndorsement","contents":[{"kind":"endorsement","level":1,"metadata":{"delegate":"tz1baker"}}]}],
+				[],
+				[],
+				[
+					{"hash":"ooBatch","contents":[
+						{"kind":"reveal","source":"tz1sender","fee":"100","metadata":{"operation_result":{"consumed_milligas":"1000000"}}},
+						{"kind":"transaction","source":"tz1sender","destination":"KT1target","fee":"500","parameters":{"entrypoint":"mint","value":{"int":"1"}},
+						 "metadata":{"operation_result":{"consumed_milligas":"2500500"},"internal_operation_results":[{"result":{"consumed_milligas":"1000"}}]}}
+					]},
+					{"hash":"ooOther","contents":[{"kind":"transaction","source":"tz1other","destination":"tz1someone","fee":"300"}]}
+				]
+			]`,
+		},
+	}
+	server := newFakeNode(t, n)
+
+	tests := []struct {
+		name     string
+		kinds    []string
+		accounts []string
+		want     map[string]data.Operation
+	}{
+		{
+			name:  "by kinds",
+			kinds: []string{node.KindTransaction, node.KindEndorsement},
+			want: map[string]data.Operation{
+				"ooEndorsement": {Type: node.KindEndorsement},
+				"ooBatch":       {Type: node.KindTransaction, GasUsed: uint64Ptr(3502), BakerFee: uint64Ptr(600)},
+				"ooOther":       {Type: node.KindTransaction, BakerFee: uint64Ptr(300)},
+			},
+		}, {
+			name:     "by accounts",
+			kinds:    []string{node.KindTransaction, node.KindEndorsement},
+			accounts: []string{"KT1target", "tz1baker"},
+			want: map[string]data.Operation{
+				"ooEndorsement": {Type: node.KindEndorsement},
+				"ooBatch":       {Type: node.KindTransaction, GasUsed: uint64Ptr(3502), BakerFee: uint64Ptr(600)},
+			},
+		}, {
+			name:  "reveal is the first matched content",
+			kinds: []string{node.KindReveal},
+			want: map[string]data.Operation{
+				"ooBatch": {Type: node.KindReveal, GasUsed: uint64Ptr(3502), BakerFee: uint64Ptr(600)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := NewChain(server.URL, tt.accounts, tt.kinds)
+			msg, err := chain.blockOperations(context.Background(), newHeader(1, "BL1", "BL0"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]data.Operation)
+			msg.Hash.Range(func(key, value any) bool {
+				got[key.(string)] = value.(data.Operation)
+				return true
+			})
+			if len(got) != len(tt.want) {
+				t.Fatalf("operations = %v, want %d", got, len(tt.want))
+			}
+			for hash, want := range tt.want {
+				operation, ok := got[hash]
+				if !ok {
+					t.Fatalf("operation %s is lost", hash)
+				}
+				if operation.Type != want.Type || operation.Level != 1 || operation.Block != "BL1" {
+					t.Errorf("%s: type = %s, level = %d, block = %s", hash, operation.Type, operation.Level, operation.Block)
+				}
+				if !equalPtr(operation.GasUsed, want.GasUsed) || !equalPtr(operation.BakerFee, want.BakerFee) {
+					t.Errorf("%s: gas = %v, fee = %v", hash, operation.GasUsed, operation.BakerFee)
+				}
+			}
+			if batch, ok := got["ooBatch"]; ok && batch.Type == node.KindTransaction && (batch.Parameters == nil || batch.Parameters.Entrypoint != "mint") {
+				t.Errorf("parameters are lost: %v", batch.Parameters)
+			}
+		})
+	}
+}
+
+func TestChain_RightsAndDelegates(t *testing.T) {
+	n := &fakeNode{
+		rights: `[{"level":10,"delegates":[{"delegate":"tz1a","first_slot":0,"endorsing_power":5},{"delegate":"tz1b","first_slot":5,"endorsing_power":2}]}]`,
+		delegates: map[string]string{
+			"tz1a": "edpkuEhzJqdFBCWMw6TU3deADRK2fq3GuwWFUphwyH7ero1Na4oGFP",
+			"tz1b": "",
+			"tz1c": "sppk7bMuoa8w2LSKz3XEuPsKx1WavsMLCWgbWG9CZNAsJg9eTmkXRPd",
+		},
+	}
+	server := newFakeNode(t, n)
+	chain := NewChain(server.URL, nil, []string{node.KindEndorsement})
+	ctx := context.Background()
+
+	rights, err := chain.Rights(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rights) != 2 || rights[0].Baker.Address != "tz1a" || rights[0].Slots != 5 || rights[1].Slots != 2 {
+		t.Errorf("unexpected rights: %+v", rights)
+	}
+
+	first, err := chain.Delegates(ctx, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := chain.Delegates(ctx, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delegates := append(first, second...)
+	if len(delegates) != 2 || delegates[0].Address != "tz1a" || delegates[1].Address != "tz1c" {
+		t.Errorf("delegates without revealed key aren't skipped: %+v", delegates)
+	}
+	if delegates[0].PublicKey != n.delegates["tz1a"] {
+		t.Errorf("public key = %s", delegates[0].PublicKey)
+	}
+}
+
+func uint64Ptr(value uint64) *uint64 {
+	return &value
+}
+
+func equalPtr(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	stdJSON "encoding/json"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/simulator"
+)
+
+const (
+	defaultSimulationWorkers = 2
+	simulationQueueSize      = 1024
+)
+
+// enqueueSimulation - queues the applied operation for simulation if it contains content of watched accounts. Only groups of manager operations
+// can be simulated. The operation is skipped if the queue is full, so slow simulations don't stall indexing.
+func (w *worker) enqueueSimulation(operation node.Applied) {
+	var watched bool
+	contents := make([]stdJSON.RawMessage, len(operation.Contents))
+	for i := range operation.Contents {
+		if !node.IsManager(operation.Contents[i].Kind) {
+			return
+		}
+		contents[i] = operation.Contents[i].Body
+
+		if watched {
+			continue
+		}
+		ok, err := w.accounts.Watches(operation.Contents[i].Kind, operation.Contents[i].Body)
+		if err != nil {
+			w.warn().Err(err).Str("hash", operation.Hash).Msg("check watched accounts")
+			return
+		}
+		watched = ok
+	}
+	if !watched {
+		return
+	}
+
+	select {
+	case w.simulations <- simulator.Operation{
+		Hash:      operation.Hash,
+		Branch:    operation.Branch,
+		Signature: operation.Signature,
+		Contents:  contents,
+	}:
+	default:
+		w.warn().Str("hash", operation.Hash).Msg("simulation queue is full")
+	}
+}
+
+// simulate - simulates queued operations on the head block and stores predicted results
+func (indexer *Indexer) simulate(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case operation := <-indexer.simulations:
+			simulation, err := indexer.simulator.Simulate(ctx, operation)
+			if err != nil {
+				indexer.warn().Err(err).Str("hash", operation.Hash).Msg("simulate operation")
+				continue
+			}
+			simulation.Network = indexer.network
+			simulation.Level = indexer.level()
+			if err := indexer.db.SaveSimulations(ctx, simulation); err != nil {
+				indexer.error(err).Str("hash", operation.Hash).Msg("save simulation")
+			}
+		}
+	}
+}
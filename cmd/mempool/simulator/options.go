@@ -0,0 +1,15 @@
+package simulator
+
+import "time"
+
+// SimulatorOption -
+type SimulatorOption func(*Simulator)
+
+// WithTimeout - sets timeout of the simulation request
+func WithTimeout(timeout time.Duration) SimulatorOption {
+	return func(s *Simulator) {
+		if timeout > 0 {
+			s.client.Timeout = timeout
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	stdJSON "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const defaultTimeout = 10 * time.Second
+
+// operation result statuses
+const (
+	StatusApplied     = "applied"
+	StatusFailed      = "failed"
+	StatusBacktracked = "backtracked"
+	StatusSkipped     = "skipped"
+)
+
+// Operation - signed operation group received from mempool
+type Operation struct {
+	Hash      string
+	Branch    string
+	Signature string
+	Contents  []stdJSON.RawMessage
+}
+
+// Simulator - predicts results of mempool operations by `run_operation` RPC of the node. The node applies the operation on its head block,
+// so balance updates, storage diffs and consumed gas are the ones the operation would have if it was included into the next block.
+type Simulator struct {
+	url     string
+	chainID string
+	client  *http.Client
+}
+
+// New - creates simulator using the node by the url
+func New(url, chainID string, opts ...SimulatorOption) *Simulator {
+	s := &Simulator{
+		url:     strings.TrimSuffix(url, "/"),
+		chainID: chainID,
+		client:  &http.Client{Timeout: defaultTimeout},
+	}
+	for i := range opts {
+		opts[i](s)
+	}
+	return s
+}
+
+type request struct {
+	Operation requestOperation `json:"operation"`
+	ChainID   string           `json:"chain_id"`
+}
+
+type requestOperation struct {
+	Branch    string               `json:"branch"`
+	Contents  []stdJSON.RawMessage `json:"contents"`
+	Signature string               `json:"signature"`
+}
+
+// Simulate - returns predicted results of the operation. Network and level of the result aren't set.
+// Operations rejected by the node are returned as failed simulations with errors of the node.
+func (s *Simulator) Simulate(ctx context.Context, operation Operation) (models.Simulation, error) {
+	simulation := models.Simulation{
+		Hash: operation.Hash,
+	}
+
+	body, err := json.Marshal(request{
+		Operation: requestOperation{
+			Branch:    operation.Branch,
+			Contents:  operation.Contents,
+			Signature: operation.Signature,
+		},
+		ChainID: s.chainID,
+	})
+	if err != nil {
+		return simulation, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/chains/main/blocks/head/helpers/scripts/run_operation", s.url), bytes.NewReader(body))
+	if err != nil {
+		return simulation, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return simulation, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return simulation, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the node returns list of errors if the operation can't be applied, e.g. balance is too low or counter is in the past
+		var errs []stdJSON.RawMessage
+		if err := json.Unmarshal(data, &errs); err != nil || len(errs) == 0 {
+			return simulation, errors.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+		simulation.Status = StatusFailed
+		simulation.Errors = models.JSONB(data)
+		return simulation, nil
+	}
+
+	var result response
+	if err := json.Unmarshal(data, &result); err != nil {
+		return simulation, errors.Wrap(err, "parse simulation")
+	}
+	return result.simulation(simulation)
+}
+
+type response struct {
+	Contents []content `json:"contents"`
+}
+
+type content struct {
+	Destination string `json:"destination"`
+	Metadata    struct {
+		BalanceUpdates           []stdJSON.RawMessage `json:"balance_updates"`
+		OperationResult          result               `json:"operation_result"`
+		InternalOperationResults []stdJSON.RawMessage `json:"internal_operation_results"`
+	} `json:"metadata"`
+}
+
+type internalOperation struct {
+	Destination string `json:"destination"`
+	Result      result `json:"result"`
+}
+
+type result struct {
+	Status           string               `json:"status"`
+	ConsumedMilligas string               `json:"consumed_milligas"`
+	Storage          stdJSON.RawMessage   `json:"storage"`
+	LazyStorageDiff  stdJSON.RawMessage   `json:"lazy_storage_diff"`
+	BalanceUpdates   []stdJSON.RawMessage `json:"balance_updates"`
+	Errors           []stdJSON.RawMessage `json:"errors"`
+}
+
+// storageDiff - predicted storage of the contract
+type storageDiff struct {
+	Destination     string             `json:"destination"`
+	Storage         stdJSON.RawMessage `json:"storage,omitempty"`
+	LazyStorageDiff stdJSON.RawMessage `json:"lazy_storage_diff,omitempty"`
+}
+
+// collector - merges results of the contents and internal operations
+type collector struct {
+	statuses       map[string]bool
+	milligas       uint64
+	balanceUpdates []stdJSON.RawMessage
+	storageDiffs   []storageDiff
+	errors         []stdJSON.RawMessage
+}
+
+func (c *collector) add(destination string, r result) error {
+	c.statuses[r.Status] = true
+	if r.ConsumedMilligas != "" {
+		milligas, err := strconv.ParseUint(r.ConsumedMilligas, 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "consumed_milligas")
+		}
+		c.milligas += milligas
+	}
+	c.balanceUpdates = append(c.balanceUpdates, r.BalanceUpdates...)
+	c.errors = append(c.errors, r.Errors...)
+	if len(r.Storage) > 0 || len(r.LazyStorageDiff) > 0 {
+		c.storageDiffs = append(c.storageDiffs, storageDiff{destination, r.Storage, r.LazyStorageDiff})
+	}
+	return nil
+}
+
+// status - the whole operation has the worst status of its contents
+func (c *collector) status() string {
+	for _, status := range []string{StatusFailed, StatusBacktracked, StatusSkipped} {
+		if c.statuses[status] {
+			return status
+		}
+	}
+	return StatusApplied
+}
+
+func (r response) simulation(simulation models.Simulation) (models.Simulation, error) {
+	c := collector{
+		statuses: make(map[string]bool),
+	}
+	internals := make([]stdJSON.RawMessage, 0)
+
+	for i := range r.Contents {
+		metadata := r.Contents[i].Metadata
+		c.balanceUpdates = append(c.balanceUpdates, metadata.BalanceUpdates...)
+		if err := c.add(r.Contents[i].Destination, metadata.OperationResult); err != nil {
+			return simulation, err
+		}
+
+		for _, raw := range metadata.InternalOperationResults {
+			var internal internalOperation
+			if err := json.Unmarshal(raw, &internal); err != nil {
+				return simulation, errors.Wrap(err, "parse internal operation")
+			}
+			if err := c.add(internal.Destination, internal.Result); err != nil {
+				return simulation, err
+			}
+			internals = append(internals, raw)
+		}
+	}
+
+	simulation.Status = c.status()
+	simulation.ConsumedMilligas = c.milligas
+
+	var err error
+	if simulation.BalanceUpdates, err = marshal(c.balanceUpdates); err != nil {
+		return simulation, err
+	}
+	if simulation.StorageDiff, err = marshal(c.storageDiffs); err != nil {
+		return simulation, err
+	}
+	if simulation.InternalOperations, err = marshal(internals); err != nil {
+		return simulation, err
+	}
+	if simulation.Errors, err = marshal(c.errors); err != nil {
+		return simulation, err
+	}
+	return simulation, nil
+}
+
+// marshal - empty lists are stored as NULL
+func marshal[T any](items []T) (models.JSONB, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	return models.JSONB(data), nil
+}
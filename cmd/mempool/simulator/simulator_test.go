@@ -0,0 +1,137 @@
+package simulator
+
+import (
+	"context"
+	stdJSON "encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSimulator_Simulate(t *testing.T) {
+	transfer := `{"contents":[{"kind":"transaction","destination":"KT1token","metadata":{
+		"balance_updates":[{"kind":"contract","contract":"tz1sender","change":"-1000","origin":"block"}],
+		"operation_result":{"status":"applied","consumed_milligas":"2500100","storage":{"int":"7"},
+			"lazy_storage_diff":[{"kind":"big_map","id":"5","diff":{"action":"update","updates":[]}}]},
+		"internal_operation_results":[{"kind":"transaction","destination":"tz1receiver","result":{"status":"applied","consumed_milligas":"1000000",
+			"balance_updates":[{"kind":"contract","contract":"tz1receiver","change":"500","origin":"block"}]}}]
+	}}]}`
+	backtracked := `{"contents":[
+		{"kind":"reveal","metadata":{"operation_result":{"status":"backtracked","consumed_milligas":"1000000"}}},
+		{"kind":"transaction","destination":"KT1token","metadata":{"operation_result":{"status":"failed","errors":[{"kind":"temporary","id":"proto.script_rejected"}]}}}
+	]}`
+	rejected := `[{"kind":"temporary","id":"proto.counter_in_the_past"}]`
+
+	tests := []struct {
+		name               string
+		code               int
+		response           string
+		wantStatus         string
+		wantMilligas       uint64
+		wantBalanceUpdates int
+		wantStorageDiff    string
+		wantInternal       int
+		wantErrors         bool
+		wantErr            bool
+	}{
+		{
+			name:               "applied with internal operation",
+			code:               http.StatusOK,
+			response:           transfer,
+			wantStatus:         StatusApplied,
+			wantMilligas:       3500100,
+			wantBalanceUpdates: 2,
+			wantStorageDiff:    `[{"destination":"KT1token","storage":{"int":"7"},"lazy_storage_diff":[{"kind":"big_map","id":"5","diff":{"action":"update","updates":[]}}]}]`,
+			wantInternal:       1,
+		}, {
+			name:         "failed content",
+			code:         http.StatusOK,
+			response:     backtracked,
+			wantStatus:   StatusFailed,
+			wantMilligas: 1000000,
+			wantErrors:   true,
+		}, {
+			name:       "rejected by node",
+			code:       http.StatusInternalServerError,
+			response:   rejected,
+			wantStatus: StatusFailed,
+			wantErrors: true,
+		}, {
+			name:     "node error",
+			code:     http.StatusBadGateway,
+			response: "bad gateway",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/chains/main/blocks/head/helpers/scripts/run_operation" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				var req request
+				if err := json.Unmarshal(body, &req); err != nil {
+					t.Error(err)
+				}
+				if req.ChainID != "NetXdQprcVkpaWU" || req.Operation.Branch != "BLbranch" || len(req.Operation.Contents) != 1 {
+					t.Errorf("unexpected request: %s", body)
+				}
+				w.WriteHeader(tt.code)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			simulation, err := New(server.URL, "NetXdQprcVkpaWU").Simulate(context.Background(), Operation{
+				Hash:      "oo1",
+				Branch:    "BLbranch",
+				Signature: "sig",
+				Contents:  []stdJSON.RawMessage{stdJSON.RawMessage(`{"kind":"transaction"}`)},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Simulate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if simulation.Hash != "oo1" {
+				t.Errorf("hash = %s, want oo1", simulation.Hash)
+			}
+			if simulation.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", simulation.Status, tt.wantStatus)
+			}
+			if simulation.ConsumedMilligas != tt.wantMilligas {
+				t.Errorf("consumed milligas = %d, want %d", simulation.ConsumedMilligas, tt.wantMilligas)
+			}
+			if got := count(t, simulation.BalanceUpdates); got != tt.wantBalanceUpdates {
+				t.Errorf("balance updates = %d, want %d", got, tt.wantBalanceUpdates)
+			}
+			if got := count(t, simulation.InternalOperations); got != tt.wantInternal {
+				t.Errorf("internal operations = %d, want %d", got, tt.wantInternal)
+			}
+			if string(simulation.StorageDiff) != tt.wantStorageDiff {
+				t.Errorf("storage diff = %s, want %s", simulation.StorageDiff, tt.wantStorageDiff)
+			}
+			if simulation.Errors.IsNull() == tt.wantErrors {
+				t.Errorf("errors = %s, want errors %v", simulation.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func count(t *testing.T, data []byte) int {
+	t.Helper()
+
+	if len(data) == 0 {
+		return 0
+	}
+	var items []stdJSON.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	return len(items)
+}
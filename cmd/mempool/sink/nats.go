@@ -0,0 +1,205 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	natsDefaultPort = "4222"
+	natsTimeout     = 10 * time.Second
+)
+
+// ErrPayloadTooLarge - message is larger than `max_payload` of the NATS server
+var ErrPayloadTooLarge = errors.New("message payload exceeds max_payload of the nats server")
+
+// natsInfo - fields of the server INFO which the client depends on
+type natsInfo struct {
+	MaxPayload   int  `json:"max_payload"`
+	AuthRequired bool `json:"auth_required"`
+	TLSRequired  bool `json:"tls_required"`
+}
+
+// Nats - minimal client of core NATS text protocol which is enough to publish messages.
+// Every batch is followed by PING so the batch is confirmed by the server's PONG. The PONG means that the server has received
+// the messages, not that any subscriber or stream has stored them: JetStream acknowledgements aren't supported.
+// Authentication and TLS aren't supported either, so servers requiring them are rejected on connect.
+type Nats struct {
+	address string
+
+	conn       net.Conn
+	reader     *bufio.Reader
+	maxPayload int
+	mx         sync.Mutex
+}
+
+// NewNats - creates NATS publisher. Connection is established lazily. URLs with credentials are rejected
+// because the client can't authenticate.
+func NewNats(rawURL string) (*Nats, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "nats url")
+	}
+	if u.Scheme != "nats" {
+		return nil, errors.Errorf("invalid nats url scheme: %s", u.Scheme)
+	}
+	if u.User != nil {
+		return nil, errors.New("credentials in nats url are not supported")
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+	return &Nats{address: address}, nil
+}
+
+// Publish -
+func (n *Nats) Publish(ctx context.Context, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	for i := range messages {
+		if messages[i].Subject == "" || strings.ContainsAny(messages[i].Subject, " \t\r\n") {
+			return errors.Errorf("invalid subject: %q", messages[i].Subject)
+		}
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if err := n.publish(ctx, messages); err != nil {
+		n.close()
+		return err
+	}
+	return nil
+}
+
+func (n *Nats) publish(ctx context.Context, messages []Message) error {
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return errors.Wrap(err, "nats connect")
+		}
+	}
+
+	deadline := time.Now().Add(natsTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := n.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	for i := range messages {
+		if n.maxPayload > 0 && len(messages[i].Data) > n.maxPayload {
+			return errors.Wrapf(ErrPayloadTooLarge, "%s: %d > %d", messages[i].Subject, len(messages[i].Data), n.maxPayload)
+		}
+	}
+
+	writer := bufio.NewWriter(n.conn)
+	for i := range messages {
+		if _, err := fmt.Fprintf(writer, "PUB %s %d\r\n", messages[i].Subject, len(messages[i].Data)); err != nil {
+			return err
+		}
+		if _, err := writer.Write(messages[i].Data); err != nil {
+			return err
+		}
+		if _, err := writer.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := writer.WriteString("PING\r\n"); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return n.waitPong()
+}
+
+func (n *Nats) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(natsTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return errors.Errorf("unexpected greeting: %s", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.UnmarshalFromString(strings.TrimPrefix(line, "INFO "), &info); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "server info")
+	}
+	if info.AuthRequired || info.TLSRequired {
+		conn.Close()
+		return errors.New("nats server requires authentication or TLS which are not supported")
+	}
+
+	if _, err := fmt.Fprint(conn, `CONNECT {"verbose":false,"pedantic":false,"name":"mempool","lang":"go","protocol":0}`+"\r\n"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	n.conn = conn
+	n.reader = reader
+	n.maxPayload = info.MaxPayload
+	return nil
+}
+
+// waitPong - reads server messages until PONG. Answers server PINGs and returns server errors.
+func (n *Nats) waitPong() error {
+	for {
+		line, err := n.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := fmt.Fprint(n.conn, "PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// Close -
+func (n *Nats) Close() error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+	return n.close()
+}
+
+func (n *Nats) close() error {
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	n.reader = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package sink
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/stream"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// SchemaVersion - version of the `Record` schema. It's increased on any incompatible change.
+const SchemaVersion = 1
+
+const (
+	defaultSubjectPrefix = "mempool"
+	// defaultMaxPayload - default `max_payload` of NATS server
+	defaultMaxPayload = 1024 * 1024
+)
+
+// Record - schema of published messages
+type Record struct {
+	Version     int              `json:"version"`
+	Type        stream.EventType `json:"type"`
+	Network     string           `json:"network"`
+	Hash        string           `json:"hash"`
+	Kind        string           `json:"kind,omitempty"`
+	Status      string           `json:"status"`
+	Level       uint64           `json:"level,omitempty"`
+	Source      string           `json:"source,omitempty"`
+	Destination string           `json:"destination,omitempty"`
+	Entrypoint  string           `json:"entrypoint,omitempty"`
+	Node        string           `json:"node,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Operation   any              `json:"operation,omitempty"`
+	// Truncated - the operation is omitted because the message exceeded the maximum payload
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Writer - stores messages in the transaction
+type Writer interface {
+	SaveSinkMessages(ctx context.Context, messages ...models.SinkMessage) error
+}
+
+// Outbox - stores events in the same database transaction as the operations so they will be published at least once.
+// Messages larger than `maxPayload` are stored without the operation, so the broker never rejects them.
+type Outbox struct {
+	prefix     string
+	maxPayload int
+}
+
+// NewOutbox -
+func NewOutbox(subjectPrefix string, maxPayload int) *Outbox {
+	if subjectPrefix == "" {
+		subjectPrefix = defaultSubjectPrefix
+	}
+	if maxPayload <= 0 {
+		maxPayload = defaultMaxPayload
+	}
+	return &Outbox{prefix: subjectPrefix, maxPayload: maxPayload}
+}
+
+// Write -
+func (o *Outbox) Write(ctx context.Context, tx Writer, events ...stream.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	messages := make([]models.SinkMessage, 0, len(events))
+	for i := range events {
+		data, err := o.marshal(newRecord(events[i]))
+		if err != nil {
+			return err
+		}
+		messages = append(messages, models.SinkMessage{
+			Subject:   o.Subject(events[i].Network, events[i].Kind),
+			Payload:   data,
+			CreatedAt: now,
+		})
+	}
+	return tx.SaveSinkMessages(ctx, messages...)
+}
+
+func (o *Outbox) marshal(record Record) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil || len(data) <= o.maxPayload {
+		return data, err
+	}
+	record.Operation = nil
+	record.Truncated = true
+	return json.Marshal(record)
+}
+
+// Subject - returns subject of the messages about operations of the kind in the network: `<prefix>.<network>.<kind>`
+func (o *Outbox) Subject(network, kind string) string {
+	if kind == "" {
+		kind = "unknown"
+	}
+	return strings.Join([]string{o.prefix, network, kind}, ".")
+}
+
+func newRecord(event stream.Event) Record {
+	return Record{
+		Version:     SchemaVersion,
+		Type:        event.Type,
+		Network:     event.Network,
+		Hash:        event.Hash,
+		Kind:        event.Kind,
+		Status:      event.Status,
+		Level:       event.Level,
+		Source:      event.Source,
+		Destination: event.Destination,
+		Entrypoint:  event.Entrypoint,
+		Node:        event.Node,
+		Timestamp:   event.Timestamp,
+		Operation:   event.Data,
+	}
+}
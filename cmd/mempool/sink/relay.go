@@ -0,0 +1,106 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/dipdup-io/workerpool"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultBatchSize = 100
+	relayInterval    = time.Second
+)
+
+// Relay - publishes messages from the outbox table to the sink and removes them after the sink confirmed them
+type Relay struct {
+	db        *database.Bun
+	sink      Sink
+	batchSize int
+	g         workerpool.Group
+}
+
+// NewRelay -
+func NewRelay(db *database.Bun, sink Sink, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Relay{
+		db:        db,
+		sink:      sink,
+		batchSize: batchSize,
+		g:         workerpool.NewGroup(),
+	}
+}
+
+// Init - creates outbox table. It has to be called before indexers start writing to the outbox.
+func (r *Relay) Init(ctx context.Context) error {
+	if _, err := r.db.DB().NewCreateTable().Model((*models.SinkMessage)(nil)).IfNotExists().Exec(ctx); err != nil {
+		return errors.Wrap(err, "create sink_outbox table")
+	}
+	return database.MakeComments(ctx, r.db, &models.SinkMessage{})
+}
+
+// Start -
+func (r *Relay) Start(ctx context.Context) {
+	r.g.GoCtx(ctx, r.run)
+}
+
+// Close -
+func (r *Relay) Close() error {
+	r.g.Wait()
+	return r.sink.Close()
+}
+
+func (r *Relay) run(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.flush(ctx); err != nil {
+				log.Err(err).Msg("sink relay")
+			}
+		}
+	}
+}
+
+// flush - publishes batches while the outbox is not empty
+func (r *Relay) flush(ctx context.Context) error {
+	for {
+		rows, err := models.GetSinkMessages(ctx, r.db.DB(), r.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		messages := make([]Message, len(rows))
+		ids := make([]uint64, len(rows))
+		for i := range rows {
+			messages[i] = Message{
+				Subject: rows[i].Subject,
+				Data:    rows[i].Payload,
+			}
+			ids[i] = rows[i].ID
+		}
+
+		if err := r.sink.Publish(ctx, messages...); err != nil {
+			return errors.Wrap(err, "publish")
+		}
+		if err := models.DeleteSinkMessages(ctx, r.db.DB(), ids...); err != nil {
+			return err
+		}
+		if len(rows) < r.batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/config"
+	"github.com/pkg/errors"
+)
+
+// sink types
+const (
+	TypeNats = "nats"
+)
+
+// Message -
+type Message struct {
+	Subject string
+	Data    []byte
+}
+
+// Sink - message broker client. `Publish` returns only after the broker has accepted all the messages.
+type Sink interface {
+	Publish(ctx context.Context, messages ...Message) error
+	Close() error
+}
+
+// New - creates sink by its config
+func New(cfg *config.Sink) (Sink, error) {
+	if cfg == nil {
+		return nil, errors.New("nil sink config")
+	}
+	switch cfg.Type {
+	case TypeNats:
+		return NewNats(cfg.URL)
+	default:
+		return nil, errors.Errorf("unknown sink type: %s", cfg.Type)
+	}
+}
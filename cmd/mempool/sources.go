@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/mempool/cmd/mempool/michelson"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/dipdup-net/mempool/cmd/mempool/receiver"
+	"github.com/dipdup-net/mempool/cmd/mempool/simulator"
+	"github.com/dipdup-net/mempool/cmd/mempool/tzkt"
+)
+
+// ChainInfo - source of chain parameters which are requested once at the start of the indexer
+type ChainInfo interface {
+	Constants(ctx context.Context, blockID string) (node.Constants, error)
+	Header(ctx context.Context, blockID string) (node.Header, error)
+	Metadata(ctx context.Context, blockID string) (node.BlockMetadata, error)
+}
+
+// OperationSource - source of mempool operations
+type OperationSource interface {
+	Start(ctx context.Context)
+	Operations() <-chan receiver.Message
+	Close() error
+}
+
+// ChainSource - source of blocks and operations included into the chain
+type ChainSource interface {
+	Connect(ctx context.Context) error
+	Subscribe() error
+	Close() error
+	Operations() <-chan tzkt.OperationMessage
+	Blocks() <-chan tzkt.BlockMessage
+	Sync(ctx context.Context, indexerLevel uint64)
+	GetBlocks(ctx context.Context, limit, state uint64) ([]tzkt.BlockMessage, error)
+	GetBlock(ctx context.Context, hash string, level uint64) (tzkt.BlockMessage, error)
+	Delegates(ctx context.Context, limit, offset int64) ([]data.Delegate, error)
+	Rights(ctx context.Context, level uint64) ([]data.Right, error)
+}
+
+// OperationSimulator - predicts results of mempool operations
+type OperationSimulator interface {
+	Simulate(ctx context.Context, operation simulator.Operation) (models.Simulation, error)
+}
+
+// IndexerOption -
+type IndexerOption func(*sources)
+
+// sources - data sources of the indexer. Sources which are not set by options are created from the config.
+type sources struct {
+	info       ChainInfo
+	operations OperationSource
+	chain      ChainSource
+	scripts    michelson.ScriptSource
+	simulator  OperationSimulator
+}
+
+// WithChainInfo - replaces the node RPC which chain parameters are requested from
+func WithChainInfo(info ChainInfo) IndexerOption {
+	return func(s *sources) {
+		s.info = info
+	}
+}
+
+// WithOperationSource - replaces node mempool monitors
+func WithOperationSource(operations OperationSource) IndexerOption {
+	return func(s *sources) {
+		s.operations = operations
+	}
+}
+
+// WithChainSource - replaces TzKT
+func WithChainSource(chain ChainSource) IndexerOption {
+	return func(s *sources) {
+		s.chain = chain
+	}
+}
+
+// WithScriptSource - replaces the node RPC which contract scripts are requested from
+func WithScriptSource(scripts michelson.ScriptSource) IndexerOption {
+	return func(s *sources) {
+		s.scripts = scripts
+	}
+}
+
+// WithSimulator - replaces the node RPC which operations are simulated by
+func WithSimulator(simulator OperationSimulator) IndexerOption {
+	return func(s *sources) {
+		s.simulator = simulator
+	}
+}
@@ -0,0 +1,832 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/pkg/errors"
+)
+
+// Memory - storage which keeps data in memory of the process. It's intended for edge deployments where data
+// should not survive restarts and for tests. Transactions are serialized and can't be nested.
+type Memory struct {
+	memoryTx
+
+	data *memoryData
+	mx   sync.Mutex
+}
+
+// NewMemory -
+func NewMemory() *Memory {
+	m := &Memory{
+		data: newMemoryData(),
+	}
+	m.memoryTx = memoryTx{memoryData: m.data, mx: &m.mx}
+	return m
+}
+
+// RunInTx -
+func (m *Memory) RunInTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.data.journal = make([]func(), 0)
+	defer func() {
+		m.data.journal = nil
+	}()
+
+	if err := fn(ctx, memoryTx{memoryData: m.data}); err != nil {
+		m.data.rollback()
+		return err
+	}
+	return nil
+}
+
+// State -
+func (m *Memory) State(ctx context.Context, name string) (*database.State, error) {
+	defer m.lock()()
+
+	state, ok := m.data.states[name]
+	if !ok {
+		return new(database.State), sql.ErrNoRows
+	}
+	result := *state
+	return &result, nil
+}
+
+// CreateState -
+func (m *Memory) CreateState(ctx context.Context, state *database.State) error {
+	defer m.lock()()
+
+	if _, ok := m.data.states[state.IndexName]; ok {
+		return errors.Errorf("state already exists: %s", state.IndexName)
+	}
+	state.CreatedAt = int(time.Now().Unix())
+	state.UpdatedAt = state.CreatedAt
+
+	stored := *state
+	m.data.states[state.IndexName] = &stored
+	return nil
+}
+
+// DeleteState -
+func (m *Memory) DeleteState(ctx context.Context, state *database.State) error {
+	defer m.lock()()
+
+	delete(m.data.states, state.IndexName)
+	return nil
+}
+
+// Close -
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Simulations - returns copies of stored simulations of the network sorted by hash
+func (m *Memory) Simulations(network string) []models.Simulation {
+	defer m.lock()()
+
+	result := make([]models.Simulation, 0)
+	for _, simulation := range m.data.simulations {
+		if simulation.Network == network {
+			result = append(result, *simulation)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Hash < result[j].Hash
+	})
+	return result
+}
+
+// MevSuspects - returns copies of stored suspects of the network sorted by victim and front hashes
+func (m *Memory) MevSuspects(network string) []models.MevSuspect {
+	defer m.lock()()
+
+	result := make([]models.MevSuspect, 0)
+	for _, suspect := range m.data.suspects {
+		if suspect.Network == network {
+			result = append(result, *suspect)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Victim != result[j].Victim {
+			return result[i].Victim < result[j].Victim
+		}
+		return result[i].Front < result[j].Front
+	})
+	return result
+}
+
+// StatusHistory - returns copies of stored status transitions of the network in the order of writing
+func (m *Memory) StatusHistory(network string) []models.StatusHistory {
+	defer m.lock()()
+
+	result := make([]models.StatusHistory, 0)
+	for i := range m.data.history {
+		if m.data.history[i].Network == network {
+			result = append(result, m.data.history[i])
+		}
+	}
+	return result
+}
+
+// Operations - returns copies of stored operations of the kinds in the network sorted by hash
+func (m *Memory) Operations(network string, kinds ...string) ([]any, error) {
+	defer m.lock()()
+
+	result := make([]any, 0)
+	for _, table := range m.data.tables(kinds) {
+		for _, row := range m.data.operations[table] {
+			if operation(row).Network == network {
+				result = append(result, copyRow(row))
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return operation(result[i]).Hash < operation(result[j]).Hash
+	})
+	return result, nil
+}
+
+type memoryData struct {
+	operations  map[reflect.Type]map[string]any
+	gasStats    map[string]*models.GasStats
+	estimates   map[string]*models.FeeEstimate
+	simulations map[string]*models.Simulation
+	suspects    map[string]*models.MevSuspect
+	blocks      map[string]*models.Block
+	states      map[string]*database.State
+	history     []models.StatusHistory
+	messages    []models.SinkMessage
+	historyID   uint64
+	messageID   uint64
+
+	// journal - undo actions of the running transaction. It's nil outside of transaction.
+	journal []func()
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		operations:  make(map[reflect.Type]map[string]any),
+		gasStats:    make(map[string]*models.GasStats),
+		estimates:   make(map[string]*models.FeeEstimate),
+		simulations: make(map[string]*models.Simulation),
+		suspects:    make(map[string]*models.MevSuspect),
+		blocks:      make(map[string]*models.Block),
+		states:      make(map[string]*database.State),
+		history:     make([]models.StatusHistory, 0),
+		messages:    make([]models.SinkMessage, 0),
+	}
+}
+
+func (d *memoryData) record(undo func()) {
+	if d.journal != nil {
+		d.journal = append(d.journal, undo)
+	}
+}
+
+func (d *memoryData) rollback() {
+	for i := len(d.journal) - 1; i >= 0; i-- {
+		d.journal[i]()
+	}
+}
+
+// backup - records the current value of the struct to restore it on rollback
+func backup[T any](d *memoryData, ptr *T) {
+	if d.journal == nil {
+		return
+	}
+	prev := *ptr
+	d.record(func() { *ptr = prev })
+}
+
+func (d *memoryData) backupRow(row any) {
+	if d.journal == nil {
+		return
+	}
+	value := reflect.ValueOf(row).Elem()
+	prev := reflect.New(value.Type()).Elem()
+	prev.Set(value)
+	d.record(func() { value.Set(prev) })
+}
+
+func (d *memoryData) insertRow(table reflect.Type, key string, row any) {
+	rows, ok := d.operations[table]
+	if !ok {
+		rows = make(map[string]any)
+		d.operations[table] = rows
+	}
+	rows[key] = row
+	d.record(func() { delete(rows, key) })
+}
+
+func (d *memoryData) deleteRow(table reflect.Type, key string) {
+	rows := d.operations[table]
+	row, ok := rows[key]
+	if !ok {
+		return
+	}
+	delete(rows, key)
+	d.record(func() { rows[key] = row })
+}
+
+// tables - returns types of the models of the kinds without duplicates
+func (d *memoryData) tables(kinds []string) []reflect.Type {
+	result := make([]reflect.Type, 0, len(kinds))
+	for _, kind := range kinds {
+		model, err := models.ModelByKind(kind)
+		if err != nil {
+			continue
+		}
+		typ := reflect.TypeOf(model).Elem()
+		if !slices.Contains(result, typ) {
+			result = append(result, typ)
+		}
+	}
+	return result
+}
+
+// update - calls `fn` for every operation of the kinds in the network matched `where`. Rows are backed up before the call.
+func (d *memoryData) update(network string, kinds []string, where func(op *models.MempoolOperation) bool, fn func(op *models.MempoolOperation)) []string {
+	hashes := make([]string, 0)
+	for _, table := range d.tables(kinds) {
+		for _, row := range d.operations[table] {
+			op := operation(row)
+			if op.Network != network || !where(op) {
+				continue
+			}
+			d.backupRow(row)
+			fn(op)
+			op.UpdatedAt = time.Now().Unix()
+			hashes = append(hashes, op.Hash)
+		}
+	}
+	return uniqueSorted(hashes)
+}
+
+type memoryTx struct {
+	*memoryData
+
+	// mx - lock of the storage. It's nil inside transaction because the lock is already held.
+	mx *sync.Mutex
+}
+
+func (tx memoryTx) lock() func() {
+	if tx.mx == nil {
+		return func() {}
+	}
+	tx.mx.Lock()
+	return tx.mx.Unlock
+}
+
+// SaveOperation -
+func (tx memoryTx) SaveOperation(ctx context.Context, model any) (bool, error) {
+	defer tx.lock()()
+
+	return tx.saveOperation(model)
+}
+
+// SaveOperations -
+func (tx memoryTx) SaveOperations(ctx context.Context, operations ...any) ([]bool, error) {
+	defer tx.lock()()
+
+	stored := make([]bool, len(operations))
+	for i := range operations {
+		ok, err := tx.saveOperation(operations[i])
+		if err != nil {
+			return nil, err
+		}
+		stored[i] = ok
+	}
+	return stored, nil
+}
+
+func (tx memoryTx) saveOperation(model any) (bool, error) {
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return false, errors.Errorf("invalid operation model type: %T", model)
+	}
+	op := operation(model)
+	if op == nil {
+		return false, errors.Errorf("model is not an operation: %T", model)
+	}
+
+	table := value.Elem().Type()
+	key := primaryKey(value.Elem())
+	if _, ok := tx.operations[table][key]; ok {
+		return false, nil
+	}
+
+	op.CreatedAt = time.Now().Unix()
+	tx.insertRow(table, key, copyRow(model))
+	return true, nil
+}
+
+// SetInChain -
+func (tx memoryTx) SetInChain(ctx context.Context, network, hash, kind string, level uint64, includedAt int64) (bool, error) {
+	defer tx.lock()()
+
+	if _, err := models.ModelByKind(kind); err != nil {
+		return false, err
+	}
+
+	hashes := tx.update(network, []string{kind}, func(op *models.MempoolOperation) bool {
+		return op.Hash == hash
+	}, func(op *models.MempoolOperation) {
+		op.Status = models.StatusInChain
+		op.Level = level
+		op.Errors = nil
+		if includedAt > 0 {
+			op.IncludedAt = includedAt
+		}
+	})
+	return len(hashes) > 0, nil
+}
+
+// SetStatus -
+func (tx memoryTx) SetStatus(ctx context.Context, network, hash, status string, errs models.JSONB, timestamp int64, kinds ...string) (bool, error) {
+	defer tx.lock()()
+
+	hashes := tx.update(network, kinds, func(op *models.MempoolOperation) bool {
+		return op.Hash == hash && op.Status != models.StatusInChain && op.Status != models.StatusExpired
+	}, func(op *models.MempoolOperation) {
+		op.Status = status
+		op.Errors = errs
+		if status == models.StatusApplied && op.AppliedAt == 0 {
+			op.AppliedAt = timestamp
+		}
+	})
+	return len(hashes) > 0, nil
+}
+
+// SetStatuses -
+func (tx memoryTx) SetStatuses(ctx context.Context, network string, updates []models.StatusUpdate, kinds ...string) ([]bool, error) {
+	found := make([]bool, len(updates))
+	for i := range updates {
+		ok, err := tx.SetStatus(ctx, network, updates[i].Hash, updates[i].Status, updates[i].Errors, updates[i].Timestamp, kinds...)
+		if err != nil {
+			return nil, err
+		}
+		found[i] = ok
+	}
+	return found, nil
+}
+
+// SetIncludedAt -
+func (tx memoryTx) SetIncludedAt(ctx context.Context, network string, level uint64, includedAt int64, kinds ...string) error {
+	defer tx.lock()()
+
+	tx.update(network, kinds, func(op *models.MempoolOperation) bool {
+		return op.Level == level && op.Status == models.StatusInChain && op.IncludedAt == 0
+	}, func(op *models.MempoolOperation) {
+		op.IncludedAt = includedAt
+	})
+	return nil
+}
+
+// SetExpired -
+func (tx memoryTx) SetExpired(ctx context.Context, network, branch string, kinds ...string) ([]string, error) {
+	defer tx.lock()()
+
+	hashes := tx.update(network, kinds, func(op *models.MempoolOperation) bool {
+		return op.Branch == branch && op.Status == models.StatusApplied
+	}, func(op *models.MempoolOperation) {
+		op.Status = models.StatusExpired
+	})
+	return hashes, nil
+}
+
+// Rollback -
+func (tx memoryTx) Rollback(ctx context.Context, network, branch string, level uint64, kinds ...string) ([]models.StatusChange, error) {
+	defer tx.lock()()
+
+	refused := tx.update(network, kinds, func(op *models.MempoolOperation) bool {
+		return op.Branch == branch && op.Status == models.StatusApplied
+	}, func(op *models.MempoolOperation) {
+		op.Status = models.StatusBranchRefused
+	})
+	applied := tx.update(network, kinds, func(op *models.MempoolOperation) bool {
+		return op.Status == models.StatusInChain && op.Level == level
+	}, func(op *models.MempoolOperation) {
+		op.Status = models.StatusApplied
+		op.Level = 0
+		op.IncludedAt = 0
+	})
+
+	changes := make([]models.StatusChange, 0, len(refused)+len(applied))
+	for _, hash := range refused {
+		changes = append(changes, models.StatusChange{Hash: hash, Status: models.StatusBranchRefused})
+	}
+	for _, hash := range applied {
+		changes = append(changes, models.StatusChange{Hash: hash, Status: models.StatusApplied})
+	}
+	return changes, nil
+}
+
+// DeleteOldOperations -
+func (tx memoryTx) DeleteOldOperations(ctx context.Context, timeout uint64, status string, limit int, kinds ...string) (int, error) {
+	defer tx.lock()()
+
+	var deleted int
+	ts := time.Now().Unix() - int64(timeout)
+	for _, table := range tx.tables(kinds) {
+		var count int
+		for key, row := range tx.operations[table] {
+			if limit > 0 && count == limit {
+				break
+			}
+			op := operation(row)
+			if op.UpdatedAt < ts && (status == "" || op.Status == status) {
+				tx.deleteRow(table, key)
+				count++
+			}
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// SaveGasStats -
+func (tx memoryTx) SaveGasStats(ctx context.Context, stats *models.GasStats) error {
+	defer tx.lock()()
+
+	return tx.saveGasStats(stats, stats.TotalGasUsed+stats.LevelInChain+stats.LevelInMempool > 0)
+}
+
+// SaveMempoolGasStats -
+func (tx memoryTx) SaveMempoolGasStats(ctx context.Context, stats ...models.GasStats) error {
+	defer tx.lock()()
+
+	for i := range stats {
+		if err := tx.saveGasStats(&stats[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx memoryTx) saveGasStats(stats *models.GasStats, upsert bool) error {
+	stats.UpdatedAt = time.Now().Unix()
+
+	key := stats.Network + "/" + stats.Hash
+	current, ok := tx.gasStats[key]
+	if !ok {
+		stored := *stats
+		tx.gasStats[key] = &stored
+		tx.record(func() { delete(tx.gasStats, key) })
+		return nil
+	}
+	if !upsert {
+		return errors.Errorf("gas stats already exist: %s", key)
+	}
+
+	backup(tx.memoryData, current)
+	current.TotalGasUsed += stats.TotalGasUsed
+	current.TotalFee += stats.TotalFee
+	if stats.LevelInChain > 0 {
+		current.LevelInChain = stats.LevelInChain
+	}
+	if stats.LevelInMempool > 0 && current.LevelInMempool == 0 {
+		current.LevelInMempool = stats.LevelInMempool
+	}
+	if stats.Size > 0 {
+		current.Size = stats.Size
+	}
+	current.UpdatedAt = stats.UpdatedAt
+	return nil
+}
+
+// DeleteOldGasStats -
+func (tx memoryTx) DeleteOldGasStats(ctx context.Context, timeout uint64, limit int) (int, error) {
+	defer tx.lock()()
+
+	var deleted int
+	ts := time.Now().Unix() - int64(timeout)
+	for key, stats := range tx.gasStats {
+		if limit > 0 && deleted == limit {
+			break
+		}
+		if stats.UpdatedAt >= ts {
+			continue
+		}
+		delete(tx.gasStats, key)
+		tx.record(func() { tx.gasStats[key] = stats })
+		deleted++
+	}
+	return deleted, nil
+}
+
+// IncludedGasStats -
+func (tx memoryTx) IncludedGasStats(ctx context.Context, network string) ([]models.GasStats, error) {
+	defer tx.lock()()
+
+	result := make([]models.GasStats, 0)
+	for _, stats := range tx.gasStats {
+		if stats.Network == network && stats.LevelInMempool > 0 && stats.LevelInChain > 0 && stats.TotalGasUsed > 0 {
+			result = append(result, *stats)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Hash < result[j].Hash
+	})
+	return result, nil
+}
+
+// SaveFeeEstimates -
+func (tx memoryTx) SaveFeeEstimates(ctx context.Context, estimates ...models.FeeEstimate) error {
+	defer tx.lock()()
+
+	now := time.Now().Unix()
+	for i := range estimates {
+		estimates[i].UpdatedAt = now
+
+		key := fmt.Sprintf("%s/%d/%d", estimates[i].Network, estimates[i].Blocks, estimates[i].Confidence)
+		if current, ok := tx.estimates[key]; ok {
+			backup(tx.memoryData, current)
+			*current = estimates[i]
+			continue
+		}
+
+		stored := estimates[i]
+		tx.estimates[key] = &stored
+		tx.record(func() { delete(tx.estimates, key) })
+	}
+	return nil
+}
+
+// SaveSimulations -
+func (tx memoryTx) SaveSimulations(ctx context.Context, simulations ...models.Simulation) error {
+	defer tx.lock()()
+
+	now := time.Now().Unix()
+	for i := range simulations {
+		simulations[i].UpdatedAt = now
+
+		key := simulations[i].Network + "/" + simulations[i].Hash
+		if current, ok := tx.simulations[key]; ok {
+			backup(tx.memoryData, current)
+			*current = simulations[i]
+			continue
+		}
+
+		stored := simulations[i]
+		tx.simulations[key] = &stored
+		tx.record(func() { delete(tx.simulations, key) })
+	}
+	return nil
+}
+
+// DeleteOldSimulations -
+func (tx memoryTx) DeleteOldSimulations(ctx context.Context, timeout uint64, limit int) (int, error) {
+	defer tx.lock()()
+
+	var deleted int
+	ts := time.Now().Unix() - int64(timeout)
+	for key, simulation := range tx.simulations {
+		if limit > 0 && deleted == limit {
+			break
+		}
+		if simulation.UpdatedAt >= ts {
+			continue
+		}
+		delete(tx.simulations, key)
+		tx.record(func() { tx.simulations[key] = simulation })
+		deleted++
+	}
+	return deleted, nil
+}
+
+// SaveMevSuspects -
+func (tx memoryTx) SaveMevSuspects(ctx context.Context, suspects ...models.MevSuspect) error {
+	defer tx.lock()()
+
+	for i := range suspects {
+		key := fmt.Sprintf("%s/%s/%s/%s", suspects[i].Network, suspects[i].Kind, suspects[i].Victim, suspects[i].Front)
+		if _, ok := tx.suspects[key]; ok {
+			continue
+		}
+		stored := suspects[i]
+		tx.suspects[key] = &stored
+		tx.record(func() { delete(tx.suspects, key) })
+	}
+	return nil
+}
+
+// DeleteOldMevSuspects -
+func (tx memoryTx) DeleteOldMevSuspects(ctx context.Context, timeout uint64, limit int) (int, error) {
+	defer tx.lock()()
+
+	var deleted int
+	ts := time.Now().Unix() - int64(timeout)
+	for key, suspect := range tx.suspects {
+		if limit > 0 && deleted == limit {
+			break
+		}
+		if suspect.DetectedAt >= ts {
+			continue
+		}
+		delete(tx.suspects, key)
+		tx.record(func() { tx.suspects[key] = suspect })
+		deleted++
+	}
+	return deleted, nil
+}
+
+// SaveStatusHistory -
+func (tx memoryTx) SaveStatusHistory(ctx context.Context, history ...models.StatusHistory) error {
+	defer tx.lock()()
+
+	if len(history) == 0 {
+		return nil
+	}
+
+	length, lastID := len(tx.history), tx.historyID
+	tx.record(func() {
+		tx.history = tx.history[:length]
+		tx.historyID = lastID
+	})
+
+	for i := range history {
+		tx.historyID++
+		history[i].ID = tx.historyID
+		tx.history = append(tx.history, history[i])
+	}
+	return nil
+}
+
+// DeleteOldStatusHistory -
+func (tx memoryTx) DeleteOldStatusHistory(ctx context.Context, timeout uint64, limit int) (int, error) {
+	defer tx.lock()()
+
+	prev := slices.Clone(tx.history)
+	ts := time.Now().Add(-time.Duration(timeout) * time.Second)
+
+	var deleted int
+	tx.history = slices.DeleteFunc(tx.history, func(history models.StatusHistory) bool {
+		if (limit > 0 && deleted == limit) || !history.Timestamp.Before(ts) {
+			return false
+		}
+		deleted++
+		return true
+	})
+	if deleted > 0 {
+		tx.record(func() { tx.history = prev })
+	}
+	return deleted, nil
+}
+
+// SaveSinkMessages -
+func (tx memoryTx) SaveSinkMessages(ctx context.Context, messages ...models.SinkMessage) error {
+	defer tx.lock()()
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	length, lastID := len(tx.messages), tx.messageID
+	tx.record(func() {
+		tx.messages = tx.messages[:length]
+		tx.messageID = lastID
+	})
+
+	for i := range messages {
+		tx.messageID++
+		messages[i].ID = tx.messageID
+		tx.messages = append(tx.messages, messages[i])
+	}
+	return nil
+}
+
+// EndorsementsWithoutBaker -
+func (tx memoryTx) EndorsementsWithoutBaker(ctx context.Context, network string, limit, offset int) ([]models.Endorsement, error) {
+	defer tx.lock()()
+
+	endorsements := make([]models.Endorsement, 0)
+	for _, row := range tx.operations[reflect.TypeOf(models.Endorsement{})] {
+		endorsement := row.(*models.Endorsement)
+		if endorsement.Network == network && endorsement.Baker == "" {
+			endorsements = append(endorsements, *endorsement)
+		}
+	}
+	sort.Slice(endorsements, func(i, j int) bool {
+		if endorsements[i].Level == endorsements[j].Level {
+			return endorsements[i].Hash < endorsements[j].Hash
+		}
+		return endorsements[i].Level < endorsements[j].Level
+	})
+
+	if offset >= len(endorsements) {
+		return nil, nil
+	}
+	endorsements = endorsements[offset:]
+	if limit > 0 && limit < len(endorsements) {
+		endorsements = endorsements[:limit]
+	}
+	return endorsements, nil
+}
+
+// SetEndorsementBaker -
+func (tx memoryTx) SetEndorsementBaker(ctx context.Context, endorsement *models.Endorsement) error {
+	defer tx.lock()()
+
+	row, ok := tx.operations[reflect.TypeOf(models.Endorsement{})][primaryKey(reflect.ValueOf(endorsement).Elem())]
+	if !ok {
+		return nil
+	}
+	stored := row.(*models.Endorsement)
+	backup(tx.memoryData, stored)
+	stored.Baker = endorsement.Baker
+	stored.UpdatedAt = time.Now().Unix()
+	return nil
+}
+
+// SetParametersDecoded -
+func (tx memoryTx) SetParametersDecoded(ctx context.Context, transaction *models.Transaction) error {
+	defer tx.lock()()
+
+	row, ok := tx.operations[reflect.TypeOf(models.Transaction{})][primaryKey(reflect.ValueOf(transaction).Elem())]
+	if !ok {
+		return nil
+	}
+	stored := row.(*models.Transaction)
+	backup(tx.memoryData, stored)
+	stored.ParametersDecoded = transaction.ParametersDecoded
+	stored.UpdatedAt = time.Now().Unix()
+	return nil
+}
+
+// SaveBlocks -
+func (tx memoryTx) SaveBlocks(ctx context.Context, blocks ...models.Block) error {
+	defer tx.lock()()
+
+	for i := range blocks {
+		key := blocks[i].Network + "/" + blocks[i].Hash
+		if _, ok := tx.blocks[key]; ok {
+			continue
+		}
+		stored := blocks[i]
+		tx.blocks[key] = &stored
+		tx.record(func() { delete(tx.blocks, key) })
+	}
+	return nil
+}
+
+// DeleteBlocks -
+func (tx memoryTx) DeleteBlocks(ctx context.Context, network string, hashes ...string) error {
+	defer tx.lock()()
+
+	for i := range hashes {
+		key := network + "/" + hashes[i]
+		block, ok := tx.blocks[key]
+		if !ok {
+			continue
+		}
+		delete(tx.blocks, key)
+		tx.record(func() { tx.blocks[key] = block })
+	}
+	return nil
+}
+
+// Blocks -
+func (tx memoryTx) Blocks(ctx context.Context, network string) ([]models.Block, error) {
+	defer tx.lock()()
+
+	result := make([]models.Block, 0)
+	for _, block := range tx.blocks {
+		if block.Network == network {
+			result = append(result, *block)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Level < result[j].Level
+	})
+	return result, nil
+}
+
+// UpdateState -
+func (tx memoryTx) UpdateState(ctx context.Context, state *database.State) error {
+	defer tx.lock()()
+
+	current, ok := tx.states[state.IndexName]
+	if !ok {
+		return nil
+	}
+	state.UpdatedAt = int(time.Now().Unix())
+
+	backup(tx.memoryData, current)
+	*current = *state
+	return nil
+}
+
+func uniqueSorted(arr []string) []string {
+	slices.Sort(arr)
+	return slices.Compact(arr)
+}
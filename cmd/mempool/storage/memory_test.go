@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+)
+
+func newTransaction(hash, branch, status string, counter int64) *models.Transaction {
+	return &models.Transaction{
+		MempoolOperation: models.MempoolOperation{
+			Network: "mainnet",
+			Hash:    hash,
+			Branch:  branch,
+			Status:  status,
+			Kind:    node.KindTransaction,
+		},
+		Counter: counter,
+	}
+}
+
+func statuses(t *testing.T, m *Memory) map[string]string {
+	t.Helper()
+
+	operations, err := m.Operations("mainnet", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]string)
+	for i := range operations {
+		tx := operations[i].(*models.Transaction)
+		result[tx.Hash] = tx.Status
+	}
+	return result
+}
+
+func TestMemory_SaveOperation(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	tests := []struct {
+		name  string
+		model any
+		want  bool
+	}{
+		{
+			name:  "new operation",
+			model: newTransaction("oo1", "BL1", models.StatusApplied, 1),
+			want:  true,
+		}, {
+			name:  "duplicate",
+			model: newTransaction("oo1", "BL1", models.StatusApplied, 1),
+			want:  false,
+		}, {
+			name:  "another content of the same group",
+			model: newTransaction("oo1", "BL1", models.StatusApplied, 2),
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.SaveOperation(ctx, tt.model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("SaveOperation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := m.SaveOperation(ctx, &models.GasStats{}); err == nil {
+		t.Error("SaveOperation() expected error for non-operation model")
+	}
+}
+
+func TestMemory_Statuses(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	for _, model := range []*models.Transaction{
+		newTransaction("oo1", "BL1", models.StatusApplied, 1),
+		newTransaction("oo2", "BL1", models.StatusApplied, 1),
+		newTransaction("oo3", "BL2", models.StatusApplied, 1),
+		newTransaction("oo4", "BL2", models.StatusRefused, 1),
+		newTransaction("oo5", "BL0", models.StatusApplied, 1),
+	} {
+		if _, err := m.SaveOperation(ctx, model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, hash := range []string{"oo1", "oo5"} {
+		found, err := m.SetInChain(ctx, "mainnet", hash, node.KindTransaction, 100, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found {
+			t.Fatal("SetInChain() operation was not found")
+		}
+	}
+	if found, _ := m.SetStatus(ctx, "mainnet", "oo1", models.StatusRefused, nil, 0, node.KindTransaction); found {
+		t.Error("SetStatus() changed status of included operation")
+	}
+
+	expired, err := m.SetExpired(ctx, "mainnet", "BL2", node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0] != "oo3" {
+		t.Errorf("SetExpired() = %v, want [oo3]", expired)
+	}
+
+	changes, err := m.Rollback(ctx, "mainnet", "BL1", 100, node.KindTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.StatusChange{
+		{Hash: "oo2", Status: models.StatusBranchRefused},
+		{Hash: "oo1", Status: models.StatusApplied},
+		{Hash: "oo5", Status: models.StatusApplied},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Rollback() = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Rollback()[%d] = %v, want %v", i, changes[i], want[i])
+		}
+	}
+
+	got := statuses(t, m)
+	for hash, status := range map[string]string{
+		"oo1": models.StatusApplied,
+		"oo2": models.StatusBranchRefused,
+		"oo3": models.StatusExpired,
+		"oo4": models.StatusRefused,
+		"oo5": models.StatusApplied,
+	} {
+		if got[hash] != status {
+			t.Errorf("status of %s = %s, want %s", hash, got[hash], status)
+		}
+	}
+}
+
+func TestMemory_RunInTx(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	state := &database.State{IndexName: "mempool_mainnet", Level: 10}
+	if err := m.CreateState(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.SaveOperation(ctx, newTransaction("oo1", "BL1", models.StatusApplied, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	errRollback := errors.New("rollback")
+	err := m.RunInTx(ctx, func(ctx context.Context, tx Tx) error {
+		if _, err := tx.SaveOperation(ctx, newTransaction("oo2", "BL1", models.StatusApplied, 1)); err != nil {
+			return err
+		}
+		if _, err := tx.SetStatus(ctx, "mainnet", "oo1", models.StatusRefused, nil, 0, node.KindTransaction); err != nil {
+			return err
+		}
+		if err := tx.SaveStatusHistory(ctx, models.StatusHistory{Hash: "oo1"}); err != nil {
+			return err
+		}
+		if err := tx.UpdateState(ctx, &database.State{IndexName: "mempool_mainnet", Level: 11}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("RunInTx() error = %v, want %v", err, errRollback)
+	}
+
+	got := statuses(t, m)
+	if len(got) != 1 || got["oo1"] != models.StatusApplied {
+		t.Errorf("operations after rollback = %v", got)
+	}
+	if len(m.data.history) != 0 {
+		t.Errorf("status history after rollback = %v", m.data.history)
+	}
+	current, err := m.State(ctx, "mempool_mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Level != 10 {
+		t.Errorf("state level after rollback = %d, want 10", current.Level)
+	}
+
+	if _, err := m.State(ctx, "unknown"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("State() error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestMemory_SaveGasStats(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	for _, stats := range []models.GasStats{
+		{Network: "mainnet", Hash: "oo1", LevelInMempool: 10, Size: 200},
+		{Network: "mainnet", Hash: "oo1", LevelInChain: 11, TotalGasUsed: 1000, TotalFee: 500},
+		{Network: "mainnet", Hash: "oo1", LevelInMempool: 12},
+		{Network: "mainnet", Hash: "oo2", LevelInChain: 11, TotalGasUsed: 1000, TotalFee: 500},
+	} {
+		if err := m.SaveGasStats(ctx, &stats); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	included, err := m.IncludedGasStats(ctx, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(included) != 1 {
+		t.Fatalf("IncludedGasStats() returned %d items, want 1", len(included))
+	}
+	want := models.GasStats{Network: "mainnet", Hash: "oo1", LevelInMempool: 10, LevelInChain: 11, TotalGasUsed: 1000, TotalFee: 500, Size: 200}
+	want.UpdatedAt = included[0].UpdatedAt
+	if included[0] != want {
+		t.Errorf("IncludedGasStats() = %+v, want %+v", included[0], want)
+	}
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+)
+
+// operation - returns pointer to the embedded `MempoolOperation` of the model or nil if the model is not an operation
+func operation(model any) *models.MempoolOperation {
+	value := reflect.ValueOf(model)
+	if value.Kind() != reflect.Ptr {
+		return nil
+	}
+	field := value.Elem().FieldByName("MempoolOperation")
+	if !field.IsValid() {
+		return nil
+	}
+	op, _ := field.Addr().Interface().(*models.MempoolOperation)
+	return op
+}
+
+func copyRow(row any) any {
+	value := reflect.ValueOf(row).Elem()
+	result := reflect.New(value.Type())
+	result.Elem().Set(value)
+	return result.Interface()
+}
+
+// primaryKey - joins values of the fields marked with `bun:",pk"` tag
+func primaryKey(value reflect.Value) string {
+	parts := make([]string, 0, 3)
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		if field.Anonymous || !isPrimaryKey(field) {
+			continue
+		}
+		parts = append(parts, fmt.Sprint(value.FieldByIndex(field.Index).Interface()))
+	}
+	return strings.Join(parts, "/")
+}
+
+func isPrimaryKey(field reflect.StructField) bool {
+	options := strings.Split(field.Tag.Get("bun"), ",")
+	for i := 1; i < len(options); i++ {
+		if options[i] == "pk" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"slices"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+	"github.com/uptrace/bun"
+)
+
+// Postgres - storage over bun connection to PostgreSQL
+type Postgres struct {
+	postgresTx
+
+	db *database.Bun
+}
+
+// PostgresOption -
+type PostgresOption func(*Postgres)
+
+// WithArchive - operation tables are partitioned by `created_at` in archive mode. Their primary keys contain `created_at`,
+// so insert can't detect existing operations by conflict and they are filtered out by select before insert.
+func WithArchive() PostgresOption {
+	return func(p *Postgres) {
+		p.archive = true
+	}
+}
+
+// NewPostgres -
+func NewPostgres(db *database.Bun, opts ...PostgresOption) *Postgres {
+	p := &Postgres{
+		postgresTx: postgresTx{db: db.DB()},
+		db:         db,
+	}
+	for i := range opts {
+		opts[i](p)
+	}
+	return p
+}
+
+// RunInTx -
+func (p *Postgres) RunInTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	return p.db.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, postgresTx{db: tx, archive: p.archive})
+	})
+}
+
+// State -
+func (p *Postgres) State(ctx context.Context, name string) (*database.State, error) {
+	return p.db.State(ctx, name)
+}
+
+// CreateState -
+func (p *Postgres) CreateState(ctx context.Context, state *database.State) error {
+	return p.db.CreateState(ctx, state)
+}
+
+// DeleteState -
+func (p *Postgres) DeleteState(ctx context.Context, state *database.State) error {
+	return p.db.DeleteState(ctx, state)
+}
+
+// Close -
+func (p *Postgres) Close() error {
+	return p.db.Close()
+}
+
+type postgresTx struct {
+	db      bun.IDB
+	archive bool
+}
+
+// SaveOperation -
+func (tx postgresTx) SaveOperation(ctx context.Context, model any) (bool, error) {
+	if tx.archive {
+		stored, err := tx.SaveOperations(ctx, model)
+		if err != nil {
+			return false, err
+		}
+		return stored[0], nil
+	}
+
+	result, err := tx.db.NewInsert().Model(model).On("CONFLICT DO NOTHING").Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	if count, err := result.RowsAffected(); err == nil && count == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// SaveOperations -
+func (tx postgresTx) SaveOperations(ctx context.Context, operations ...any) ([]bool, error) {
+	types := make([]reflect.Type, 0)
+	groups := make(map[reflect.Type][]int)
+	for i := range operations {
+		typ := reflect.TypeOf(operations[i])
+		if _, ok := groups[typ]; !ok {
+			types = append(types, typ)
+		}
+		groups[typ] = append(groups[typ], i)
+	}
+
+	stored := make([]bool, len(operations))
+	for _, typ := range types {
+		indices := groups[typ]
+		if tx.archive {
+			existing, err := tx.existingKeys(ctx, typ, operations, indices)
+			if err != nil {
+				return nil, err
+			}
+			indices = slices.DeleteFunc(indices, func(i int) bool {
+				return existing[primaryKey(reflect.ValueOf(operations[i]).Elem())]
+			})
+			if len(indices) == 0 {
+				continue
+			}
+		}
+
+		rows := reflect.New(reflect.SliceOf(typ))
+		for _, i := range indices {
+			rows.Elem().Set(reflect.Append(rows.Elem(), reflect.ValueOf(operations[i])))
+		}
+
+		inserted := reflect.New(reflect.SliceOf(typ))
+		if _, err := tx.db.NewInsert().
+			Model(rows.Interface()).
+			On("CONFLICT DO NOTHING").
+			Returning("?PKs").
+			Exec(ctx, inserted.Interface()); err != nil {
+			return nil, err
+		}
+
+		keys := make(map[string]bool, inserted.Elem().Len())
+		for j := 0; j < inserted.Elem().Len(); j++ {
+			keys[primaryKey(inserted.Elem().Index(j).Elem())] = true
+		}
+		// rows with the same key in one statement: only the first one is inserted
+		for _, i := range indices {
+			key := primaryKey(reflect.ValueOf(operations[i]).Elem())
+			stored[i] = keys[key]
+			keys[key] = false
+		}
+	}
+	return stored, nil
+}
+
+// existingKeys - returns primary keys of the operations which are already stored
+func (tx postgresTx) existingKeys(ctx context.Context, typ reflect.Type, operations []any, indices []int) (map[string]bool, error) {
+	rows := reflect.New(reflect.SliceOf(typ))
+	for _, i := range indices {
+		rows.Elem().Set(reflect.Append(rows.Elem(), reflect.ValueOf(operations[i])))
+	}
+
+	existing := reflect.New(reflect.SliceOf(typ))
+	if err := tx.db.NewSelect().
+		Model(rows.Interface()).
+		ColumnExpr("?PKs").
+		WherePK().
+		Scan(ctx, existing.Interface()); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, existing.Elem().Len())
+	for j := 0; j < existing.Elem().Len(); j++ {
+		keys[primaryKey(existing.Elem().Index(j).Elem())] = true
+	}
+	return keys, nil
+}
+
+// SetInChain -
+func (tx postgresTx) SetInChain(ctx context.Context, network, hash, kind string, level uint64, includedAt int64) (bool, error) {
+	return models.SetInChain(ctx, tx.db, network, hash, kind, level, includedAt)
+}
+
+// SetStatus -
+func (tx postgresTx) SetStatus(ctx context.Context, network, hash, status string, errs models.JSONB, timestamp int64, kinds ...string) (bool, error) {
+	return models.SetStatus(ctx, tx.db, network, hash, status, errs, timestamp, kinds...)
+}
+
+// SetStatuses -
+func (tx postgresTx) SetStatuses(ctx context.Context, network string, updates []models.StatusUpdate, kinds ...string) ([]bool, error) {
+	return models.SetStatuses(ctx, tx.db, network, updates, kinds...)
+}
+
+// SetIncludedAt -
+func (tx postgresTx) SetIncludedAt(ctx context.Context, network string, level uint64, includedAt int64, kinds ...string) error {
+	return models.SetIncludedAt(ctx, tx.db, network, level, includedAt, kinds...)
+}
+
+// SetExpired -
+func (tx postgresTx) SetExpired(ctx context.Context, network, branch string, kinds ...string) ([]string, error) {
+	return models.SetExpired(ctx, tx.db, network, branch, kinds...)
+}
+
+// Rollback -
+func (tx postgresTx) Rollback(ctx context.Context, network, branch string, level uint64, kinds ...string) ([]models.StatusChange, error) {
+	return models.Rollback(ctx, tx.db, network, branch, level, kinds...)
+}
+
+// DeleteOldOperations -
+func (tx postgresTx) DeleteOldOperations(ctx context.Context, timeout uint64, status string, limit int, kinds ...string) (int, error) {
+	return models.DeleteOldOperations(ctx, tx.db, timeout, status, limit, kinds...)
+}
+
+// SaveGasStats -
+func (tx postgresTx) SaveGasStats(ctx context.Context, stats *models.GasStats) error {
+	return stats.Save(ctx, tx.db)
+}
+
+// SaveMempoolGasStats -
+func (tx postgresTx) SaveMempoolGasStats(ctx context.Context, stats ...models.GasStats) error {
+	return models.SaveMempoolGasStats(ctx, tx.db, stats...)
+}
+
+// DeleteOldGasStats -
+func (tx postgresTx) DeleteOldGasStats(ctx context.Context, timeout uint64, limit int) (int, error) {
+	return models.DeleteOldGasStats(ctx, tx.db, timeout, limit)
+}
+
+// IncludedGasStats -
+func (tx postgresTx) IncludedGasStats(ctx context.Context, network string) ([]models.GasStats, error) {
+	return models.IncludedGasStats(ctx, tx.db, network)
+}
+
+// SaveFeeEstimates -
+func (tx postgresTx) SaveFeeEstimates(ctx context.Context, estimates ...models.FeeEstimate) error {
+	return models.SaveFeeEstimates(ctx, tx.db, estimates...)
+}
+
+// SaveSimulations -
+func (tx postgresTx) SaveSimulations(ctx context.Context, simulations ...models.Simulation) error {
+	return models.SaveSimulations(ctx, tx.db, simulations...)
+}
+
+// DeleteOldSimulations -
+func (tx postgresTx) DeleteOldSimulations(ctx context.Context, timeout uint64, limit int) (int, error) {
+	return models.DeleteOldSimulations(ctx, tx.db, timeout, limit)
+}
+
+// SaveMevSuspects -
+func (tx postgresTx) SaveMevSuspects(ctx context.Context, suspects ...models.MevSuspect) error {
+	return models.SaveMevSuspects(ctx, tx.db, suspects...)
+}
+
+// DeleteOldMevSuspects -
+func (tx postgresTx) DeleteOldMevSuspects(ctx context.Context, timeout uint64, limit int) (int, error) {
+	return models.DeleteOldMevSuspects(ctx, tx.db, timeout, limit)
+}
+
+// SaveStatusHistory -
+func (tx postgresTx) SaveStatusHistory(ctx context.Context, history ...models.StatusHistory) error {
+	return models.SaveStatusHistory(ctx, tx.db, history...)
+}
+
+// DeleteOldStatusHistory -
+func (tx postgresTx) DeleteOldStatusHistory(ctx context.Context, timeout uint64, limit int) (int, error) {
+	return models.DeleteOldStatusHistory(ctx, tx.db, timeout, limit)
+}
+
+// SaveSinkMessages -
+func (tx postgresTx) SaveSinkMessages(ctx context.Context, messages ...models.SinkMessage) error {
+	return models.SaveSinkMessages(ctx, tx.db, messages...)
+}
+
+// EndorsementsWithoutBaker -
+func (tx postgresTx) EndorsementsWithoutBaker(ctx context.Context, network string, limit, offset int) ([]models.Endorsement, error) {
+	return models.EndorsementsWithoutBaker(ctx, tx.db, network, limit, offset)
+}
+
+// SetEndorsementBaker -
+func (tx postgresTx) SetEndorsementBaker(ctx context.Context, endorsement *models.Endorsement) error {
+	return models.SetEndorsementBaker(ctx, tx.db, endorsement)
+}
+
+// SetParametersDecoded -
+func (tx postgresTx) SetParametersDecoded(ctx context.Context, transaction *models.Transaction) error {
+	return models.SetParametersDecoded(ctx, tx.db, transaction)
+}
+
+// SaveBlocks -
+func (tx postgresTx) SaveBlocks(ctx context.Context, blocks ...models.Block) error {
+	return models.SaveBlocks(ctx, tx.db, blocks...)
+}
+
+// DeleteBlocks -
+func (tx postgresTx) DeleteBlocks(ctx context.Context, network string, hashes ...string) error {
+	return models.DeleteBlocks(ctx, tx.db, network, hashes...)
+}
+
+// Blocks -
+func (tx postgresTx) Blocks(ctx context.Context, network string) ([]models.Block, error) {
+	return models.GetBlocks(ctx, tx.db, network)
+}
+
+// UpdateState -
+func (tx postgresTx) UpdateState(ctx context.Context, state *database.State) error {
+	_, err := tx.db.NewUpdate().Model(state).WherePK().Exec(ctx)
+	return err
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/dipdup-net/mempool/cmd/mempool/models"
+)
+
+// Tx - data operations of the indexer. All of them are executed in the transaction if they are called inside `RunInTx`.
+type Tx interface {
+	// SaveOperation - stores the operation model if it doesn't exist yet. Returns true if the model was stored.
+	SaveOperation(ctx context.Context, model any) (bool, error)
+	// SaveOperations - stores the operation models which don't exist yet. Models of the same type are written by one statement.
+	// Returns flags whether each model was stored.
+	SaveOperations(ctx context.Context, operations ...any) ([]bool, error)
+	SetInChain(ctx context.Context, network, hash, kind string, level uint64, includedAt int64) (bool, error)
+	SetStatus(ctx context.Context, network, hash, status string, errs models.JSONB, timestamp int64, kinds ...string) (bool, error)
+	// SetStatuses - applies status updates of the batch by one statement per kind. Returns flags whether the operation of each update was found.
+	SetStatuses(ctx context.Context, network string, updates []models.StatusUpdate, kinds ...string) ([]bool, error)
+	SetIncludedAt(ctx context.Context, network string, level uint64, includedAt int64, kinds ...string) error
+	SetExpired(ctx context.Context, network, branch string, kinds ...string) ([]string, error)
+	Rollback(ctx context.Context, network, branch string, level uint64, kinds ...string) ([]models.StatusChange, error)
+	// DeleteOldOperations - deletes operations which were not updated during `timeout` seconds. If `limit` is positive
+	// not more than `limit` rows of each kind are deleted. Returns count of deleted rows.
+	DeleteOldOperations(ctx context.Context, timeout uint64, status string, limit int, kinds ...string) (int, error)
+
+	SaveGasStats(ctx context.Context, stats *models.GasStats) error
+	SaveMempoolGasStats(ctx context.Context, stats ...models.GasStats) error
+	DeleteOldGasStats(ctx context.Context, timeout uint64, limit int) (int, error)
+	IncludedGasStats(ctx context.Context, network string) ([]models.GasStats, error)
+	SaveFeeEstimates(ctx context.Context, estimates ...models.FeeEstimate) error
+	// SaveSimulations - stores simulations replacing previous results of the same operations
+	SaveSimulations(ctx context.Context, simulations ...models.Simulation) error
+	DeleteOldSimulations(ctx context.Context, timeout uint64, limit int) (int, error)
+	// SaveMevSuspects - stores suspects which don't exist yet
+	SaveMevSuspects(ctx context.Context, suspects ...models.MevSuspect) error
+	DeleteOldMevSuspects(ctx context.Context, timeout uint64, limit int) (int, error)
+
+	SaveStatusHistory(ctx context.Context, history ...models.StatusHistory) error
+	DeleteOldStatusHistory(ctx context.Context, timeout uint64, limit int) (int, error)
+	SaveSinkMessages(ctx context.Context, messages ...models.SinkMessage) error
+
+	EndorsementsWithoutBaker(ctx context.Context, network string, limit, offset int) ([]models.Endorsement, error)
+	SetEndorsementBaker(ctx context.Context, endorsement *models.Endorsement) error
+	SetParametersDecoded(ctx context.Context, transaction *models.Transaction) error
+
+	// SaveBlocks - stores blocks of the queue which don't exist yet. Blocks returns stored blocks of the network sorted by level.
+	SaveBlocks(ctx context.Context, blocks ...models.Block) error
+	DeleteBlocks(ctx context.Context, network string, hashes ...string) error
+	Blocks(ctx context.Context, network string) ([]models.Block, error)
+
+	UpdateState(ctx context.Context, state *database.State) error
+}
+
+// Storage - storage backend of the indexer
+type Storage interface {
+	Tx
+	database.StateRepository
+
+	// RunInTx - runs `fn` in transaction. The transaction is rolled back if `fn` returns error.
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error
+
+	io.Closer
+}
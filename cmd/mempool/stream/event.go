@@ -0,0 +1,37 @@
+package stream
+
+import "time"
+
+// EventType -
+type EventType string
+
+// event types
+const (
+	// EventTypeOperation - operation was stored for the first time
+	EventTypeOperation EventType = "operation"
+	// EventTypeStatus - status of the stored operation was changed
+	EventTypeStatus EventType = "status"
+	// EventTypeReorg - blocks were orphaned. `Hash` and `Level` of the event point to the common ancestor, `Data` is Reorg.
+	EventTypeReorg EventType = "reorg"
+)
+
+// Reorg - data of reorg event
+type Reorg struct {
+	Orphaned []string `json:"orphaned"`
+}
+
+// Event - processed mempool operation or its status change
+type Event struct {
+	Type        EventType `json:"type"`
+	Network     string    `json:"network"`
+	Hash        string    `json:"hash"`
+	Kind        string    `json:"kind,omitempty"`
+	Status      string    `json:"status"`
+	Level       uint64    `json:"level,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Entrypoint  string    `json:"entrypoint,omitempty"`
+	Node        string    `json:"node,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+	Data        any       `json:"data,omitempty"`
+}
@@ -0,0 +1,69 @@
+package stream
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Filter - subscriber filter. Empty field matches any value. Non-empty field requires the event attribute to be one of the values.
+type Filter struct {
+	Networks    map[string]struct{}
+	Kinds       map[string]struct{}
+	Accounts    map[string]struct{}
+	Entrypoints map[string]struct{}
+	Statuses    map[string]struct{}
+}
+
+// NewFilter - parses filter from query parameters `kind`, `account`, `entrypoint` and `status`.
+// Each parameter may be repeated or contain comma-separated values.
+func NewFilter(query url.Values) Filter {
+	return Filter{
+		Kinds:       parseSet(query["kind"]),
+		Accounts:    parseSet(query["account"]),
+		Entrypoints: parseSet(query["entrypoint"]),
+		Statuses:    parseSet(query["status"]),
+	}
+}
+
+// Match - returns true if the event satisfies the filter. Account matches the source or the destination of the event.
+func (f Filter) Match(event Event) bool {
+	if !matchSet(f.Networks, event.Network) {
+		return false
+	}
+	if !matchSet(f.Kinds, event.Kind) {
+		return false
+	}
+	if !matchSet(f.Statuses, event.Status) {
+		return false
+	}
+	if !matchSet(f.Entrypoints, event.Entrypoint) {
+		return false
+	}
+	if len(f.Accounts) > 0 && !matchSet(f.Accounts, event.Source) && !matchSet(f.Accounts, event.Destination) {
+		return false
+	}
+	return true
+}
+
+func matchSet(set map[string]struct{}, value string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	_, ok := set[value]
+	return ok
+}
+
+func parseSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{})
+	for i := range values {
+		for _, value := range strings.Split(values[i], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				set[value] = struct{}{}
+			}
+		}
+	}
+	return set
+}
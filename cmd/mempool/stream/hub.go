@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const defaultBufferSize = 1024
+
+// Subscription -
+type Subscription struct {
+	id      uint64
+	filter  Filter
+	events  chan Event
+	dropped atomic.Uint64
+	onDrop  func(Event)
+}
+
+// Events - channel of events matched the subscription filter
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped - returns count of events which were skipped because the subscriber was too slow
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Hub - delivers published events to subscribers. Publishing never blocks: if subscriber buffer is full the event is dropped for the subscriber.
+type Hub struct {
+	subscribers map[uint64]*Subscription
+	lastID      uint64
+	mx          sync.RWMutex
+}
+
+// NewHub -
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe - creates subscription with the filter. If `size` is not positive default buffer size is used.
+func (h *Hub) Subscribe(filter Filter, size int) *Subscription {
+	return h.SubscribeWithOverflow(filter, size, nil)
+}
+
+// SubscribeWithOverflow - creates subscription which passes events skipped because of the full buffer to `onDrop`.
+// `onDrop` is called by the publisher, so it must not block.
+func (h *Hub) SubscribeWithOverflow(filter Filter, size int, onDrop func(Event)) *Subscription {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.lastID++
+	s := &Subscription{
+		id:     h.lastID,
+		filter: filter,
+		events: make(chan Event, size),
+		onDrop: onDrop,
+	}
+	h.subscribers[s.id] = s
+	return s
+}
+
+// Unsubscribe - removes subscription and closes its channel
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if _, ok := h.subscribers[s.id]; !ok {
+		return
+	}
+	delete(h.subscribers, s.id)
+	close(s.events)
+}
+
+// Publish -
+func (h *Hub) Publish(events ...Event) {
+	if h == nil {
+		return
+	}
+
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+
+	for _, s := range h.subscribers {
+		for i := range events {
+			if !s.filter.Match(events[i]) {
+				continue
+			}
+			select {
+			case s.events <- events[i]:
+			default:
+				s.dropped.Add(1)
+				if s.onDrop != nil {
+					s.onDrop(events[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	event := Event{
+		Network:     "mainnet",
+		Kind:        "transaction",
+		Status:      "applied",
+		Source:      "tz1source",
+		Destination: "KT1destination",
+		Entrypoint:  "swap",
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "empty filter", query: "", want: true},
+		{name: "kind", query: "kind=delegation,transaction", want: true},
+		{name: "other kind", query: "kind=delegation", want: false},
+		{name: "account is destination", query: "account=KT1destination", want: true},
+		{name: "account is source", query: "account=tz1other&account=tz1source", want: true},
+		{name: "other account", query: "account=tz1other", want: false},
+		{name: "entrypoint and status", query: "entrypoint=swap&status=applied", want: true},
+		{name: "other status", query: "entrypoint=swap&status=refused", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := NewFilter(query).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	transactions := hub.Subscribe(Filter{Kinds: map[string]struct{}{"transaction": {}}}, 1)
+	all := hub.Subscribe(Filter{}, 10)
+
+	hub.Publish(
+		Event{Hash: "a", Kind: "transaction"},
+		Event{Hash: "b", Kind: "delegation"},
+		Event{Hash: "c", Kind: "transaction"},
+	)
+
+	if got := len(all.Events()); got != 3 {
+		t.Errorf("all subscriber received %d events, want 3", got)
+	}
+	if got := (<-transactions.Events()).Hash; got != "a" {
+		t.Errorf("transactions subscriber received %s, want a", got)
+	}
+	if got := transactions.Dropped(); got != 1 {
+		t.Errorf("transactions subscriber dropped %d events, want 1", got)
+	}
+
+	hub.Unsubscribe(transactions)
+	hub.Unsubscribe(transactions)
+	if _, ok := <-transactions.Events(); ok {
+		t.Error("channel of removed subscription should be closed")
+	}
+}
+
+func TestHubPublishOverflow(t *testing.T) {
+	hub := NewHub()
+	var dropped []string
+	subscription := hub.SubscribeWithOverflow(Filter{}, 1, func(event Event) {
+		dropped = append(dropped, event.Hash)
+	})
+
+	hub.Publish(Event{Hash: "a"}, Event{Hash: "b"}, Event{Hash: "c"})
+
+	if got := (<-subscription.Events()).Hash; got != "a" {
+		t.Errorf("subscriber received %s, want a", got)
+	}
+	if len(dropped) != 2 || dropped[0] != "b" || dropped[1] != "c" {
+		t.Errorf("dropped = %v, want [b c]", dropped)
+	}
+}
@@ -84,10 +84,11 @@ func (msg *OperationMessage) copy() OperationMessage {
 	return message
 }
 
-// BlockMessage -
+// BlockMessage - `Predecessor` is empty if the source couldn't receive it.
 type BlockMessage struct {
-	Hash      string             `json:"hash"`
-	Level     uint64             `json:"level"`
-	Type      events.MessageType `json:"type"`
-	Timestamp time.Time
+	Hash        string             `json:"hash"`
+	Predecessor string             `json:"predecessor,omitempty"`
+	Level       uint64             `json:"level"`
+	Type        events.MessageType `json:"type"`
+	Timestamp   time.Time
 }
@@ -0,0 +1,13 @@
+package tzkt
+
+import "github.com/dipdup-net/mempool/cmd/mempool/record"
+
+// TzKTOption -
+type TzKTOption func(*TzKT)
+
+// WithRecorder - writes every block and operation message to the recording
+func WithRecorder(recorder *record.Recorder) TzKTOption {
+	return func(tzkt *TzKT) {
+		tzkt.recorder = recorder
+	}
+}
@@ -0,0 +1,218 @@
+package tzkt
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// replayHistorySize - count of last replayed blocks which are kept for GetBlocks
+const replayHistorySize = 4096
+
+// operationsPayload - recorded form of OperationMessage
+type operationsPayload struct {
+	Level      uint64           `json:"level"`
+	Block      string           `json:"block"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Operations []data.Operation `json:"operations"`
+}
+
+func newOperationsPayload(msg OperationMessage) operationsPayload {
+	payload := operationsPayload{
+		Level:     msg.Level,
+		Block:     msg.Block,
+		Timestamp: msg.Timestamp,
+	}
+	msg.Hash.Range(func(key, value any) bool {
+		if operation, ok := value.(data.Operation); ok {
+			payload.Operations = append(payload.Operations, operation)
+		}
+		return true
+	})
+	sort.Slice(payload.Operations, func(i, j int) bool {
+		return payload.Operations[i].ID < payload.Operations[j].ID
+	})
+	return payload
+}
+
+func (payload operationsPayload) message() OperationMessage {
+	msg := newOperationMessage()
+	msg.Level = payload.Level
+	msg.Block = payload.Block
+	msg.Timestamp = payload.Timestamp
+	for i := range payload.Operations {
+		msg.Hash.Store(payload.Operations[i].Hash, payload.Operations[i])
+	}
+	return msg
+}
+
+func (tzkt *TzKT) sendBlock(block BlockMessage) {
+	switch block.Type {
+	case events.MessageTypeData:
+		tzkt.head = block
+	case events.MessageTypeReorg:
+		tzkt.head = BlockMessage{}
+	}
+	Record(tzkt.recorder, record.SourceTzKT, block)
+	tzkt.blocks <- block
+}
+
+func (tzkt *TzKT) sendOperations(msg OperationMessage) {
+	Record(tzkt.recorder, record.SourceTzKT, msg)
+	tzkt.operations <- msg
+}
+
+// Record - writes block or operations message of the chain source to the recording. Nil recorder is allowed.
+func Record(recorder *record.Recorder, source string, msg any) {
+	if recorder == nil {
+		return
+	}
+
+	entry := record.Entry{Source: source}
+	var payload any
+	switch typed := msg.(type) {
+	case BlockMessage:
+		entry.Kind = record.KindBlock
+		payload = typed
+	case OperationMessage:
+		entry.Kind = record.KindOperations
+		payload = newOperationsPayload(typed)
+	default:
+		log.Error().Msgf("can't record message of type %T", msg)
+		return
+	}
+	if err := recorder.Record(entry, payload); err != nil {
+		log.Err(err).Str("source", source).Msg("record chain message")
+	}
+}
+
+// Replay - chain source which feeds blocks and operations from the recording instead of TzKT or the node.
+// Delegates and rights are not recorded, so replay returns empty lists for them.
+type Replay struct {
+	history    []BlockMessage
+	mx         sync.RWMutex
+	operations chan OperationMessage
+	blocks     chan BlockMessage
+}
+
+// NewReplay -
+func NewReplay() *Replay {
+	return &Replay{
+		history:    make([]BlockMessage, 0),
+		operations: make(chan OperationMessage, 1024),
+		blocks:     make(chan BlockMessage, 1024),
+	}
+}
+
+// Connect -
+func (r *Replay) Connect(ctx context.Context) error { return nil }
+
+// Subscribe -
+func (r *Replay) Subscribe() error { return nil }
+
+// Sync - recording already contains messages of synchronization, so it does nothing
+func (r *Replay) Sync(ctx context.Context, indexerLevel uint64) {}
+
+// Close -
+func (r *Replay) Close() error {
+	close(r.operations)
+	close(r.blocks)
+	return nil
+}
+
+// Operations -
+func (r *Replay) Operations() <-chan OperationMessage {
+	return r.operations
+}
+
+// Blocks -
+func (r *Replay) Blocks() <-chan BlockMessage {
+	return r.blocks
+}
+
+// GetBlocks - returns replayed blocks not higher than `state` in descending order
+func (r *Replay) GetBlocks(ctx context.Context, limit, state uint64) ([]BlockMessage, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	blocks := make([]BlockMessage, 0, limit)
+	for i := len(r.history) - 1; i >= 0 && uint64(len(blocks)) < limit; i-- {
+		if r.history[i].Level <= state {
+			blocks = append(blocks, r.history[i])
+		}
+	}
+	return blocks, nil
+}
+
+// GetBlock - returns the replayed block by hash
+func (r *Replay) GetBlock(ctx context.Context, hash string, level uint64) (BlockMessage, error) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	for i := len(r.history) - 1; i >= 0; i-- {
+		if r.history[i].Hash == hash {
+			return r.history[i], nil
+		}
+	}
+	return BlockMessage{}, errors.Errorf("block %s is not replayed", hash)
+}
+
+// Delegates -
+func (r *Replay) Delegates(ctx context.Context, limit, offset int64) ([]data.Delegate, error) {
+	return nil, nil
+}
+
+// Rights -
+func (r *Replay) Rights(ctx context.Context, level uint64) ([]data.Right, error) {
+	return nil, nil
+}
+
+// Push - decodes the recorded message and sends it to the output channel. Entries of other sources are ignored.
+func (r *Replay) Push(ctx context.Context, entry record.Entry) error {
+	if entry.Source != record.SourceTzKT && entry.Source != record.SourceNodeChain {
+		return nil
+	}
+
+	switch entry.Kind {
+	case record.KindBlock:
+		var block BlockMessage
+		if err := json.Unmarshal(entry.Payload, &block); err != nil {
+			return errors.Wrap(err, "decode block")
+		}
+		r.mx.Lock()
+		r.history = append(r.history, block)
+		if len(r.history) > replayHistorySize {
+			r.history = r.history[len(r.history)-replayHistorySize:]
+		}
+		r.mx.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r.blocks <- block:
+		}
+	case record.KindOperations:
+		var payload operationsPayload
+		if err := json.Unmarshal(entry.Payload, &payload); err != nil {
+			return errors.Wrap(err, "decode operations")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case r.operations <- payload.message():
+		}
+	default:
+		return errors.Errorf("unknown recorded %s message: %s", entry.Source, entry.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package tzkt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
+)
+
+func TestReplay_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tzkt.jsonl.gz")
+
+	recorder, err := record.NewRecorder(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := NewTzKT("http://localhost", nil, nil, WithRecorder(recorder))
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	gasUsed := uint64(1000)
+	for level := uint64(100); level < 103; level++ {
+		msg := newOperationMessage()
+		msg.Level = level
+		msg.Block = "BL" + string(rune('a'+level-100))
+		msg.Timestamp = timestamp
+		msg.Hash.Store("oo1", data.Operation{ID: 1, Level: level, Hash: "oo1", GasUsed: &gasUsed})
+		msg.Hash.Store("oo2", data.Operation{ID: 2, Level: level, Hash: "oo2"})
+		source.sendOperations(msg)
+		source.sendBlock(BlockMessage{Hash: msg.Block, Level: level, Type: events.MessageTypeData, Timestamp: timestamp})
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewReplay()
+	if err := record.Play(ctx, path, 0, replay.Push); err != nil {
+		t.Fatal(err)
+	}
+
+	for level := uint64(100); level < 103; level++ {
+		msg := <-replay.Operations()
+		if msg.Level != level || !msg.Timestamp.Equal(timestamp) {
+			t.Errorf("operations message: level = %d, timestamp = %s", msg.Level, msg.Timestamp)
+		}
+		value, ok := msg.Hash.Load("oo1")
+		if !ok {
+			t.Fatalf("operation oo1 of level %d is lost", level)
+		}
+		if operation := value.(data.Operation); operation.GasUsed == nil || *operation.GasUsed != gasUsed {
+			t.Errorf("gas used of oo1 is not replayed: %v", operation.GasUsed)
+		}
+		if _, ok := msg.Hash.Load("oo2"); !ok {
+			t.Fatalf("operation oo2 of level %d is lost", level)
+		}
+
+		block := <-replay.Blocks()
+		if block.Level != level || block.Hash != msg.Block || block.Type != events.MessageTypeData {
+			t.Errorf("unexpected block: %+v", block)
+		}
+	}
+
+	blocks, err := replay.GetBlocks(ctx, 2, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 || blocks[0].Level != 101 || blocks[1].Level != 100 {
+		t.Errorf("GetBlocks returned %+v", blocks)
+	}
+}
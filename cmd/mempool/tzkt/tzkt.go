@@ -11,6 +11,7 @@ import (
 	"github.com/dipdup-net/go-lib/tzkt/api"
 	"github.com/dipdup-net/go-lib/tzkt/data"
 	"github.com/dipdup-net/go-lib/tzkt/events"
+	"github.com/dipdup-net/mempool/cmd/mempool/record"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
@@ -26,6 +27,10 @@ type TzKT struct {
 	state    uint64
 	kinds    []string
 	accounts []string
+	recorder *record.Recorder
+
+	// head - the last sent data block. It's the predecessor of the next block unless the reorg happened.
+	head BlockMessage
 
 	operations chan OperationMessage
 	blocks     chan BlockMessage
@@ -33,14 +38,14 @@ type TzKT struct {
 }
 
 // NewTzKT - TzKT constructor
-func NewTzKT(url string, accounts []string, kinds []string) *TzKT {
+func NewTzKT(url string, accounts []string, kinds []string, opts ...TzKTOption) *TzKT {
 	tzktKinds := make([]string, 0)
 	for i := range kinds {
 		if kind, ok := toTzKTKinds[kinds[i]]; ok {
 			tzktKinds = append(tzktKinds, kind)
 		}
 	}
-	return &TzKT{
+	tzkt := &TzKT{
 		client:     events.NewTzKT(fmt.Sprintf("%s/%s", strings.TrimSuffix(url, "/"), "v1/ws")),
 		kinds:      tzktKinds,
 		accounts:   accounts,
@@ -49,6 +54,10 @@ func NewTzKT(url string, accounts []string, kinds []string) *TzKT {
 		blocks:     make(chan BlockMessage, 1024),
 		g:          workerpool.NewGroup(),
 	}
+	for i := range opts {
+		opts[i](tzkt)
+	}
+	return tzkt
 }
 
 // Connect -
@@ -71,7 +80,7 @@ func (tzkt *TzKT) Connect(ctx context.Context) error {
 							log.Err(err).Msg("handleOperationMessage")
 						}
 					case events.ChannelBlocks:
-						if err := tzkt.handleBlockMessage(msg); err != nil {
+						if err := tzkt.handleBlockMessage(ctx, msg); err != nil {
 							log.Err(err).Msg("handleBlockMessage")
 						}
 					}
@@ -87,7 +96,17 @@ func (tzkt *TzKT) Connect(ctx context.Context) error {
 						tzkt.Sync(ctx, msg.State)
 					}
 					tzkt.state = msg.State
-				case events.MessageTypeReorg, events.MessageTypeSubscribed:
+				case events.MessageTypeReorg:
+					if msg.Channel != events.ChannelBlocks {
+						continue
+					}
+					log.Warn().Uint64("old_state", tzkt.state).Uint64("new_level", msg.State).Msg("reorg")
+					tzkt.sendBlock(BlockMessage{
+						Type:  events.MessageTypeReorg,
+						Level: msg.State,
+					})
+					tzkt.state = msg.State
+				case events.MessageTypeSubscribed:
 				}
 
 			}
@@ -120,24 +139,49 @@ func (tzkt *TzKT) Blocks() <-chan BlockMessage {
 	return tzkt.blocks
 }
 
-func (tzkt *TzKT) handleBlockMessage(msg events.Message) error {
+func (tzkt *TzKT) handleBlockMessage(ctx context.Context, msg events.Message) error {
 	if msg.Body == nil {
 		return nil
 	}
 	blocks := msg.Body.([]data.Block)
 	for i := range blocks {
-		tzkt.blocks <- BlockMessage{
-			Hash:      blocks[i].Hash,
-			Level:     blocks[i].Level,
-			Type:      msg.Type,
-			Timestamp: blocks[i].Timestamp.UTC(),
-		}
+		tzkt.sendBlock(BlockMessage{
+			Hash:        blocks[i].Hash,
+			Predecessor: tzkt.predecessor(ctx, blocks[i].Level),
+			Level:       blocks[i].Level,
+			Type:        msg.Type,
+			Timestamp:   blocks[i].Timestamp.UTC(),
+		})
 		tzkt.state = blocks[i].Level
 	}
 
 	return nil
 }
 
+// predecessor - returns hash of the block preceding the level. Blocks channel of TzKT doesn't contain it, so it's taken
+// from the last sent block or requested from the API if the block of the previous level wasn't sent or the reorg happened.
+func (tzkt *TzKT) predecessor(ctx context.Context, level uint64) string {
+	if level == 0 {
+		return ""
+	}
+	if tzkt.head.Hash != "" && tzkt.head.Level == level-1 {
+		return tzkt.head.Hash
+	}
+
+	blocks, err := tzkt.api.GetBlocks(ctx, map[string]string{
+		"level":         strconv.FormatUint(level-1, 10),
+		"select.fields": "hash,level",
+	})
+	if err != nil {
+		log.Err(err).Uint64("level", level-1).Msg("get predecessor")
+		return ""
+	}
+	if len(blocks) == 0 {
+		return ""
+	}
+	return blocks[0].Hash
+}
+
 func (tzkt *TzKT) handleOperationMessage(msg events.Message) error {
 	if msg.Body == nil {
 		return nil
@@ -158,7 +202,7 @@ func (tzkt *TzKT) handleUpdateMessage(operations []any) error {
 		}
 	}
 
-	tzkt.operations <- message
+	tzkt.sendOperations(message)
 	tzkt.state = message.Level
 	return nil
 }
@@ -485,13 +529,14 @@ func (tzkt *TzKT) processSync(ctx context.Context, state syncState, msg *Operati
 				msg.Level = operation.Level
 				msg.Block = operation.Block
 			case msg.Level != operation.Level:
-				tzkt.blocks <- BlockMessage{
-					Type:      events.MessageTypeData,
-					Level:     msg.Level,
-					Hash:      msg.Block,
-					Timestamp: msg.Timestamp.UTC(),
-				}
-				tzkt.operations <- msg.copy()
+				tzkt.sendBlock(BlockMessage{
+					Type:        events.MessageTypeData,
+					Level:       msg.Level,
+					Hash:        msg.Block,
+					Predecessor: tzkt.predecessor(ctx, msg.Level),
+					Timestamp:   msg.Timestamp.UTC(),
+				})
+				tzkt.sendOperations(msg.copy())
 				msg.clear()
 			}
 
@@ -501,12 +546,13 @@ func (tzkt *TzKT) processSync(ctx context.Context, state syncState, msg *Operati
 	}
 
 	if msg.Level > 0 && state.finished() {
-		tzkt.blocks <- BlockMessage{
-			Type:  events.MessageTypeData,
-			Level: msg.Level,
-			Hash:  msg.Block,
-		}
-		tzkt.operations <- msg.copy()
+		tzkt.sendBlock(BlockMessage{
+			Type:        events.MessageTypeData,
+			Level:       msg.Level,
+			Hash:        msg.Block,
+			Predecessor: tzkt.predecessor(ctx, msg.Level),
+		})
+		tzkt.sendOperations(msg.copy())
 		msg.clear()
 	}
 	return nil
@@ -615,11 +661,11 @@ func getOperations[M data.OperationConstraint](ctx context.Context, table *table
 	return nil
 }
 
-// GetBlocks -
+// GetBlocks - returns `limit` blocks up to `state` sorted by level descending. One more block is requested to set the predecessor of the lowest one.
 func (tzkt *TzKT) GetBlocks(ctx context.Context, limit, state uint64) ([]BlockMessage, error) {
 	filters := map[string]string{
 		"sort.desc":     "level",
-		"limit":         fmt.Sprintf("%d", limit),
+		"limit":         fmt.Sprintf("%d", limit+1),
 		"level.le":      fmt.Sprintf("%d", state),
 		"select.fields": "hash,level",
 	}
@@ -629,15 +675,35 @@ func (tzkt *TzKT) GetBlocks(ctx context.Context, limit, state uint64) ([]BlockMe
 	}
 	messages := make([]BlockMessage, 0, len(blocks))
 	for i := range blocks {
-		messages = append(messages, BlockMessage{
+		if uint64(i) == limit {
+			break
+		}
+		message := BlockMessage{
 			Type:  events.MessageTypeData,
 			Hash:  blocks[i].Hash,
 			Level: blocks[i].Level,
-		})
+		}
+		if i+1 < len(blocks) && blocks[i+1].Level+1 == blocks[i].Level {
+			message.Predecessor = blocks[i+1].Hash
+		}
+		messages = append(messages, message)
 	}
 	return messages, nil
 }
 
+// GetBlock - returns the block of the main chain by hash. The level is required because TzKT doesn't return predecessors of blocks,
+// so the block of the previous level is requested with it.
+func (tzkt *TzKT) GetBlock(ctx context.Context, hash string, level uint64) (BlockMessage, error) {
+	blocks, err := tzkt.GetBlocks(ctx, 1, level)
+	if err != nil {
+		return BlockMessage{}, err
+	}
+	if len(blocks) == 0 || blocks[0].Level != level || blocks[0].Hash != hash {
+		return BlockMessage{}, errors.Errorf("block %s of %d level is not found", hash, level)
+	}
+	return blocks[0], nil
+}
+
 // Delegates -
 func (tzkt *TzKT) Delegates(ctx context.Context, limit, offset int64) ([]data.Delegate, error) {
 	return tzkt.api.GetDelegates(ctx, map[string]string{
@@ -0,0 +1,63 @@
+package tzkt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/tzkt/data"
+	"github.com/dipdup-net/go-lib/tzkt/events"
+)
+
+func TestTzKT_Reorg(t *testing.T) {
+	ctx := context.Background()
+
+	// canonical chain of TzKT after the reorg
+	chain := map[string]string{
+		"99":  "BL99",
+		"100": "BL100",
+		"101": "BL101b",
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		hash, ok := chain[r.URL.Query().Get("level")]
+		if r.URL.Path != "/v1/blocks" || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"level":` + r.URL.Query().Get("level") + `,"hash":"` + hash + `"}]`))
+	}))
+	defer server.Close()
+
+	source := NewTzKT(server.URL, nil, nil)
+	blocks := func(items ...data.Block) {
+		if err := source.handleBlockMessage(ctx, events.Message{Type: events.MessageTypeData, Body: items}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks(data.Block{Level: 100, Hash: "BL100"}, data.Block{Level: 101, Hash: "BL101"}, data.Block{Level: 102, Hash: "BL102"})
+	source.sendBlock(BlockMessage{Type: events.MessageTypeReorg, Level: 100})
+	blocks(data.Block{Level: 101, Hash: "BL101b"}, data.Block{Level: 102, Hash: "BL102b"})
+
+	want := []BlockMessage{
+		{Type: events.MessageTypeData, Level: 100, Hash: "BL100", Predecessor: "BL99"},
+		{Type: events.MessageTypeData, Level: 101, Hash: "BL101", Predecessor: "BL100"},
+		{Type: events.MessageTypeData, Level: 102, Hash: "BL102", Predecessor: "BL101"},
+		{Type: events.MessageTypeReorg, Level: 100},
+		{Type: events.MessageTypeData, Level: 101, Hash: "BL101b", Predecessor: "BL100"},
+		{Type: events.MessageTypeData, Level: 102, Hash: "BL102b", Predecessor: "BL101b"},
+	}
+	for i := range want {
+		got := <-source.Blocks()
+		got.Timestamp = want[i].Timestamp
+		if got != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if requests != 2 {
+		t.Errorf("API requests = %d, want 2: predecessors are requested only for the first block and after the reorg", requests)
+	}
+}
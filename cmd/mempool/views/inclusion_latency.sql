@@ -0,0 +1,46 @@
+create or replace view inclusion_latency as
+	select lat.network,
+	       lat.kind,
+	       lat.fee_bucket,
+	       count(*) as count,
+	       avg(lat.latency) as avg,
+	       percentile_disc(0.5) within group (order by lat.latency) as p50,
+	       percentile_disc(0.75) within group (order by lat.latency) as p75,
+	       percentile_disc(0.9) within group (order by lat.latency) as p90,
+	       percentile_disc(0.95) within group (order by lat.latency) as p95,
+	       percentile_disc(0.99) within group (order by lat.latency) as p99
+	from (
+            select network, kind,
+                case
+                    when fee < 1000 then '0-999'
+                    when fee < 2000 then '1000-1999'
+                    when fee < 5000 then '2000-4999'
+                    when fee < 10000 then '5000-9999'
+                    else '10000+'
+                end as fee_bucket,
+                greatest(included_at - first_seen_at, 0) as latency
+            from (
+                select network, kind, fee, first_seen_at, included_at from "transactions" where status = 'in_chain'
+                union all
+                select network, kind, fee, first_seen_at, included_at from delegations where status = 'in_chain'
+                union all
+                select network, kind, fee, first_seen_at, included_at from originations where status = 'in_chain'
+                union all
+                select network, kind, fee, first_seen_at, included_at from reveals where status = 'in_chain'
+            ) as ops
+            where first_seen_at is not null
+                and included_at is not null
+	) as lat
+	group by lat.network, lat.kind, lat.fee_bucket;
+
+comment on view inclusion_latency is 'Percentiles of time between the first appearance of the operation in mempool and its inclusion in a block.';
+comment on column inclusion_latency.network is 'Identifies belonging network.';
+comment on column inclusion_latency.kind is 'Type of the operation.';
+comment on column inclusion_latency.fee_bucket is 'Range of the operation fee in mutez.';
+comment on column inclusion_latency.count is 'Count of included operations.';
+comment on column inclusion_latency.avg is 'Average latency in milliseconds.';
+comment on column inclusion_latency.p50 is 'Percentile (50%) of latency in milliseconds.';
+comment on column inclusion_latency.p75 is 'Percentile (75%) of latency in milliseconds.';
+comment on column inclusion_latency.p90 is 'Percentile (90%) of latency in milliseconds.';
+comment on column inclusion_latency.p95 is 'Percentile (95%) of latency in milliseconds.';
+comment on column inclusion_latency.p99 is 'Percentile (99%) of latency in milliseconds.';
@@ -8,7 +8,7 @@ create or replace view mutez_per_gas_unit as
         from (
 		    select
                 (level_in_chain - level_in_mempool) as waiting_levels,
-                ((total_fee - 100 - 150 * 1)::float / total_gas_used) as mutez_per_gas_unit
+                ((total_fee - 100 - coalesce(nullif(size, 0), 150))::float / total_gas_used) as mutez_per_gas_unit
                 from gas_stats gs
                     where level_in_chain > 0
                         and level_in_mempool > 0